@@ -1,12 +1,19 @@
 package main
 
 import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
 	"avoid_the_space_rocks/internal/scenes"
 	"avoid_the_space_rocks/internal/scenes/attractmode"
 	"avoid_the_space_rocks/internal/scenes/gameover"
+	"avoid_the_space_rocks/internal/scenes/netgame"
 	"avoid_the_space_rocks/internal/scenes/playfield"
+	"flag"
+	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"os"
+	"path/filepath"
 )
 
 const (
@@ -14,43 +21,180 @@ const (
 	screenHeight = 768.0
 )
 
+var (
+	seed       = flag.Uint64("seed", 0, "seed for the game's random source; 0 picks one from the clock")
+	headless   = flag.Bool("headless", false, "play one game with no window or sound, then print the score")
+	replayFile = flag.String("replay", "", "play back a replay file instead of reading the keyboard")
+	replayDir  = flag.String("replay-dir", "replays", "directory to save a replay of every game in; empty saves none")
+	difficulty = flag.String("difficulty", "normal", "difficulty of the first game: easy, normal, hard, or insane")
+	mode       = flag.String("mode", "classic", "mode of the first game: classic, time-attack, survival, zen, or billiards")
+	players    = flag.Int("players", 1, "players taking turns in each new Classic game: 1 or 2")
+	ships      = flag.Int("ships", 1, "players flying at once in each new game, a spaceship each: 1 or 2")
+	friendly   = flag.Bool("friendly-fire", false, "let players flying at once shoot each other down")
+	saveFile   = flag.String("save", configPath("save.json"), "file to save a game in progress to; empty disables saving")
+	highScores = flag.String("highscores", configPath("highscores.json"), "file to keep the high scores in; empty keeps none")
+	tuningFile = flag.String("tuning", "assets/tuning.json", "file of gameplay tuning, reloaded on change with DEBUG=1; empty uses the defaults")
+	connect    = flag.String("connect", "", "play the first game on the server at this address, such as localhost:7777")
+)
+
+// configPath returns where to keep the named file in the user's config directory, or nothing if there
+// isn't one.
+func configPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "avoid_the_space_rocks", name)
+}
+
 func main() {
-	rl.InitWindow(screenWidth, screenHeight, "Avoid the Space Rocks")
-	defer rl.CloseWindow()
-	rl.InitAudioDevice()
-	defer rl.CloseAudioDevice()
+	flag.Parse()
+	session := newSession()
+
+	if *headless {
+		runHeadless(session)
+		return
+	}
 
-	rl.SetTargetFPS(60)
-	rl.SetExitKey(rl.KeyNull)
+	rp := platform.OpenRaylib(screenWidth, screenHeight, "Avoid the Space Rocks")
+	defer rp.Close()
+	platform.LogTo(rp)
+	session.Platform = rp
 
 	if os.Getenv("DEBUG") != "" {
 		rl.SetTraceLogLevel(rl.LogDebug)
 	}
 
 	sceneCode := scenes.AttractModeScene
+	if session.Replay != nil {
+		sceneCode = scenes.GameplayScene
+	} else if session.Connect != "" {
+		sceneCode = scenes.NetGameScene
+	}
 	for sceneCode != scenes.Quit {
-		rl.TraceLog(rl.LogInfo, "Starting scene code %v", sceneCode)
-		scene := initScene(sceneCode)
+		platform.Log(rl.LogInfo, "Starting scene code %v", sceneCode)
+		scene := initScene(sceneCode, session)
 		sceneCode = scene.Loop()
 		scene.Close()
 	}
 }
 
-func initScene(code scenes.SceneCode) scenes.Scene {
+// newSession sets up the session from the command line, exiting if the replay or tuning file can't be
+// read or the difficulty, mode, or number of players or ships makes no sense.
+func newSession() *scenes.Session {
+	session := &scenes.Session{
+		Seed:           *seed,
+		HighScoresPath: *highScores,
+		ReplayDir:      *replayDir,
+		SavePath:       *saveFile,
+		TuningPath:     *tuningFile,
+		Connect:        *connect,
+	}
+	d, err := core.ParseDifficulty(*difficulty)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	session.Difficulty = d
+	m, err := core.ParseMode(*mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	session.Mode = m
+	if *players < 1 || *players > 2 {
+		fmt.Fprintf(os.Stderr, "can't play with %d players; choose 1 or 2\n", *players)
+		os.Exit(1)
+	}
+	session.Players = *players
+	if *ships < 1 || *ships > 2 {
+		fmt.Fprintf(os.Stderr, "can't fly %d spaceships at once; choose 1 or 2\n", *ships)
+		os.Exit(1)
+	}
+	if *players > 1 && *ships > 1 {
+		fmt.Fprintln(os.Stderr, "players either take turns or fly together; choose -players or -ships")
+		os.Exit(1)
+	}
+	session.Ships = *ships
+	if *connect != "" && (*headless || *replayFile != "") {
+		fmt.Fprintln(os.Stderr, "a game on a server is played at the keyboard in a window; drop -headless and -replay")
+		os.Exit(1)
+	}
+	session.FriendlyFire = *friendly
+	if *tuningFile != "" {
+		tuning, err := core.LoadTuning(*tuningFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bad tuning file %v\n", err)
+			os.Exit(1)
+		}
+		session.Tuning = tuning
+	}
+	if *replayFile != "" {
+		r, err := replay.Load(*replayFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		session.Replay = r
+	}
+	return session
+}
+
+func initScene(code scenes.SceneCode, session *scenes.Session) scenes.Scene {
 	if code == scenes.AttractModeScene {
-		am := &attractmode.AttractMode{}
+		am := &attractmode.AttractMode{Session: session}
 		am.Init(screenWidth, screenHeight)
 		return am
 	} else if code == scenes.GameplayScene {
-		gm := &playfield.Gameloop{}
+		gm := &playfield.Gameloop{Session: session}
 		gm.Init(screenWidth, screenHeight)
 		return gm
 	} else if code == scenes.GameOverScene {
-		gom := &gameover.GameOverMode{}
+		gom := &gameover.GameOverMode{Session: session}
 		gom.Init(screenWidth, screenHeight)
 		return gom
+	} else if code == scenes.NetGameScene {
+		ng := &netgame.NetGame{Session: session}
+		ng.Init(screenWidth, screenHeight)
+		return ng
 	} else {
-		rl.TraceLog(rl.LogError, "Unknown scene code %v", code)
+		platform.Log(rl.LogError, "Unknown scene code %v", code)
 		return nil
 	}
 }
+
+// runHeadless plays a single game with nobody at the controls, as fast as the machine allows, and
+// prints how it went. It needs no display or audio device, so it runs anywhere.
+func runHeadless(session *scenes.Session) {
+	if session.Mode == core.ModeZen && session.Replay == nil {
+		fmt.Fprintln(os.Stderr, "a zen game only ends when the player ends it, so it can't be played headless; play a replay of one instead")
+		os.Exit(1)
+	}
+	hp := platform.NewHeadless()
+	hp.LogLevel = rl.LogInfo
+	if os.Getenv("DEBUG") != "" {
+		hp.LogLevel = rl.LogDebug
+	}
+	platform.LogTo(hp)
+	session.Platform = hp
+
+	gm := &playfield.Gameloop{Session: session}
+	gm.Init(screenWidth, screenHeight)
+	gm.Loop()
+	gm.Close()
+
+	if len(session.LastPlayers) > 1 {
+		for i, game := range session.LastPlayers {
+			fmt.Printf("player %d, seed %d: level %d, score %d\n", i+1, game.Random.Seed(), game.Level, game.Score)
+		}
+		fmt.Printf("after %d frames\n", hp.Frames())
+		return
+	}
+	game := session.LastGame
+	fmt.Printf("seed %d: level %d, score %d after %d frames\n", game.Random.Seed(), game.Level, game.Score, hp.Frames())
+	if ships := game.World.Spaceships; len(ships) > 1 {
+		for _, ship := range ships {
+			fmt.Printf("player %d: score %d\n", ship.Player+1, ship.Score)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/netplay"
+	"avoid_the_space_rocks/internal/platform"
+	"flag"
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"os"
+)
+
+var (
+	listen     = flag.String("listen", ":7777", "UDP address to listen for players on")
+	players    = flag.Int("players", 2, "players flying at once, a spaceship each; the game starts once they've all joined")
+	seed       = flag.Uint64("seed", 0, "seed for the game's random source; 0 picks one from the clock")
+	difficulty = flag.String("difficulty", "normal", "difficulty of the game: easy, normal, hard, or insane")
+	mode       = flag.String("mode", "classic", "mode of the game: classic, time-attack, survival, zen, or billiards")
+	friendly   = flag.Bool("friendly-fire", false, "let the players shoot each other down")
+	tuningFile = flag.String("tuning", "assets/tuning.json", "file of gameplay tuning; empty uses the defaults")
+)
+
+// The server runs one game, with no window or sound, for players connecting with -connect, and exits
+// once it's over. It reads the sprites to size everything, so run it from the directory with the assets.
+func main() {
+	flag.Parse()
+	config := netplay.ServerConfig{
+		Players:      *players,
+		Seed:         *seed,
+		FriendlyFire: *friendly,
+	}
+	d, err := core.ParseDifficulty(*difficulty)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	config.Difficulty = d
+	m, err := core.ParseMode(*mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	config.Mode = m
+	if *tuningFile != "" {
+		tuning, err := core.LoadTuning(*tuningFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bad tuning file %v\n", err)
+			os.Exit(1)
+		}
+		config.Tuning = tuning
+	}
+
+	hp := platform.NewHeadless()
+	hp.LogLevel = rl.LogInfo
+	if os.Getenv("DEBUG") != "" {
+		hp.LogLevel = rl.LogDebug
+	}
+	platform.LogTo(hp)
+
+	server, err := netplay.Listen(*listen, config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := server.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if game := server.Game(); game != nil {
+		fmt.Printf("seed %d: level %d, score %d\n", game.Random.Seed(), game.Level, game.Score)
+		for _, ship := range game.World.Spaceships {
+			fmt.Printf("player %d: score %d\n", ship.Player+1, ship.Score)
+		}
+	}
+}
@@ -5,7 +5,6 @@ go 1.24.5
 require github.com/gen2brain/raylib-go/raylib v0.55.1 // raylib 5.0 compatible
 
 require (
-	github.com/asaskevich/EventBus v0.0.0-20200907212545-49d423059eef
 	github.com/dustin/go-humanize v1.0.1
 	github.com/hashicorp/go-set v0.1.14
 )
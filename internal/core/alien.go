@@ -1,19 +1,19 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/core/events"
 	"avoid_the_space_rocks/internal/gameobjects"
-	"avoid_the_space_rocks/internal/utils"
-	"context"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"math"
 	"time"
 )
 
-type AlienSize int
+type AlienSize = events.AlienSize
 
 const (
-	AlienSmall AlienSize = iota
-	AlienBig
+	AlienSmall = events.AlienSmall
+	AlienBig   = events.AlienBig
 )
 
 // The sprite file specifies filename, rows, and columns
@@ -28,18 +28,22 @@ var alienSpriteFile = []struct {
 // Alien spaceships
 type Alien struct {
 	gameobjects.Rigidbody
-	spritesheet  *gameobjects.SpriteSheet
-	isAlive      bool
-	size         AlienSize
-	bulletDrift  float32
-	runnerCancel context.CancelFunc
+	spritesheet *gameobjects.SpriteSheet
+	isAlive     bool
+	size        AlienSize
+	bulletDrift float32
+	runner      *Timer
+	shape       gameobjects.Capsule // The outline GetShape hands out a pointer to
+	game        *Game
 }
 
 var _ gameobjects.Collidable = (*Alien)(nil)
 var _ gameobjects.Destructible = (*Alien)(nil)
 var _ gameobjects.GameObject = (*Alien)(nil)
+var _ gameobjects.Pushable = (*Alien)(nil)
+var _ gameobjects.Exploder = (*Alien)(nil)
 
-func NewAlien(size AlienSize, position rl.Vector2) Alien {
+func NewAlien(game *Game, size AlienSize, position rl.Vector2) Alien {
 	spriteFile := alienSpriteFile[size]
 	sheet := gameobjects.LoadSpriteSheet(spriteFile.filename, spriteFile.row, spriteFile.col)
 	alien := Alien{
@@ -52,19 +56,20 @@ func NewAlien(size AlienSize, position rl.Vector2) Alien {
 		},
 		isAlive: true,
 		size:    size,
+		game:    game,
 	}
 	return alien
 }
 
 // Update applies physics to the alien so it moves along its current direction
 func (a *Alien) Update(delta float32) error {
-	game := GetGame()
+	game := a.game
 	a.Rigidbody.ApplyPhysics(delta)
 	if game.World.IsOutsideEdges(a.Position) {
 		// If the alien goes outside the edges, we remove it from the game sometimes
-		if utils.Chance(0.2) {
+		if game.Random.Chance(0.2) {
 			a.isAlive = false
-			game.EventBus.Publish("alien:left_playfield", a.size)
+			events.Publish(game.EventBus, events.AlienLeftPlayfield{Size: a.size, Position: a.Position})
 		} else {
 			// Wrap around the edges of the playfield
 			a.Position = game.World.Wraparound(a.Position)
@@ -74,14 +79,14 @@ func (a *Alien) Update(delta float32) error {
 }
 
 // Draw renders the alien  to the screen
-func (a *Alien) Draw() error {
+func (a *Alien) Draw(p platform.Platform) error {
 	row, col, err := a.spritesheet.FrameLocation(a.frameIndex())
 	if err != nil {
-		rl.TraceLog(rl.LogError, "Error getting alien frame location: %v", err)
+		platform.Log(rl.LogError, "Error getting alien frame location: %v", err)
 		row = 0
 		col = 0
 	}
-	return a.spritesheet.Draw(row, col, a.Position, a.Rotation)
+	return a.spritesheet.DrawWrapped(p, row, col, a.Position, a.Rotation, a.game.World.Width, a.game.World.Height)
 }
 
 // IsAlive returns whether the alien is alive or not
@@ -89,6 +94,11 @@ func (a *Alien) IsAlive() bool {
 	return a.isAlive
 }
 
+// Size returns whether this is a big or small alien
+func (a *Alien) Size() AlienSize {
+	return a.size
+}
+
 // IsEnemy returns true; always true for Aliens
 func (a *Alien) IsEnemy() bool {
 	return true
@@ -99,166 +109,172 @@ func (a *Alien) GetHitbox() rl.Rectangle {
 	return a.spritesheet.GetRectangle(a.Position)
 }
 
+// GetShape returns the alien's outline for collision detection: a capsule the width of its saucer,
+// leaving out the empty corners above and below the rim.
+func (a *Alien) GetShape() gameobjects.Shape {
+	size := a.spritesheet.GetSize()
+	radius := size.Y * 0.4
+	reach := max(0, size.X/2-radius)
+	a.shape = gameobjects.Capsule{
+		A:      a.ToWorld(rl.Vector2{X: -reach}),
+		B:      a.ToWorld(rl.Vector2{X: reach}),
+		Radius: radius,
+	}
+	return &a.shape
+}
+
+// Mass returns how hard the alien is to push around.
+func (a *Alien) Mass() float32 {
+	return a.game.Tuning.Alien.Mass
+}
+
+// Blast returns the explosion the alien goes off with when it's destroyed.
+func (a *Alien) Blast() (rl.Vector2, float32, float32) {
+	return a.game.blast(a.Position)
+}
+
 // OnCollision handles the collision with another Collidable object. Aliens can blow up
 // spaceships only; they are in turn destroyed by rocks.
 func (a *Alien) OnCollision(other gameobjects.Collidable) error {
 	s, ok := other.(*Spaceship)
 	if ok {
-		return s.OnDestruction(a.Velocity)
+		return s.OnDestruction(a, a.Velocity)
 	}
 	return nil
 }
 
 // OnDestruction handles the destruction of the alien.
-func (a *Alien) OnDestruction(_ rl.Vector2) error {
-	game := GetGame()
+func (a *Alien) OnDestruction(by gameobjects.Collidable, _ rl.Vector2) error {
+	game := a.game
+	if !a.isAlive {
+		// Already destroyed by something else it overlapped this tick
+		return nil
+	}
 	a.isAlive = false
 	// Spawn shrapnel in random directions and lifespans
 	sheet := gameobjects.LoadSpriteSheet("shrapnel.png", 5, 1)
 	for range 6 {
-		frame := int(utils.RndIntInRange(0, 4))
-		shrapnel := NewShrapnel(a.Position, sheet, uint(utils.RndIntInRange(200, 400)), frame)
-		game.World.Objects.Add(&shrapnel)
-	}
-	// Cancel the runner goroutine if it exists
-	if a.runnerCancel != nil {
-		a.runnerCancel()
+		frame := int(game.Random.RndIntInRange(0, 4))
+		shrapnel := NewShrapnel(game, a.Position, sheet, uint(game.Random.RndIntInRange(200, 400)), frame)
+		game.World.Objects.Add(shrapnel)
 	}
+	game.explode(a, by)
+	// Stop the runner so the alien doesn't act from beyond the grave
+	a.runner.Cancel()
 	// Notify other services
-	game.EventBus.Publish("alien:destroyed", a.size)
+	events.Publish(game.EventBus, events.AlienDestroyed{Size: a.size, Position: a.Position, By: causeOf(by), Player: playerOf(by)})
 	return nil
 }
 
-// frameIndex returns the index of the correct frame to use given the current time
+// frameIndex returns the index of the correct frame to use given the current game time
 func (a *Alien) frameIndex() int {
 	// AlienBig is 2x2; AlienSmall is 3x3 but only 7 frames
 	frameCount := 4
 	if a.size == AlienSmall {
 		frameCount = 7
 	}
-	halfSeconds := int(math.Floor(rl.GetTime() * 2))
+	halfSeconds := int(math.Floor(a.game.Scheduler.Now().Seconds() * 2))
 	return halfSeconds % frameCount
 }
 
 // randomizeAlienTarget sets the alien's target to a random position on the playfield, at a random speed.
 func (a *Alien) randomizeAlienTarget() {
-	game := GetGame()
+	game := a.game
 	target := game.World.RandomPosition()
 	a.Velocity = rl.Vector2Normalize(rl.Vector2Subtract(target, a.Position))
+	maxSpeed := game.Tuning.Alien.MaxSpeed
 	if a.size == AlienBig {
-		sp := utils.RndFloat32InRange(alienMaxSpeed/3, alienMaxSpeed) / 2
+		sp := game.Random.RndFloat32InRange(maxSpeed/3, maxSpeed) / 2
 		a.Velocity = rl.Vector2Scale(a.Velocity, sp)
 	} else {
-		sp := utils.RndFloat32InRange(alienMaxSpeed/3, alienMaxSpeed)
+		sp := game.Random.RndFloat32InRange(maxSpeed/3, maxSpeed)
 		a.Velocity = rl.Vector2Scale(a.Velocity, sp)
 	}
 }
 
-// AlienSpawner adds new aliens to the playfield at an appropriate rate
-func AlienSpawner(ctx context.Context) {
-	rl.TraceLog(rl.LogDebug, "AlienSpawner starting")
+// AlienSpawner adds new aliens to the playfield at an appropriate rate for the level, one at a time.
+// Cancel the returned timer to stop spawning.
+func AlienSpawner(game *Game) *Timer {
+	platform.Log(rl.LogDebug, "AlienSpawner starting")
 	// Decide how frequently we should spawn aliens
-	game := GetGame()
-	var alien *Alien = nil
-	var runnerCtx context.Context
 	spawnDelay := time.Second * max(1, time.Duration(10.0-(float32(game.Level)*1.25)))
+	spawnDelay = time.Duration(float64(spawnDelay) * float64(game.Difficulty.scale().SpawnDelay))
 
-	ticker := time.NewTicker(spawnDelay)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			rl.TraceLog(rl.LogDebug, "AlienSpawner exiting")
-			if alien != nil && alien.runnerCancel != nil {
-				alien.runnerCancel()
-			}
+	return game.Scheduler.Every(spawnDelay, func() {
+		if !game.Mode.rules().Aliens {
 			return
-
-		case <-ticker.C:
-
-			if game.Paused {
-				continue
-			}
-
-			// Handle case where there's already an alien on the playfield
-			if alien != nil {
-				if alien.IsAlive() {
-					// There's already an active alien in the level; let it run
-					continue
-				}
-				rl.TraceLog(rl.LogInfo, "Alien no longer on playfield; stopping")
-				alien.runnerCancel()
-				alien = nil
-				// Don't spawn another right away
-				continue
+		}
+		// Handle case where there's already an alien on the playfield
+		if game.alien != nil {
+			if game.alien.IsAlive() {
+				// There's already an active alien in the level; let it run
+				return
 			}
+			platform.Log(rl.LogInfo, "Alien no longer on playfield; stopping")
+			game.alien.runner.Cancel()
+			game.alien = nil
+			// Don't spawn another right away
+			return
+		}
 
-			// Try to spawn a new alien, but if the position is occupied just skip this time around
-			position := game.World.RandomBorderPosition()
-			alien = newSpawnedAlien(game, position)
-			if game.World.Objects.IsRectangleOccupied(gameobjects.ExtendRectangle(alien.GetHitbox(), 0.25)) {
-				continue
-			}
-			rl.TraceLog(rl.LogInfo, "Spawning new alien")
-			runnerCtx, alien.runnerCancel = context.WithCancel(context.Background())
-			go AlienRunner(runnerCtx, alien)
-			game.World.Objects.Add(alien)
-			game.EventBus.Publish("alien:spawned", alien.size)
+		// Try to spawn a new alien, but if the position is occupied just skip this time around
+		position := game.World.RandomBorderPosition()
+		candidate := newSpawnedAlien(game, position)
+		if game.World.Objects.IsRectangleOccupied(gameobjects.ExtendRectangle(candidate.GetHitbox(), 0.25)) {
+			return
 		}
-	}
+		platform.Log(rl.LogInfo, "Spawning new alien")
+		game.alien = candidate
+		candidate.runner = AlienRunner(game, candidate)
+		game.World.Objects.Add(candidate)
+		events.Publish(game.EventBus, events.AlienSpawned{Size: candidate.size, Position: candidate.Position})
+	})
 }
 
-func AlienRunner(ctx context.Context, alien *Alien) {
-	rl.TraceLog(rl.LogDebug, "AlienRunner starting")
-	game := GetGame()
+// AlienRunner makes the alien act every so often: change direction, fire at the spaceship, or do
+// nothing. Smaller aliens and higher levels act more frequently. Cancel the returned timer to stop it.
+func AlienRunner(game *Game, alien *Alien) *Timer {
+	platform.Log(rl.LogDebug, "AlienRunner starting")
 
 	// Small aliens do things more frequently
 	actionDelay := 3000 - int(300*game.Level)
 	if alien.size == AlienSmall {
 		actionDelay /= 2
 	}
-	if actionDelay < alienMinActionDelay {
-		actionDelay = alienMinActionDelay
+	actionDelay = int(float32(actionDelay) * game.Difficulty.scale().ActionDelay)
+	if actionDelay < game.Tuning.Alien.MinActionDelayMs {
+		actionDelay = game.Tuning.Alien.MinActionDelayMs
 	}
 
-	ticker := time.NewTicker(time.Millisecond * time.Duration(actionDelay))
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			rl.TraceLog(rl.LogDebug, "AlienRunner exiting")
+	var runner *Timer
+	runner = game.Scheduler.Every(time.Millisecond*time.Duration(actionDelay), func() {
+		if !alien.IsAlive() {
+			// The alien associated with this runner is no longer alive; stop shooting permanently
+			platform.Log(rl.LogDebug, "AlienRunner exiting")
+			runner.Cancel()
 			return
+		}
+		target := game.World.NearestSpaceship(alien.Position)
+		if target == nil {
+			return
+		}
 
-		case <-ticker.C:
-			if game.Paused {
-				continue
-			}
-			if !alien.IsAlive() {
-				// The alien associated with this runner is no longer alive; stop shooting permanently
-				return
-			}
-			if !game.World.Spaceship.IsAlive() {
-				continue
-			}
-
-			// Alien does one of three things: fire, change direction, nothing
-			if utils.Chance(0.3) {
-				// Change direction
-				rl.TraceLog(rl.LogInfo, "Alien changing direction")
-				alien.randomizeAlienTarget()
-			} else if utils.Chance(0.5) {
-				// Fire a bullet roughly towards the spaceship
-				drift := utils.RndFloat32InRange(-alien.bulletDrift, alien.bulletDrift)
-				shootDirection := rl.Vector2Normalize(rl.Vector2Subtract(game.World.Spaceship.Position, alien.Position))
-				shootDirection = rl.Vector2Rotate(shootDirection, drift)
-				bullet := NewBullet(alien.Position, rl.Vector2Scale(shootDirection, bulletSpeed), false)
-				game.World.Objects.Add(&bullet)
-				game.EventBus.Publish("alien:fire")
-			}
+		// Alien does one of three things: fire, change direction, nothing
+		if game.Random.Chance(0.3) {
+			// Change direction
+			platform.Log(rl.LogInfo, "Alien changing direction")
+			alien.randomizeAlienTarget()
+		} else if game.Random.Chance(0.5) {
+			// Fire a bullet roughly towards the nearest spaceship
+			drift := game.Random.RndFloat32InRange(-alien.bulletDrift, alien.bulletDrift)
+			shootDirection := rl.Vector2Normalize(rl.Vector2Subtract(target.Position, alien.Position))
+			shootDirection = rl.Vector2Rotate(shootDirection, drift)
+			bullet := NewBullet(game, alien.Position, rl.Vector2Scale(shootDirection, game.Tuning.Bullet.Speed), nil)
+			game.World.Objects.Add(bullet)
+			events.Publish(game.EventBus, events.AlienFired{Position: bullet.Position, Velocity: bullet.Velocity})
 		}
-	}
+	})
+	return runner
 }
 
 // newSpawnedAlien returns a new alien at the specified position, moving in a random direction at the
@@ -266,16 +282,17 @@ func AlienRunner(ctx context.Context, alien *Alien) {
 func newSpawnedAlien(game *Game, position rl.Vector2) *Alien {
 	// Spawn a new alien
 	size := AlienBig
-	if game.Level > 2 && utils.RndIntInRange(0, 10) < game.Level {
+	if game.Level > 2 && game.Random.RndIntInRange(0, 10) < game.Level {
 		size = AlienSmall
 	}
-	spawnedAlien := NewAlien(size, position)
+	spawnedAlien := NewAlien(game, size, position)
 	spawnedAlien.randomizeAlienTarget()
 
+	maxDrift := degreesToRadians(game.Tuning.Alien.MaxBulletDrift) * game.Difficulty.scale().BulletDrift
 	if size == AlienBig {
-		spawnedAlien.bulletDrift = utils.RndFloat32(alienMaxBulletDrift)
+		spawnedAlien.bulletDrift = game.Random.RndFloat32(maxDrift)
 	} else {
-		spawnedAlien.bulletDrift = utils.RndFloat32(alienMaxBulletDrift) / 3
+		spawnedAlien.bulletDrift = game.Random.RndFloat32(maxDrift) / 3
 	}
 	return &spawnedAlien
 }
@@ -1,14 +1,17 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/gameobjects"
 	"testing"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 func TestAlien_OnCollision(t *testing.T) {
-	alien := NewAlien(AlienSmall, rl.NewVector2(100, 100))
-	spaceship := NewSpaceship()
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	alien := NewAlien(game, AlienSmall, rl.NewVector2(100, 100))
+	spaceship := NewSpaceship(game, 0)
 	spaceship.Position = rl.NewVector2(100, 100)
 
 	err := alien.OnCollision(&spaceship)
@@ -21,10 +24,12 @@ func TestAlien_OnCollision(t *testing.T) {
 }
 
 func TestAlien_OnDestruction(t *testing.T) {
-	alien := NewAlien(AlienBig, rl.NewVector2(1, 1))
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	alien := NewAlien(game, AlienBig, rl.NewVector2(1, 1))
 	bulletVelocity := rl.NewVector2(1, 1)
 
-	err := alien.OnDestruction(bulletVelocity)
+	err := alien.OnDestruction(nil, bulletVelocity)
 	if err != nil {
 		t.Errorf("Unexpected error during destruction: %v", err)
 	}
@@ -33,3 +38,24 @@ func TestAlien_OnDestruction(t *testing.T) {
 		t.Errorf("Expected alien to be destroyed")
 	}
 }
+
+func TestAlien_GetShape(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	for _, size := range []AlienSize{AlienBig, AlienSmall} {
+		alien := NewAlien(game, size, rl.NewVector2(100, 100))
+		hitbox := alien.GetHitbox()
+
+		capsule, ok := alien.GetShape().(*gameobjects.Capsule)
+		if !ok {
+			t.Fatalf("Expected the alien to be a capsule, got %v", alien.GetShape())
+		}
+		// As wide as the saucer, but not as tall as the sprite, and clear of its corners
+		if bounds := capsule.Bounds(); bounds.Width != hitbox.Width || bounds.Height >= hitbox.Height {
+			t.Errorf("Expected the capsule %v to span the width of the sprite %v but not its height", bounds, hitbox)
+		}
+		if gameobjects.Overlaps(capsule, gameobjects.Circle{Center: rl.NewVector2(hitbox.X+1, hitbox.Y+1), Radius: 1}) {
+			t.Errorf("Expected the corner of the sprite to be clear of the alien")
+		}
+	}
+}
@@ -2,24 +2,31 @@ package core
 
 import (
 	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 type Bullet struct {
 	gameobjects.Rigidbody
-	spritesheet   *gameobjects.SpriteSheet
-	isAlive       bool
-	isPlayerFired bool
-	ageMs         uint
+	spritesheet *gameobjects.SpriteSheet
+	isAlive     bool
+	owner       *Spaceship // The spaceship that fired it, or nil for an alien's
+	ageMs       uint
+	shape       gameobjects.Capsule // The latest sweep, which GetShape hands out a pointer to
+	trail       gameobjects.Emitter
+	game        *Game
 }
 
 var _ gameobjects.Collidable = (*Bullet)(nil)
 var _ gameobjects.GameObject = (*Bullet)(nil)
+var _ gameobjects.Recyclable = (*Bullet)(nil)
 
-// NewBullet creates a new bullet with a given position and velocity.
-func NewBullet(position, velocity rl.Vector2, isPlayerFired bool) Bullet {
+// NewBullet creates a new bullet with a given position and velocity, fired by the given spaceship or, if
+// it's nil, by an alien. Bullets come from the game's pool, reusing ones that have been recycled.
+func NewBullet(game *Game, position, velocity rl.Vector2, owner *Spaceship) *Bullet {
 	sheet := gameobjects.LoadSpriteSheet("bullet.png", 1, 1)
-	bullet := Bullet{
+	bullet := game.bullets.Get()
+	*bullet = Bullet{
 		spritesheet: sheet,
 		Rigidbody: gameobjects.Rigidbody{
 			Velocity: velocity,
@@ -27,30 +34,32 @@ func NewBullet(position, velocity rl.Vector2, isPlayerFired bool) Bullet {
 				Position: position,
 			},
 		},
-		isPlayerFired: isPlayerFired,
-		isAlive:       true,
-		ageMs:         0,
+		owner:   owner,
+		isAlive: true,
+		ageMs:   0,
+		trail:   gameobjects.Emitter{Style: bulletTrail, Rate: 40},
+		game:    game,
 	}
 	return bullet
 }
 
 // Update applies physics to the bullet so it moves per its velocity.
 func (b *Bullet) Update(delta float32) error {
-	game := GetGame()
 	b.Rigidbody.ApplyPhysics(delta)
-	b.Position = game.World.Wraparound(b.Position)
+	b.Position = b.game.World.Wraparound(b.Position)
 	b.ageMs += uint(delta * 1000)
+	b.trail.Emit(&b.game.World.Particles, delta, b.Position, rl.Vector2Negate(b.Velocity), rl.Vector2{})
 	return nil
 }
 
 // Draw renders the bullet to the screen.
-func (b *Bullet) Draw() error {
-	return b.spritesheet.Draw(0, 0, b.Position, b.Rotation)
+func (b *Bullet) Draw(p platform.Platform) error {
+	return b.spritesheet.DrawWrapped(p, 0, 0, b.Position, b.Rotation, b.game.World.Width, b.game.World.Height)
 }
 
 // IsAlive returns true if the bullet is still alive. Always dead after its lifetime.
 func (b *Bullet) IsAlive() bool {
-	return b.isAlive && b.ageMs < bulletLifetimeMs
+	return b.isAlive && b.ageMs < b.game.Tuning.Bullet.LifetimeMs
 }
 
 func (b *Bullet) IsEnemy() bool {
@@ -58,7 +67,12 @@ func (b *Bullet) IsEnemy() bool {
 }
 
 func (b *Bullet) IsPlayerFired() bool {
-	return b.isPlayerFired
+	return b.owner != nil
+}
+
+// Owner returns the spaceship that fired the bullet, or nil if an alien did.
+func (b *Bullet) Owner() *Spaceship {
+	return b.owner
 }
 
 // GetHitbox returns the hitbox of the bullet, used for basic collision detection.
@@ -71,23 +85,39 @@ func (b *Bullet) GetHitbox() rl.Rectangle {
 	}
 }
 
+// GetShape returns the bullet's outline for collision detection: a circle the size of its sprite, swept
+// along the line it travelled in its last update so it can't tunnel through anything on a slow frame.
+func (b *Bullet) GetShape() gameobjects.Shape {
+	b.shape = b.Swept(b.spritesheet.GetSize().X / 2)
+	return &b.shape
+}
+
+// Recycle gives the bullet back to the game's pool once it's gone from the playfield.
+func (b *Bullet) Recycle() {
+	b.game.bullets.Put(b)
+}
+
 // OnCollision handles the collision of the bullet with another object.
 func (b *Bullet) OnCollision(other gameobjects.Collidable) error {
 	if destructible, ok := other.(gameobjects.Destructible); ok {
-		if _, ok := other.(*Spaceship); ok {
-			// Spaceship bullets don't destroy the spaceship
-			if b.isPlayerFired {
+		if ship, ok := other.(*Spaceship); ok && b.owner != nil {
+			// A spaceship's bullets never destroy it, and only destroy the other players' with friendly fire on
+			if ship == b.owner || !b.game.FriendlyFire {
 				return nil
 			}
 		}
 		if _, ok := other.(*Alien); ok {
 			// Alien bullets don't destroy the alien
-			if !b.isPlayerFired {
+			if b.owner == nil {
 				return nil
 			}
 		}
 		b.isAlive = false
-		return destructible.OnDestruction(b.Velocity)
+		// The bullet's blow pushes whatever it hits, which matters for anything that survives it
+		if pushable, ok := other.(gameobjects.Pushable); ok {
+			gameobjects.Push(pushable, rl.Vector2Scale(b.Velocity, b.game.Tuning.Bullet.Mass))
+		}
+		return destructible.OnDestruction(b, b.Velocity)
 	}
 	return nil
 }
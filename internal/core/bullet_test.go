@@ -1,12 +1,17 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/gameobjects"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"testing"
 )
 
 func TestBullet_IsAlive(t *testing.T) {
-	bullet := NewBullet(rl.NewVector2(0, 0), rl.NewVector2(0, 0), true)
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	owner := NewSpaceship(game, 0)
+	bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
 
 	// Test that the bullet is alive immediately after creation
 	if !bullet.IsAlive() {
@@ -14,15 +19,18 @@ func TestBullet_IsAlive(t *testing.T) {
 	}
 
 	// Test that the bullet is not alive after its lifetime has passed
-	bullet.ageMs = bulletLifetimeMs + 1
+	bullet.ageMs = game.Tuning.Bullet.LifetimeMs + 1
 	if bullet.IsAlive() {
 		t.Errorf("Expected bullet to be dead after its lifetime has passed")
 	}
 }
 
 func TestBullet_GetHitbox(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
 	position := rl.NewVector2(10, 20)
-	bullet := NewBullet(position, rl.NewVector2(0, 0), true)
+	owner := NewSpaceship(game, 0)
+	bullet := NewBullet(game, position, rl.NewVector2(0, 0), &owner)
 
 	expectedHitbox := rl.Rectangle{
 		X:      position.X,
@@ -37,9 +45,63 @@ func TestBullet_GetHitbox(t *testing.T) {
 	}
 }
 
+func TestBullet_GetShape(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	position := rl.NewVector2(10, 20)
+	bullet := NewBullet(game, position, rl.NewVector2(0, 0), nil)
+
+	radius := bullet.spritesheet.GetSize().X / 2
+	want := gameobjects.Capsule{A: position, B: position, Radius: radius}
+	if shape := bullet.GetShape().(*gameobjects.Capsule); *shape != want || radius <= 0 {
+		t.Errorf("Expected a new bullet to be a circle the size of its sprite, %v, got %v", want, shape)
+	}
+
+	// Once moving, it covers the whole way it came
+	bullet.Velocity = rl.NewVector2(500, 0)
+	_ = bullet.Update(0.1)
+	want = gameobjects.Capsule{A: position, B: rl.NewVector2(60, 20), Radius: radius}
+	if shape := bullet.GetShape().(*gameobjects.Capsule); *shape != want {
+		t.Errorf("Expected the bullet to be swept along the way it came, %v, got %v", want, shape)
+	}
+}
+
+func TestBullet_CannotTunnel(t *testing.T) {
+	t.Parallel()
+	// Try every frame time up to a tenth of a second, each starting at several points along a step, so
+	// wherever the steps fall the bullet would otherwise land either side of the rock at least once
+	for deltaMs := 1; deltaMs <= 100; deltaMs++ {
+		delta := float32(deltaMs) / 1000
+		for phase := range 5 {
+			game := NewGame(800, 600, 1)
+			game.World.Initialize(game)
+			game.World.Spaceships[0].Alive = false
+			rock := NewRock(game, RockTiny, rl.NewVector2(400, 300))
+			rock.Velocity = rl.Vector2{}
+			game.World.Objects.Add(&rock)
+
+			speed := game.Tuning.Bullet.Speed * 2
+			start := rl.NewVector2(300-float32(phase)/5*speed*delta, 300)
+			bullet := NewBullet(game, start, rl.NewVector2(speed, 0), nil)
+			game.World.Objects.Add(bullet)
+			for bullet.IsAlive() && rock.IsAlive() && bullet.Position.X < 500 {
+				game.World.Objects.Update(delta)
+			}
+
+			if rock.IsAlive() {
+				t.Fatalf("Expected the bullet to hit the tiny rock with %dms frames, starting at %v; it ended at %v",
+					deltaMs, start, bullet.Position)
+			}
+		}
+	}
+}
+
 func TestBullet_OnCollisionWithRock(t *testing.T) {
-	bullet := NewBullet(rl.NewVector2(0, 0), rl.NewVector2(0, 0), true)
-	rock := NewRock(RockBig, rl.NewVector2(0, 0))
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	owner := NewSpaceship(game, 0)
+	bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
+	rock := NewRock(game, RockBig, rl.NewVector2(0, 0))
 	err := bullet.OnCollision(&rock)
 	if err != nil {
 		t.Errorf("Unexpected error during collision: %v", err)
@@ -57,8 +119,11 @@ func TestBullet_OnCollisionWithRock(t *testing.T) {
 }
 
 func TestBullet_OnCollisionWithAlien(t *testing.T) {
-	bullet := NewBullet(rl.NewVector2(0, 0), rl.NewVector2(0, 0), true)
-	alien := NewAlien(AlienBig, rl.NewVector2(0, 0))
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	owner := NewSpaceship(game, 0)
+	bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
+	alien := NewAlien(game, AlienBig, rl.NewVector2(0, 0))
 	err := bullet.OnCollision(&alien)
 	if err != nil {
 		t.Errorf("Unexpected error during collision: %v", err)
@@ -76,9 +141,11 @@ func TestBullet_OnCollisionWithAlien(t *testing.T) {
 }
 
 func TestBullet_OnCollisionWithSpaceshipFiredBySpaceship(t *testing.T) {
-	bullet := NewBullet(rl.NewVector2(0, 0), rl.NewVector2(0, 0), true)
-	ship := NewSpaceship()
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	ship := NewSpaceship(game, 0)
 	ship.Alive = true
+	bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &ship)
 	err := bullet.OnCollision(&ship)
 	if err != nil {
 		t.Errorf("Unexpected error during collision: %v", err)
@@ -94,8 +161,10 @@ func TestBullet_OnCollisionWithSpaceshipFiredBySpaceship(t *testing.T) {
 }
 
 func TestBullet_OnCollisionWithSpaceshipFiredByAlien(t *testing.T) {
-	bullet := NewBullet(rl.NewVector2(0, 0), rl.NewVector2(0, 0), false)
-	ship := NewSpaceship()
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), nil)
+	ship := NewSpaceship(game, 0)
 	err := bullet.OnCollision(&ship)
 	if err != nil {
 		t.Errorf("Unexpected error during collision: %v", err)
@@ -109,3 +178,98 @@ func TestBullet_OnCollisionWithSpaceshipFiredByAlien(t *testing.T) {
 		t.Errorf("Expected alien bullet to be dead after collision with ship")
 	}
 }
+
+func TestBullet_OnCollisionPublishesCause(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	var got []events.RockDestroyed
+	sub := events.Subscribe(game.EventBus, func(e events.RockDestroyed) {
+		got = append(got, e)
+	}, events.PriorityNormal)
+	defer func() { _ = game.EventBus.Unsubscribe(sub) }()
+
+	owner := NewSpaceship(game, 1)
+	playerBullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
+	alienBullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), nil)
+	rock1 := NewRock(game, RockTiny, rl.NewVector2(10, 20))
+	rock2 := NewRock(game, RockTiny, rl.NewVector2(30, 40))
+	_ = playerBullet.OnCollision(&rock1)
+	_ = alienBullet.OnCollision(&rock2)
+	game.EventBus.Dispatch()
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 rock destroyed events, got %d", len(got))
+	}
+	if got[0].By != events.CausePlayerBullet || got[0].Player != 1 || got[0].Position != rock1.Position {
+		t.Errorf("Expected first rock destroyed by player 1's bullet at %v, got %v", rock1.Position, got[0])
+	}
+	if got[1].By != events.CauseAlienBullet || got[1].Player != -1 || got[1].Position != rock2.Position {
+		t.Errorf("Expected second rock destroyed by alien bullet at %v, got %v", rock2.Position, got[1])
+	}
+}
+
+func TestBullet_TwoBulletsDestroyARockOnce(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	destroyed := 0
+	sub := events.Subscribe(game.EventBus, func(events.RockDestroyed) { destroyed++ }, events.PriorityNormal)
+	defer func() { _ = game.EventBus.Unsubscribe(sub) }()
+
+	owner := NewSpaceship(game, 0)
+	first := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
+	second := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
+	rock := NewRock(game, RockTiny, rl.NewVector2(10, 20))
+	_ = first.OnCollision(&rock)
+	_ = second.OnCollision(&rock)
+	game.EventBus.Dispatch()
+
+	if destroyed != 1 {
+		t.Errorf("Expected the rock destroyed once, got %d", destroyed)
+	}
+}
+
+func TestBullet_FriendlyFire(t *testing.T) {
+	t.Parallel()
+	for _, friendlyFire := range []bool{false, true} {
+		game := NewGame(800, 600, 1)
+		game.FriendlyFire = friendlyFire
+		shooter := NewSpaceship(game, 0)
+		target := NewSpaceship(game, 1)
+		target.Alive = true
+		bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &shooter)
+		if err := bullet.OnCollision(&target); err != nil {
+			t.Errorf("Unexpected error during collision: %v", err)
+		}
+
+		if target.IsAlive() == friendlyFire {
+			t.Errorf("Expected the other player's ship alive %v with friendly fire %v", !friendlyFire, friendlyFire)
+		}
+		if bullet.IsAlive() == friendlyFire {
+			t.Errorf("Expected the bullet alive %v with friendly fire %v", !friendlyFire, friendlyFire)
+		}
+	}
+}
+
+func TestBullet_PushesWhatItHits(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.Mode = ModeZen
+	game.FriendlyFire = true
+	shooter, target := NewSpaceship(game, 0), NewSpaceship(game, 1)
+	target.Alive = true
+	velocity := rl.NewVector2(500, 0)
+	bullet := NewBullet(game, target.Position, velocity, &shooter)
+
+	if err := bullet.OnCollision(&target); err != nil {
+		t.Fatalf("Unexpected error colliding: %v", err)
+	}
+
+	// Spaceships can't break in Zen, so the target survives the hit and is knocked along by it
+	want := rl.Vector2Scale(velocity, game.Tuning.Bullet.Mass/game.Tuning.Ship.Mass)
+	if !target.IsAlive() || rl.Vector2Distance(target.Velocity, want) > 0.001 {
+		t.Errorf("Expected the spaceship to survive and be pushed to %v, got %v (alive %v)", want, target.Velocity, target.IsAlive())
+	}
+	if bullet.IsAlive() {
+		t.Errorf("Expected the bullet to be used up")
+	}
+}
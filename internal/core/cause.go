@@ -0,0 +1,38 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/gameobjects"
+)
+
+// causeOf describes the object that destroyed something, for the events published about it.
+func causeOf(by gameobjects.Collidable) events.Cause {
+	switch obj := by.(type) {
+	case *Bullet:
+		if obj.IsPlayerFired() {
+			return events.CausePlayerBullet
+		}
+		return events.CauseAlienBullet
+	case *Rock:
+		return events.CauseRock
+	case *Alien:
+		return events.CauseAlien
+	case *Spaceship:
+		return events.CauseSpaceship
+	default:
+		return events.CauseUnknown
+	}
+}
+
+// playerOf returns the player whose spaceship, or bullet, destroyed something, or -1 if it wasn't a player.
+func playerOf(by gameobjects.Collidable) int {
+	switch obj := by.(type) {
+	case *Bullet:
+		if obj.owner != nil {
+			return obj.owner.Player
+		}
+	case *Spaceship:
+		return obj.Player
+	}
+	return -1
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Difficulty is a preset that scales the whole game up or down from the tuning. Normal is the zero
+// value, so a game is Normal unless told otherwise.
+type Difficulty int
+
+const (
+	DifficultyNormal Difficulty = iota
+	DifficultyEasy
+	DifficultyHard
+	DifficultyInsane
+)
+
+// Difficulties lists the presets from easiest to hardest, the order to offer them in.
+var Difficulties = []Difficulty{DifficultyEasy, DifficultyNormal, DifficultyHard, DifficultyInsane}
+
+// difficultyScale is how a preset changes the game. Everything but Lives multiplies the tuned value,
+// so 1 leaves it as tuned.
+type difficultyScale struct {
+	Lives       int     // Lives at the start of the game
+	RockSpeed   float32 // How fast rocks move
+	RockCount   float32 // How many rocks arrive each level
+	SpawnDelay  float32 // How long between aliens
+	ActionDelay float32 // How long aliens wait between actions
+	BulletDrift float32 // How far off aliens' aim can be
+	ExtraLife   float32 // How many points between extra lives
+}
+
+var difficultyScales = map[Difficulty]difficultyScale{
+	DifficultyEasy:   {Lives: 5, RockSpeed: 0.75, RockCount: 0.75, SpawnDelay: 1.5, ActionDelay: 1.5, BulletDrift: 1.5, ExtraLife: 0.5},
+	DifficultyNormal: {Lives: 3, RockSpeed: 1, RockCount: 1, SpawnDelay: 1, ActionDelay: 1, BulletDrift: 1, ExtraLife: 1},
+	DifficultyHard:   {Lives: 3, RockSpeed: 1.25, RockCount: 1.25, SpawnDelay: 0.75, ActionDelay: 0.75, BulletDrift: 0.6, ExtraLife: 1.5},
+	DifficultyInsane: {Lives: 1, RockSpeed: 1.5, RockCount: 1.5, SpawnDelay: 0.5, ActionDelay: 0.5, BulletDrift: 0.3, ExtraLife: 2},
+}
+
+var difficultyNames = map[Difficulty]string{
+	DifficultyEasy:   "Easy",
+	DifficultyNormal: "Normal",
+	DifficultyHard:   "Hard",
+	DifficultyInsane: "Insane",
+}
+
+func (d Difficulty) String() string {
+	if name, ok := difficultyNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("Difficulty(%d)", int(d))
+}
+
+// ParseDifficulty returns the preset with the given name, ignoring case.
+func ParseDifficulty(name string) (Difficulty, error) {
+	for _, d := range Difficulties {
+		if strings.EqualFold(name, d.String()) {
+			return d, nil
+		}
+	}
+	return DifficultyNormal, fmt.Errorf("unknown difficulty %q; choose from easy, normal, hard, or insane", name)
+}
+
+// MarshalText writes the difficulty by name, so it reads well in saved games and high scores.
+func (d Difficulty) MarshalText() ([]byte, error) {
+	if _, ok := difficultyNames[d]; !ok {
+		return nil, fmt.Errorf("unknown difficulty %d", int(d))
+	}
+	return []byte(strings.ToLower(d.String())), nil
+}
+
+// UnmarshalText reads a difficulty written by MarshalText.
+func (d *Difficulty) UnmarshalText(text []byte) error {
+	parsed, err := ParseDifficulty(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// scale returns how the preset changes the game, treating unknown presets as Normal.
+func (d Difficulty) scale() difficultyScale {
+	if scale, ok := difficultyScales[d]; ok {
+		return scale
+	}
+	return difficultyScales[DifficultyNormal]
+}
+
+// SetDifficulty sets the game's difficulty preset, along with the lives each spaceship starts with. Call
+// it before the game starts.
+func (g *Game) SetDifficulty(d Difficulty) {
+	g.Difficulty = d
+	g.startingLives = d.scale().Lives
+	for _, ship := range g.World.Spaceships {
+		ship.Lives = g.startingLives
+	}
+}
+
+// ExtraLifeEvery returns how many points the player needs for each extra life.
+func (g *Game) ExtraLifeEvery() uint {
+	return max(1, uint(float64(g.Tuning.Ship.ExtraLifeEvery)*float64(g.Difficulty.scale().ExtraLife)))
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	"testing"
+)
+
+func TestParseDifficulty(t *testing.T) {
+	t.Parallel()
+	for _, d := range Difficulties {
+		text, err := d.MarshalText()
+		if err != nil {
+			t.Fatalf("Unexpected error writing %v: %v", d, err)
+		}
+		var parsed Difficulty
+		if err := parsed.UnmarshalText(text); err != nil || parsed != d {
+			t.Errorf("Expected %q to read back as %v, got %v (%v)", text, d, parsed, err)
+		}
+	}
+	if d, err := ParseDifficulty("INSANE"); err != nil || d != DifficultyInsane {
+		t.Errorf("Expected names to ignore case, got %v (%v)", d, err)
+	}
+	if _, err := ParseDifficulty("nightmare"); err == nil {
+		t.Errorf("Expected an unknown difficulty to be an error")
+	}
+}
+
+func TestSetDifficulty(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		difficulty Difficulty
+		lives      int
+		extraLife  uint
+		rocks      int
+	}{
+		{DifficultyEasy, 5, 5_000, 3},
+		{DifficultyNormal, 3, 10_000, 4},
+		{DifficultyHard, 3, 15_000, 5},
+		{DifficultyInsane, 1, 20_000, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.difficulty.String(), func(t *testing.T) {
+			t.Parallel()
+			game := NewGame(800, 600, 42)
+			game.SetDifficulty(tt.difficulty)
+			game.World.Initialize(game)
+			if game.Lives() != tt.lives {
+				t.Errorf("Expected %d lives, got %d", tt.lives, game.Lives())
+			}
+			if every := game.ExtraLifeEvery(); every != tt.extraLife {
+				t.Errorf("Expected an extra life every %d points, got %d", tt.extraLife, every)
+			}
+
+			game.StartLevel()
+			game.Update(3)
+			rocks := 0
+			game.World.Objects.Any(func(o gameobjects.GameObject) bool {
+				if _, ok := o.(*Rock); ok {
+					rocks++
+				}
+				return false
+			})
+			if rocks != tt.rocks {
+				t.Errorf("Expected %d rocks on level 1, got %d", tt.rocks, rocks)
+			}
+		})
+	}
+}
+
+func TestDifficulty_RockSpeed(t *testing.T) {
+	t.Parallel()
+	speed := func(d Difficulty) float32 {
+		game := NewGame(800, 600, 42)
+		game.SetDifficulty(d)
+		rock := NewRock(game, RockBig, game.World.RandomBorderPosition())
+		return rock.Velocity.X*rock.Velocity.X + rock.Velocity.Y*rock.Velocity.Y
+	}
+	if normal, insane := speed(DifficultyNormal), speed(DifficultyInsane); insane <= normal {
+		t.Errorf("Expected rocks to be faster on Insane, got %v vs %v", insane, normal)
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
+)
+
+// particleSheet is a plain white dot, which takes on whatever colour a particle is tinted.
+var particleSheet = gameobjects.LoadSpriteSheet("particle.png", 1, 1)
+
+// exhaust is the flame out of the back of a spaceship burning fuel.
+var exhaust = &gameobjects.ParticleStyle{
+	Sheet:       particleSheet,
+	StartColor:  rl.Orange,
+	EndColor:    rl.NewColor(230, 41, 55, 0),
+	StartScale:  1.2,
+	EndScale:    0.4,
+	MinLifetime: 0.25,
+	MaxLifetime: 0.45,
+	MinSpeed:    80,
+	MaxSpeed:    160,
+	Spread:      0.3,
+	Drag:        2,
+}
+
+// bulletTrail is the smoke a bullet leaves hanging behind it.
+var bulletTrail = &gameobjects.ParticleStyle{
+	Sheet:       particleSheet,
+	StartColor:  rl.DarkGray,
+	EndColor:    rl.NewColor(200, 200, 200, 0),
+	StartScale:  0.8,
+	EndScale:    0.2,
+	MinLifetime: 0.15,
+	MaxLifetime: 0.3,
+	MaxSpeed:    15,
+	Spread:      math.Pi,
+}
+
+// rockDust is the cloud thrown up when a rock splits, spreading as it settles.
+var rockDust = &gameobjects.ParticleStyle{
+	Sheet:       particleSheet,
+	StartColor:  rl.Gray,
+	EndColor:    rl.NewColor(211, 176, 131, 0),
+	StartScale:  1.5,
+	EndScale:    3,
+	MinLifetime: 0.5,
+	MaxLifetime: 1,
+	MinSpeed:    20,
+	MaxSpeed:    90,
+	Spread:      math.Pi,
+	Drag:        1.5,
+}
+
+// HyperspaceDeparture is the spaceship breaking up as it jumps into hyperspace.
+var HyperspaceDeparture = &gameobjects.ParticleStyle{
+	Sheet:       particleSheet,
+	StartColor:  rl.DarkBlue,
+	EndColor:    rl.NewColor(102, 191, 255, 0),
+	StartScale:  1.5,
+	EndScale:    0.5,
+	MinLifetime: 0.6,
+	MaxLifetime: 0.9,
+	MinSpeed:    100,
+	MaxSpeed:    300,
+	Spread:      math.Pi,
+	Drag:        1,
+}
+
+// HyperspaceArrival is the spaceship coming back together where it jumps out of hyperspace: particles
+// start in a ring around it and all reach the middle as they die, a second later.
+var HyperspaceArrival = &gameobjects.ParticleStyle{
+	Sheet:       particleSheet,
+	StartColor:  rl.NewColor(102, 191, 255, 0),
+	EndColor:    rl.DarkBlue,
+	StartScale:  0.5,
+	EndScale:    1.5,
+	MinLifetime: 1,
+	MaxLifetime: 1,
+	MinSpeed:    -200,
+	MaxSpeed:    -200,
+	Spread:      math.Pi,
+	StartRadius: 200,
+}
@@ -0,0 +1,248 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Priority decides the order in which the subscribers to a single event run. Higher runs first;
+// subscribers with the same priority run in the order they subscribed.
+type Priority int
+
+const (
+	PriorityLow    Priority = -10 // Runs after everything else, e.g. checks on the resulting game state
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10 // Runs before everything else, e.g. bookkeeping that others rely on
+)
+
+// asyncQueueSize is how many events an async subscriber can fall behind before Dispatch waits for it.
+const asyncQueueSize = 64
+
+// Bus carries events from the game objects to the observers. Publish only queues the event;
+// Dispatch delivers everything queued, synchronously and in the order it was published, at a fixed
+// point in the simulation tick. That keeps the observers on the simulation thread and makes the
+// order of their reactions the same every time.
+//
+// Events are keyed by their Go type, so use the Subscribe and Publish functions rather than
+// topic names. Each type's events wait in a queue of their own type rather than all together as
+// interfaces, so once the queues have grown to a busy tick's worth, publishing allocates nothing.
+type Bus struct {
+	handlers    map[reflect.Type][]*Subscription
+	queues      map[reflect.Type]queue
+	order       []queue         // The queue holding each event waiting for dispatch, in publish order
+	next        int             // How far through order Dispatch has got
+	subs        []*Subscription // Reused by Dispatch for its copy of each event's subscribers
+	nextID      uint64
+	dispatching bool
+	closing     []*Subscription // Async subscribers that unsubscribed mid-dispatch, closed once it ends
+	lock        sync.Mutex
+	inFlight    sync.WaitGroup
+}
+
+// Subscription is a handler registered for one type of event. Keep it to unsubscribe later.
+type Subscription struct {
+	id       uint64
+	topic    reflect.Type
+	handler  any // A func(T) for events of type T
+	priority Priority
+	async    mailbox // Nil for synchronous subscribers
+	removed  bool
+}
+
+// queue holds the events of one type from when they're published until they're dispatched.
+type queue interface {
+	topic() reflect.Type
+	// pop takes the oldest event off the queue, keeping it to deliver. Call it with the bus locked.
+	pop()
+	// deliver hands the event last popped to the subscribers.
+	deliver(b *Bus, subs []*Subscription)
+}
+
+type typedQueue[T any] struct {
+	events []T
+	next   int
+	popped T
+}
+
+// mailbox carries events to an async subscriber's goroutine.
+type mailbox interface {
+	close()
+}
+
+type channel[T any] chan T
+
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[reflect.Type][]*Subscription),
+		queues:   make(map[reflect.Type]queue),
+		order:    make([]queue, 0, 100),
+	}
+}
+
+// Subscribe registers the handler to be called during Dispatch for every event of type T.
+func Subscribe[T any](bus *Bus, handler func(T), priority Priority) *Subscription {
+	return bus.subscribe(reflect.TypeFor[T](), handler, priority, nil)
+}
+
+// SubscribeAsync registers a slow handler to run off the simulation thread. It receives the events of
+// type T in publish order on its own goroutine, so it must not touch the game state.
+func SubscribeAsync[T any](bus *Bus, handler func(T)) *Subscription {
+	events := make(channel[T], asyncQueueSize)
+	go runAsync(bus, events, handler)
+	return bus.subscribe(reflect.TypeFor[T](), handler, PriorityNormal, events)
+}
+
+// Publish queues the event for the next Dispatch. Safe to call from anywhere, including from within a
+// handler.
+func Publish[T any](bus *Bus, event T) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	topic := reflect.TypeFor[T]()
+	q, ok := bus.queues[topic].(*typedQueue[T])
+	if !ok {
+		q = &typedQueue[T]{}
+		bus.queues[topic] = q
+	}
+	q.events = append(q.events, event)
+	bus.order = append(bus.order, q)
+}
+
+// Unsubscribe removes the subscription so it receives no more events, even ones already being
+// dispatched. Call it from the simulation thread.
+func (b *Bus) Unsubscribe(sub *Subscription) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	subs := b.handlers[sub.topic]
+	idx := slices.Index(subs, sub)
+	if idx < 0 {
+		return fmt.Errorf("not subscribed to %s", sub.topic)
+	}
+	b.handlers[sub.topic] = slices.Delete(subs, idx, idx+1)
+	sub.removed = true
+	if sub.async != nil {
+		// Dispatch may still be working through a copy of the subscribers, so leave the channel open
+		// until it's done
+		if b.dispatching {
+			b.closing = append(b.closing, sub)
+		} else {
+			sub.async.close()
+		}
+	}
+	return nil
+}
+
+// Dispatch delivers every queued event to its subscribers in the order it was published, including any
+// events the subscribers publish along the way. Call it from the simulation thread, but not from within
+// a handler.
+func (b *Bus) Dispatch() {
+	b.lock.Lock()
+	b.dispatching = true
+	b.lock.Unlock()
+	defer b.endDispatch()
+
+	for {
+		b.lock.Lock()
+		if b.next == len(b.order) {
+			clear(b.order)
+			b.order, b.next = b.order[:0], 0
+			b.lock.Unlock()
+			return
+		}
+		q := b.order[b.next]
+		b.next++
+		q.pop()
+		// Subscribers may come and go as the event is delivered, so work from a copy
+		b.subs = append(b.subs[:0], b.handlers[q.topic()]...)
+		b.lock.Unlock()
+
+		q.deliver(b, b.subs)
+	}
+}
+
+// endDispatch closes the channels of the async subscribers that unsubscribed during the dispatch.
+func (b *Bus) endDispatch() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.dispatching = false
+	for _, sub := range b.closing {
+		sub.async.close()
+	}
+	clear(b.closing)
+	b.closing = b.closing[:0]
+	clear(b.subs)
+}
+
+// WaitAsync blocks until the async subscribers have caught up with everything dispatched so far.
+func (b *Bus) WaitAsync() {
+	b.inFlight.Wait()
+}
+
+func (b *Bus) subscribe(topic reflect.Type, handler any, priority Priority, async mailbox) *Subscription {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.nextID++
+	sub := &Subscription{
+		id:       b.nextID,
+		topic:    topic,
+		handler:  handler,
+		priority: priority,
+		async:    async,
+	}
+	subs := append(b.handlers[topic], sub)
+	slices.SortStableFunc(subs, func(s1, s2 *Subscription) int {
+		return int(s2.priority) - int(s1.priority)
+	})
+	b.handlers[topic] = subs
+	return sub
+}
+
+// removed returns true if the subscriber has unsubscribed.
+func (b *Bus) removed(sub *Subscription) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return sub.removed
+}
+
+func (q *typedQueue[T]) topic() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func (q *typedQueue[T]) pop() {
+	var zero T
+	q.popped, q.events[q.next] = q.events[q.next], zero
+	q.next++
+	if q.next == len(q.events) {
+		q.events, q.next = q.events[:0], 0
+	}
+}
+
+// deliver calls each subscriber with the event, or hands it to the subscriber's goroutine if it's
+// async. A subscriber that unsubscribed earlier in the same dispatch gets nothing.
+func (q *typedQueue[T]) deliver(b *Bus, subs []*Subscription) {
+	event := q.popped
+	for _, sub := range subs {
+		if b.removed(sub) {
+			continue
+		}
+		if sub.async == nil {
+			sub.handler.(func(T))(event)
+			continue
+		}
+		b.inFlight.Add(1)
+		sub.async.(channel[T]) <- event
+	}
+}
+
+func (c channel[T]) close() {
+	close(c)
+}
+
+// runAsync calls an async subscriber with its events, one at a time, until it unsubscribes.
+func runAsync[T any](b *Bus, events channel[T], handler func(T)) {
+	for event := range events {
+		handler(event)
+		b.inFlight.Done()
+	}
+}
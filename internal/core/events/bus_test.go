@@ -0,0 +1,188 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestBus_PublishQueuesUntilDispatch(t *testing.T) {
+	bus := NewBus()
+	got := make([]RockSize, 0, 2)
+	Subscribe(bus, func(e RockDestroyed) {
+		got = append(got, e.Size)
+	}, PriorityNormal)
+
+	Publish(bus, RockDestroyed{Size: RockBig})
+	Publish(bus, RockDestroyed{Size: RockTiny})
+	if len(got) != 0 {
+		t.Errorf("Expected no events before dispatch, got %v", got)
+	}
+
+	bus.Dispatch()
+	if !slices.Equal(got, []RockSize{RockBig, RockTiny}) {
+		t.Errorf("Expected events in publish order, got %v", got)
+	}
+}
+
+func TestBus_OnlyMatchingType(t *testing.T) {
+	bus := NewBus()
+	spawned, destroyed := 0, 0
+	Subscribe(bus, func(RockSpawned) { spawned++ }, PriorityNormal)
+	Subscribe(bus, func(RockDestroyed) { destroyed++ }, PriorityNormal)
+
+	Publish(bus, RockSpawned{Size: RockBig})
+	Publish(bus, AlienFired{})
+	bus.Dispatch()
+
+	if spawned != 1 || destroyed != 0 {
+		t.Errorf("Expected 1 spawned and 0 destroyed, got %d and %d", spawned, destroyed)
+	}
+}
+
+func TestBus_PublishOrderAcrossTypes(t *testing.T) {
+	bus := NewBus()
+	got := make([]string, 0, 4)
+	Subscribe(bus, func(RockSpawned) { got = append(got, "spawned") }, PriorityNormal)
+	Subscribe(bus, func(RockDestroyed) { got = append(got, "destroyed") }, PriorityNormal)
+
+	Publish(bus, RockSpawned{})
+	Publish(bus, RockSpawned{})
+	Publish(bus, RockDestroyed{})
+	bus.Dispatch()
+
+	want := []string{"spawned", "spawned", "destroyed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestBus_Priority(t *testing.T) {
+	bus := NewBus()
+	got := make([]string, 0, 4)
+	Subscribe(bus, func(RockDestroyed) { got = append(got, "low") }, PriorityLow)
+	Subscribe(bus, func(RockDestroyed) { got = append(got, "normal 1") }, PriorityNormal)
+	Subscribe(bus, func(RockDestroyed) { got = append(got, "high") }, PriorityHigh)
+	Subscribe(bus, func(RockDestroyed) { got = append(got, "normal 2") }, PriorityNormal)
+
+	Publish(bus, RockDestroyed{})
+	bus.Dispatch()
+
+	want := []string{"high", "normal 1", "normal 2", "low"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestBus_PublishFromHandler(t *testing.T) {
+	bus := NewBus()
+	got := make([]string, 0, 4)
+	Subscribe(bus, func(RockDestroyed) {
+		got = append(got, "destroyed")
+		Publish(bus, ExtraLife{Lives: 4})
+	}, PriorityNormal)
+	Subscribe(bus, func(RockDestroyed) { got = append(got, "destroyed again") }, PriorityNormal)
+	Subscribe(bus, func(ExtraLife) { got = append(got, "extra life") }, PriorityNormal)
+
+	Publish(bus, RockDestroyed{})
+	bus.Dispatch()
+
+	// The nested event runs after the current one finishes, but still within the same dispatch
+	want := []string{"destroyed", "destroyed again", "extra life"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+	count := 0
+	sub := Subscribe(bus, func(AlienFired) { count++ }, PriorityNormal)
+
+	Publish(bus, AlienFired{})
+	bus.Dispatch()
+	if err := bus.Unsubscribe(sub); err != nil {
+		t.Errorf("Unexpected error unsubscribing: %v", err)
+	}
+	Publish(bus, AlienFired{})
+	bus.Dispatch()
+
+	if count != 1 {
+		t.Errorf("Expected 1 event before unsubscribing, got %d", count)
+	}
+	if err := bus.Unsubscribe(sub); err == nil {
+		t.Errorf("Expected error unsubscribing twice")
+	}
+}
+
+func TestBus_UnsubscribeDuringDispatch(t *testing.T) {
+	bus := NewBus()
+	count := 0
+	var async *Subscription
+	// The first subscriber runs first and removes the second while the event is on its way to it
+	Subscribe(bus, func(AlienFired) {
+		if err := bus.Unsubscribe(async); err != nil {
+			t.Errorf("Unexpected error unsubscribing: %v", err)
+		}
+	}, PriorityHigh)
+	async = SubscribeAsync(bus, func(AlienFired) { count++ })
+
+	Publish(bus, AlienFired{})
+	bus.Dispatch()
+	bus.WaitAsync()
+
+	if count != 0 {
+		t.Errorf("Expected no events after unsubscribing, got %d", count)
+	}
+	if len(bus.closing) != 0 {
+		t.Errorf("Expected the unsubscribed channel closed once dispatch ended")
+	}
+}
+
+func TestBus_SubscribeAsync(t *testing.T) {
+	bus := NewBus()
+	var lock sync.Mutex
+	got := make([]AlienSize, 0, 3)
+	sub := SubscribeAsync(bus, func(e AlienSpawned) {
+		lock.Lock()
+		defer lock.Unlock()
+		got = append(got, e.Size)
+	})
+
+	Publish(bus, AlienSpawned{Size: AlienBig})
+	Publish(bus, AlienSpawned{Size: AlienSmall})
+	Publish(bus, AlienSpawned{Size: AlienBig})
+	bus.Dispatch()
+	bus.WaitAsync()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if !slices.Equal(got, []AlienSize{AlienBig, AlienSmall, AlienBig}) {
+		t.Errorf("Expected async events in publish order, got %v", got)
+	}
+	_ = bus.Unsubscribe(sub)
+}
+
+func TestBus_PublishDoesNotAllocate(t *testing.T) {
+	bus := NewBus()
+	count := 0
+	Subscribe(bus, func(AlienFired) { count++ }, PriorityNormal)
+	Subscribe(bus, func(RockDestroyed) { count++ }, PriorityNormal)
+	tick := func() {
+		Publish(bus, AlienFired{})
+		Publish(bus, RockDestroyed{Size: RockBig})
+		Publish(bus, AlienFired{})
+		bus.Dispatch()
+	}
+	// The first tick grows the queues
+	tick()
+
+	if allocs := testing.AllocsPerRun(100, tick); allocs != 0 {
+		t.Errorf("Expected publishing and dispatching to reuse the queues, got %v allocations", allocs)
+	}
+	count = 0
+	tick()
+	if count != 3 {
+		t.Errorf("Expected every event delivered, got %d", count)
+	}
+}
@@ -0,0 +1,128 @@
+// Package events is the catalog of everything that happens in a game which observers might care
+// about, and the bus that carries it to them. Every event is its own Go type, so subscribing to an
+// event that doesn't exist is a compile error rather than a silent no-op.
+package events
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type RockSize int
+
+const (
+	RockTiny RockSize = iota
+	RockSmall
+	RockMedium
+	RockBig
+)
+
+type AlienSize int
+
+const (
+	AlienSmall AlienSize = iota
+	AlienBig
+)
+
+// Cause is what destroyed something.
+type Cause int
+
+const (
+	CauseUnknown      Cause = iota // Destroyed by something else, such as a debug key
+	CausePlayerBullet              // Shot by a player
+	CauseAlienBullet               // Shot by an alien
+	CauseRock                      // Hit by a rock
+	CauseAlien                     // Rammed by an alien
+	CauseSpaceship                 // Rammed by a player's spaceship
+)
+
+func (c Cause) String() string {
+	switch c {
+	case CausePlayerBullet:
+		return "player bullet"
+	case CauseAlienBullet:
+		return "alien bullet"
+	case CauseRock:
+		return "rock"
+	case CauseAlien:
+		return "alien"
+	case CauseSpaceship:
+		return "spaceship"
+	default:
+		return "unknown"
+	}
+}
+
+// RockSpawned is published when a rock joins the playfield, either at the start of a level or
+// when a bigger rock splits.
+type RockSpawned struct {
+	Size     RockSize
+	Position rl.Vector2
+}
+
+// RockDestroyed is published when a rock is destroyed, after any smaller rocks it split into
+// have spawned.
+type RockDestroyed struct {
+	Size     RockSize
+	Position rl.Vector2
+	Velocity rl.Vector2
+	By       Cause
+	Player   int // The player whose spaceship or bullet it was, or -1 if it wasn't a player
+}
+
+// AlienSpawned is published when an alien ship arrives on the playfield.
+type AlienSpawned struct {
+	Size     AlienSize
+	Position rl.Vector2
+}
+
+// AlienDestroyed is published when an alien ship is destroyed.
+type AlienDestroyed struct {
+	Size     AlienSize
+	Position rl.Vector2
+	By       Cause
+	Player   int // The player whose spaceship or bullet it was, or -1 if it wasn't a player
+}
+
+// AlienLeftPlayfield is published when an alien ship flies off the edge for good.
+type AlienLeftPlayfield struct {
+	Size     AlienSize
+	Position rl.Vector2
+}
+
+// AlienFired is published when an alien ship shoots a bullet.
+type AlienFired struct {
+	Position rl.Vector2
+	Velocity rl.Vector2
+}
+
+// SpaceshipFired is published when a player shoots a bullet.
+type SpaceshipFired struct {
+	Player   int // Index of the player's spaceship in the world
+	Position rl.Vector2
+	Velocity rl.Vector2
+}
+
+// SpaceshipThrust is published when a player starts or stops burning fuel.
+type SpaceshipThrust struct {
+	Player  int
+	Burning bool
+}
+
+// SpaceshipEnteredHyperspace is published when a player jumps into hyperspace.
+type SpaceshipEnteredHyperspace struct {
+	Player   int
+	Position rl.Vector2
+}
+
+// SpaceshipDestroyed is published when a player's spaceship is destroyed.
+type SpaceshipDestroyed struct {
+	Player   int
+	Position rl.Vector2
+	By       Cause
+}
+
+// ExtraLife is published when a player earns another life.
+type ExtraLife struct {
+	Player int
+	Lives  int // The player's lives, counting the new one
+}
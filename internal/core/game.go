@@ -1,58 +1,52 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
 	"avoid_the_space_rocks/internal/utils"
-	"context"
 	"fmt"
-	evbus "github.com/asaskevich/EventBus"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"math"
 	"os"
 	"time"
 )
 
-var instance *Game
-
-// Constants for gameplay feel
-const (
-	shipRotateSpeed float32 = math.Pi * 3 // 1.5 rotations per second
-	shipMaxSpeed    float32 = 400.0
-	shipDecaySpeed  float32 = 1.0
-	shipFuelBoost   float32 = 50.0
-
-	bulletSpeed      float32 = 500.0
-	bulletLifetimeMs uint    = 1250
-
-	shrapnelMaxSpeed  float32 = 500.0
-	shrapnelMaxRotate float32 = math.Pi * 12 // 6 rotations per second
-
-	rockMaxSpeed  float32 = 200.0
-	rockMaxRotate float32 = math.Pi * 6 // 3 rotations per second
-	rockMaxCount          = 30
-
-	alienMaxSpeed       float32 = 400.0
-	alienMaxBulletDrift float32 = math.Pi / 4
-	alienMinActionDelay         = 500
-)
+// RulesVersion identifies the gameplay rules. Bump it whenever a change would make a recorded game
+// play out differently, so replays recorded under older rules can be recognized.
+const RulesVersion = 9
 
 type Game struct {
-	World *World
+	World      *World
+	Random     *utils.Random
+	Scheduler  *Scheduler
+	Tuning     *Tuning    // How the game feels; swap in a new one between ticks to change it mid-game
+	Difficulty Difficulty // Scales the tuning up or down; set with SetDifficulty
+	Mode       Mode       // The rules the game is played under; set before the game starts
+
+	Ships        int  // How many players fly at once, each with a spaceship; set before the world is initialized
+	FriendlyFire bool // Players' bullets destroy each other's spaceships
 
-	Lives int
 	Level int
 	Rocks int
-	Score uint
+	Score uint // Everyone's points together, including for whatever the players didn't destroy themselves
 
 	Paused    bool
 	DebugMode bool
 	Over      bool
 
-	EventBus  evbus.Bus
+	EventBus  *events.Bus
 	Observers []EventObserver
 
-	Overlay func()
+	Overlay func(p platform.Platform) // Draws over the playfield on p, if there's something to show
+
+	startingLives int // The lives each spaceship starts with
+	alienSpawner  *Timer
+	alien         *Alien // The alien the spawner is watching, if any
 
-	levelOver context.CancelFunc
+	// Short-lived objects are reused once they're gone rather than allocated afresh every shot and blast
+	bullets  gameobjects.Pool[Bullet]
+	shrapnel gameobjects.Pool[Shrapnel]
 }
 
 type EventObserver interface {
@@ -61,65 +55,139 @@ type EventObserver interface {
 	Update(game *Game) error
 }
 
-func GetGame() *Game {
-	if instance == nil {
-		panic("Game not initialized. Call InitGame first")
-	}
-	return instance
+// NewGame creates a game with its own world, random source, scheduler, and event bus. Games share
+// nothing, so any number of them can run side by side. A seed of zero picks one from the clock;
+// anything else makes the game replay identically given the same inputs.
+func NewGame(screenWidth, screenHeight float32, seed uint64) *Game {
+	random := utils.NewRandom(seed)
+	game := newGame(screenWidth, screenHeight, random)
+	platform.Log(rl.LogInfo, "Game seed %d", random.Seed())
+	return game
 }
 
-func InitGame(screenWidth, screenHeight float32) *Game {
-	w := NewWorld(screenWidth, screenHeight)
-	instance = &Game{
+// newGame creates a game on a new playfield with the given random source.
+func newGame(screenWidth, screenHeight float32, random *utils.Random) *Game {
+	w := NewWorld(screenWidth, screenHeight, random)
+	game := &Game{
 		World:     w,
-		Lives:     3,
-		EventBus:  evbus.New(),
+		Random:    random,
+		Scheduler: NewScheduler(),
+		Tuning:    DefaultTuning(),
+		Ships:     1,
+		EventBus:  events.NewBus(),
 		Observers: make([]EventObserver, 0, 10),
+
+		startingLives: 3,
 	}
 	if os.Getenv("DEBUG") != "" {
-		instance.DebugMode = true
+		game.DebugMode = true
 	}
-	return instance
+	return game
 }
 
-// StartLevel kicks off a new level. Run this as a goroutine so that the physics
-// engine and everything keeps running.
+// Update advances the game by one simulation tick: timers, then objects and their effects, then the
+// events they published, then the observers.
+func (g *Game) Update(delta float32) {
+	g.Scheduler.Advance(delta)
+	g.World.Objects.Update(delta)
+	g.World.Particles.Update(delta)
+	g.EventBus.Dispatch()
+	for _, obs := range g.Observers {
+		_ = obs.Update(g)
+	}
+}
+
+// StartLevel kicks off a new level. The level number is shown for a couple of seconds of game time
+// and then the rocks and aliens arrive; the physics engine and everything keeps running meanwhile.
 func (g *Game) StartLevel() {
 	g.Level += 1
-	g.Rocks = 0
 
 	// Display the level number for a few seconds
-	rl.TraceLog(rl.LogInfo, "Starting level %d", g.Level)
-	g.Overlay = func() {
-		utils.CenterText(fmt.Sprintf("Level %d", g.Level), rl.Vector2{X: g.World.Width / 2, Y: g.World.Height / 3}, 60)
+	platform.Log(rl.LogInfo, "Starting level %d", g.Level)
+	g.Overlay = func(p platform.Platform) {
+		utils.CenterText(p, fmt.Sprintf("Level %d", g.Level), rl.Vector2{X: g.World.Width / 2, Y: g.World.Height / 3}, 60)
+	}
+	g.Scheduler.After(time.Second*2, func() {
+		g.Overlay = nil
+	})
+	g.Scheduler.After(time.Millisecond*2500, func() {
+		// Kick off the alien spawner
+		g.alienSpawner = AlienSpawner(g)
+
+		// Spawn the appropriate number of rocks
+		count := int(math.Round(float64(g.Level+3) * float64(g.Difficulty.scale().RockCount)))
+		for range min(max(1, count), g.Tuning.Rock.MaxCount) {
+			g.SpawnRock()
+		}
+	})
+}
+
+// RaiseLevel moves straight on to the next level, with no break and no new rocks, for modes where the
+// rocks never stop coming. Aliens come more often and more of them are small from then on.
+func (g *Game) RaiseLevel() {
+	g.Level += 1
+	platform.Log(rl.LogInfo, "Raising level to %d", g.Level)
+	if g.alienSpawner != nil {
+		g.alienSpawner.Cancel()
 	}
-	time.Sleep(time.Second * 2)
-	g.Overlay = nil
-	time.Sleep(time.Millisecond * 500)
-
-	// Kick off the alien spawner
-	ctx, cancel := context.WithCancel(context.Background())
-	g.levelOver = cancel
-	go AlienSpawner(ctx)
-
-	// Spawn the appropriate number of rocks
-	for range min(g.Level+3, rockMaxCount) {
-		rock := NewRock(RockBig, g.World.RandomBorderPosition())
-		g.World.Objects.Add(&rock)
-		g.EventBus.Publish("rock:spawned", RockBig)
+	g.alienSpawner = AlienSpawner(g)
+}
+
+// TrickleDelay returns how long to wait between rocks when they drift in one at a time rather than
+// arriving with each level. They come more often on higher levels and harder difficulties.
+func (g *Game) TrickleDelay() time.Duration {
+	seconds := max(1, 6-0.5*float64(g.Level-1)) / float64(g.Difficulty.scale().RockCount)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// SpawnRock adds a big rock at a random spot on the edge of the playfield.
+func (g *Game) SpawnRock() {
+	rock := NewRock(g, RockBig, g.World.RandomBorderPosition())
+	g.World.Objects.Add(&rock)
+	events.Publish(g.EventBus, events.RockSpawned{Size: RockBig, Position: rock.Position})
+}
+
+// blast returns the explosion of something destroyed at the position, for its Blast method.
+func (g *Game) blast(position rl.Vector2) (rl.Vector2, float32, float32) {
+	return position, g.Tuning.Explosion.Impulse, g.Tuning.Explosion.Radius
+}
+
+// explode blasts whatever is near the destroyed object away from it. Whatever it collided with is nil
+// if it wasn't destroyed in a collision; otherwise the collection sets off the blast itself once the
+// collision has been handled.
+func (g *Game) explode(destroyed gameobjects.Exploder, by gameobjects.Collidable) {
+	if by == nil {
+		g.World.Objects.Explode(destroyed.Blast())
 	}
 }
 
+// Lives returns how many lives the players have left between them.
+func (g *Game) Lives() int {
+	lives := 0
+	for _, ship := range g.World.Spaceships {
+		lives += ship.Lives
+	}
+	return lives
+}
+
+// LevelUnderway returns true from when the level's rocks arrive until the level is stopped.
+func (g *Game) LevelUnderway() bool {
+	return g.alienSpawner != nil
+}
+
 // StopLevel runs the end of level logic
 func (g *Game) StopLevel() {
-	g.levelOver()
+	g.alienSpawner.Cancel()
+	g.alienSpawner = nil
+	g.alien = nil
 }
 
-// GameOver is called when the player has no more lives.
+// GameOver is called when the players have no more lives.
 func (g *Game) GameOver() {
-	g.Overlay = func() {
-		utils.CenterText("Game Over", rl.Vector2{X: g.World.Width / 2, Y: g.World.Height / 3}, 60)
+	g.Overlay = func(p platform.Platform) {
+		utils.CenterText(p, "Game Over", rl.Vector2{X: g.World.Width / 2, Y: g.World.Height / 3}, 60)
 	}
-	time.Sleep(time.Second * 5)
-	g.Overlay = nil
+	g.Scheduler.After(time.Second*5, func() {
+		g.Overlay = nil
+	})
 }
@@ -1,21 +1,19 @@
 package core
 
 import (
-	"os"
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
+	rl "github.com/gen2brain/raylib-go/raylib"
 	"testing"
 )
 
-func TestMain(m *testing.M) {
-	InitGame(800, 600)
-	code := m.Run()
-	os.Exit(code)
-}
-
-func TestInitGame(t *testing.T) {
-	game := GetGame()
+func TestNewGame(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 42)
+	game.World.Initialize(game)
 
-	if game.Lives != 3 {
-		t.Errorf("Expected Lives to be 3, got %d", game.Lives)
+	if game.Lives() != 3 {
+		t.Errorf("Expected Lives to be 3, got %d", game.Lives())
 	}
 
 	if game.Level != 0 {
@@ -33,11 +31,73 @@ func TestInitGame(t *testing.T) {
 	if game.World.Height != 600 {
 		t.Errorf("Expected World height to be 600, got %f", game.World.Height)
 	}
+
+	if game.Random.Seed() != 42 {
+		t.Errorf("Expected seed to be 42, got %d", game.Random.Seed())
+	}
 }
 
-func TestGetGame(t *testing.T) {
-	game := GetGame()
-	if game == nil {
-		t.Fatal("Expected game to be initialized, got nil")
+func TestNewGame_Independent(t *testing.T) {
+	t.Parallel()
+	game1 := NewGame(800, 600, 42)
+	game2 := NewGame(400, 300, 42)
+
+	rock := NewRock(game1, RockBig, rl.NewVector2(100, 100))
+	game1.World.Objects.Add(&rock)
+	if err := rock.OnDestruction(nil, rl.NewVector2(1, 0)); err != nil {
+		t.Fatalf("Unexpected error during destruction: %v", err)
+	}
+	game1.Update(0.1)
+
+	anything := func(gameobjects.GameObject) bool { return true }
+	if !game1.World.Objects.Any(anything) {
+		t.Errorf("Expected the destroyed rock to leave pieces in its own game")
+	}
+	if game2.World.Objects.Any(anything) {
+		t.Errorf("Expected the other game's playfield to be untouched")
+	}
+	if game2.Scheduler.Now() != 0 {
+		t.Errorf("Expected the other game's clock not to move, at %v", game2.Scheduler.Now())
+	}
+}
+
+// BenchmarkFirefight plays a heavy firefight: two spaceships trading fire with friendly fire on, a
+// bullet from each every frame, and a rock's worth of shrapnel flying every few frames. In Zen the
+// spaceships survive the hits, so nothing changes from frame to frame but the bullets, their trails,
+// and the shrapnel coming and going. Once the pools hold enough of them, and the event queues enough of
+// the shots, a frame allocates nothing.
+func BenchmarkFirefight(b *testing.B) {
+	game := NewGame(800, 600, 1)
+	game.Mode = ModeZen
+	game.FriendlyFire = true
+	game.Ships = 2
+	game.World.Initialize(game)
+	shrapnel := gameobjects.LoadSpriteSheet("shrapnel.png", 5, 1)
+	screen := platform.NewHeadless()
+	frames := 0
+	frame := func() {
+		left, right := game.World.Spaceships[0], game.World.Spaceships[1]
+		left.Position, left.Velocity, left.Rotation = rl.NewVector2(200, 300), rl.Vector2{}, rl.NewVector2(1, 0)
+		right.Position, right.Velocity, right.Rotation = rl.NewVector2(600, 300), rl.Vector2{}, rl.NewVector2(-1, 0)
+		left.Fire()
+		right.Fire()
+		if frames%4 == 0 {
+			for i := range 8 {
+				game.World.Objects.Add(NewShrapnel(game, rl.NewVector2(400, 100), shrapnel, 500, i%5))
+			}
+		}
+		game.Update(1.0 / 60)
+		game.World.Objects.Draw(screen)
+		frames++
+	}
+	// Play long enough for everything fired at the start to be gone and back in the pools
+	for range 300 {
+		frame()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		frame()
 	}
 }
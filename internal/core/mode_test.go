@@ -0,0 +1,81 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+	for _, m := range Modes {
+		text, err := m.MarshalText()
+		if err != nil {
+			t.Fatalf("Unexpected error writing %v: %v", m, err)
+		}
+		var parsed Mode
+		if err := parsed.UnmarshalText(text); err != nil || parsed != m {
+			t.Errorf("Expected %q to read back as %v, got %v (%v)", text, m, parsed, err)
+		}
+	}
+	for _, name := range []string{"time-attack", "Time Attack", "TIME-ATTACK"} {
+		if m, err := ParseMode(name); err != nil || m != ModeTimeAttack {
+			t.Errorf("Expected %q to be Time Attack, got %v (%v)", name, m, err)
+		}
+	}
+	if _, err := ParseMode("deathmatch"); err == nil {
+		t.Errorf("Expected an unknown mode to be an error")
+	}
+}
+
+func TestModeZen_SpaceshipCantBreak(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 42)
+	game.Mode = ModeZen
+	game.World.Initialize(game)
+	rock := NewRock(game, RockBig, game.World.Spaceships[0].Position)
+	if err := rock.OnCollision(game.World.Spaceships[0]); err != nil {
+		t.Fatalf("Unexpected error colliding: %v", err)
+	}
+	if !game.World.Spaceships[0].IsAlive() {
+		t.Errorf("Expected the spaceship to survive a rock in Zen")
+	}
+
+	game.Mode = ModeClassic
+	if err := rock.OnCollision(game.World.Spaceships[0]); err != nil {
+		t.Fatalf("Unexpected error colliding: %v", err)
+	}
+	if game.World.Spaceships[0].IsAlive() {
+		t.Errorf("Expected the spaceship to break on a rock in Classic")
+	}
+}
+
+func TestModeZen_NoAliens(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 42)
+	game.Mode = ModeZen
+	game.World.Initialize(game)
+	game.StartLevel()
+	for range 60 * 120 {
+		game.Update(1.0 / 120)
+		if game.alien != nil {
+			t.Fatalf("Expected no aliens in Zen, got one at %v", game.Scheduler.Now())
+		}
+	}
+	if !game.LevelUnderway() {
+		t.Errorf("Expected the level to be under way without aliens")
+	}
+}
+
+func TestRaiseLevel(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 42)
+	game.World.Initialize(game)
+	game.RaiseLevel()
+	if game.Level != 1 || !game.LevelUnderway() || game.Overlay != nil {
+		t.Errorf("Expected level 1 to be under way straight away, got level %d", game.Level)
+	}
+	first := game.TrickleDelay()
+	game.RaiseLevel()
+	if game.Level != 2 || game.TrickleDelay() >= first {
+		t.Errorf("Expected rocks to come more often on level 2, every %v rather than %v", game.TrickleDelay(), first)
+	}
+}
@@ -1,18 +1,20 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/core/events"
 	"avoid_the_space_rocks/internal/gameobjects"
-	"avoid_the_space_rocks/internal/utils"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
 )
 
-type RockSize int
+type RockSize = events.RockSize
 
 const (
-	RockTiny RockSize = iota
-	RockSmall
-	RockMedium
-	RockBig
+	RockTiny   = events.RockTiny
+	RockSmall  = events.RockSmall
+	RockMedium = events.RockMedium
+	RockBig    = events.RockBig
 )
 
 // Create a constant array of four string elements
@@ -23,6 +25,9 @@ var rockSpriteFile = []string{
 	"rock_big.png",
 }
 
+// rockMass is how heavy each size of rock is, in proportion to the area of its sprite.
+var rockMass = []float32{1, 6, 14, 39}
+
 // Rock is a game object that has a consistent rotation speed and constant velocity.
 type Rock struct {
 	gameobjects.Rigidbody
@@ -30,13 +35,18 @@ type Rock struct {
 	rotationSpeed float32 // rotations per second
 	isAlive       bool
 	size          RockSize
+	shape         gameobjects.Circle // Reused by GetShape rather than allocating an outline every tick
+	game          *Game
 }
 
 var _ gameobjects.Collidable = (*Rock)(nil)
 var _ gameobjects.Destructible = (*Rock)(nil)
 var _ gameobjects.GameObject = (*Rock)(nil)
+var _ gameobjects.Pushable = (*Rock)(nil)
+var _ gameobjects.Exploder = (*Rock)(nil)
 
-func NewRock(size RockSize, position rl.Vector2) Rock {
+func NewRock(game *Game, size RockSize, position rl.Vector2) Rock {
+	random := game.Random
 	sheet := gameobjects.LoadSpriteSheet(rockSpriteFile[size], 1, 1)
 	rock := Rock{
 		spritesheet: sheet,
@@ -46,35 +56,45 @@ func NewRock(size RockSize, position rl.Vector2) Rock {
 				Rotation: rl.Vector2{X: 1, Y: 0},
 			},
 		},
-		rotationSpeed: utils.RndFloat32(rockMaxRotate) / 4,
+		rotationSpeed: random.RndFloat32(turnsToRadians(game.Tuning.Rock.MaxRotate)) / 4,
 		isAlive:       true,
 		size:          size,
+		game:          game,
 	}
 	// Half of 'em rotate counterclockwise
-	if utils.Chance(0.5) {
+	if random.Chance(0.5) {
 		rock.rotationSpeed = -rock.rotationSpeed
 	}
 	// Randomize the speed and direction
-	maxSpeed := rockMaxSpeed / float32(size+2)
+	maxSpeed := game.Tuning.Rock.MaxSpeed * game.Difficulty.scale().RockSpeed / float32(size+2)
 	rock.Velocity = rl.Vector2{
-		X: utils.RndFloat32InRange(-maxSpeed, maxSpeed),
-		Y: utils.RndFloat32InRange(-maxSpeed, maxSpeed),
+		X: random.RndFloat32InRange(-maxSpeed, maxSpeed),
+		Y: random.RndFloat32InRange(-maxSpeed, maxSpeed),
 	}
 	return rock
 }
 
 // Update applies physics to the rock so it moves per its velocity and rotation speed.
 func (r *Rock) Update(delta float32) error {
-	game := GetGame()
 	r.Rotation = rl.Vector2Rotate(r.Rotation, r.rotationSpeed*delta)
 	r.Rigidbody.ApplyPhysics(delta)
-	r.Position = game.World.Wraparound(r.Position)
+	r.Position = r.game.World.Wraparound(r.Position)
 	return nil
 }
 
 // Draw renders the rock to the screen.
-func (r *Rock) Draw() error {
-	return r.spritesheet.Draw(0, 0, r.Position, r.Rotation)
+func (r *Rock) Draw(p platform.Platform) error {
+	return r.spritesheet.DrawWrapped(p, 0, 0, r.Position, r.Rotation, r.game.World.Width, r.game.World.Height)
+}
+
+// Mass returns how heavy the rock is, which depends only on its size.
+func (r *Rock) Mass() float32 {
+	return rockMass[r.size]
+}
+
+// Blast returns the explosion the rock goes off with when it's destroyed.
+func (r *Rock) Blast() (rl.Vector2, float32, float32) {
+	return r.game.blast(r.Position)
 }
 
 // IsAlive returns whether the rock is alive or not.
@@ -91,51 +111,112 @@ func (r *Rock) GetHitbox() rl.Rectangle {
 	return r.spritesheet.GetRectangle(r.Position)
 }
 
+// GetShape returns the rock's outline for collision detection, a circle a little inside the corners of
+// its sprite, since rocks are round enough that turning doesn't change what they hit.
+func (r *Rock) GetShape() gameobjects.Shape {
+	r.shape = gameobjects.Circle{Center: r.Position, Radius: r.radius()}
+	return &r.shape
+}
+
+// radius returns the radius of the rock's outline.
+func (r *Rock) radius() float32 {
+	size := r.spritesheet.GetSize()
+	return min(size.X, size.Y) / 2 * 0.9
+}
+
 // OnCollision handles the collision of the rock with another Collidable object. If object
 // is destructible it destroys it -- unless it's a rock; rocks don't destroy rocks in this game.
+// In modes with physical rocks they bounce off each other instead of passing through.
 func (r *Rock) OnCollision(other gameobjects.Collidable) error {
-	if destructible, ok := other.(gameobjects.Destructible); ok {
-		if _, ok := other.(*Rock); ok {
-			return nil
+	if rock, ok := other.(*Rock); ok {
+		if r.game.Mode.rules().PhysicalRocks {
+			r.bounceOff(rock)
 		}
-		return destructible.OnDestruction(r.Velocity)
+		return nil
+	}
+	if destructible, ok := other.(gameobjects.Destructible); ok {
+		return destructible.OnDestruction(r, r.Velocity)
 	}
 	return nil
 }
 
+// bounceOff bounces the rock and another it has run into off each other like billiard balls, the
+// heavier one barely slowing while the lighter one is knocked away. Both rocks' OnCollision methods
+// are called for the same collision, but once they've bounced they're moving apart, so the second
+// call leaves them be.
+func (r *Rock) bounceOff(other *Rock) {
+	normal := r.game.World.Towards(r.Position, other.Position)
+	gameobjects.Bounce(&r.Rigidbody, r.Mass(), &other.Rigidbody, other.Mass(), normal)
+}
+
 // OnDestruction handles the destruction of the rock, spawning smaller rocks if applicable.
 // This is called by the bullet's OnCollision method when it hits this rock.
-func (r *Rock) OnDestruction(bulletVelocity rl.Vector2) error {
-	game := GetGame()
+func (r *Rock) OnDestruction(by gameobjects.Collidable, bulletVelocity rl.Vector2) error {
+	game := r.game
+	if !r.isAlive {
+		// Already destroyed by something else it overlapped this tick
+		return nil
+	}
 	r.isAlive = false
 	// Spawn smaller rocks at same location as appropriate for level
-	if int(r.size) > max(0, 4-game.Level) {
+	tuning := &game.Tuning.Rock
+	if fromLevel, ok := tuning.splitFromLevel(r.size); ok && game.Level >= fromLevel {
 		// Span more rocks at higher levels, but if we've hit our cap, replace one for one
-		toSpawn := utils.RndIntInRange(2, max(3, int(game.Level/2)))
-		if game.Rocks >= rockMaxCount {
+		toSpawn := game.Random.RndIntInRange(tuning.MinPieces, max(tuning.MaxPieces, game.Level/tuning.LevelsPerExtraPiece)+1)
+		if game.Rocks >= tuning.MaxCount {
 			toSpawn = 1
 		}
-		for range toSpawn {
+		game.World.Particles.Burst(rockDust, 8*int(r.size), r.Position, rl.Vector2{X: 1}, r.Velocity)
+		for i := range toSpawn {
 			// Spawn a new rock at the same position as the old one but a bit away from dir of the bullet
-			newRock := NewRock(r.size-1, r.Position)
+			newRock := NewRock(game, r.size-1, r.Position)
 			spriteWidth := newRock.spritesheet.GetRectangle(newRock.Position).Width / 2
 			scaledBulletVelocity := rl.Vector2Scale(rl.Vector2Normalize(bulletVelocity), spriteWidth)
+			if game.Mode.rules().PhysicalRocks {
+				scaledBulletVelocity = splitOffset(bulletVelocity, i, toSpawn, newRock.radius())
+			}
 			newRock.Position = rl.Vector2Add(newRock.Position, scaledBulletVelocity)
 			game.World.Objects.Add(&newRock)
-			game.EventBus.Publish("rock:spawned", r.size)
-			// Add a bit of bullet velocity to each new rock so more likely moving away
-			newRock.Velocity = rl.Vector2Add(newRock.Velocity, rl.Vector2Scale(bulletVelocity, 0.1))
+			events.Publish(game.EventBus, events.RockSpawned{Size: newRock.size, Position: newRock.Position})
+			// The bullet's blow pushes each new rock, the small ones hardest, so they're more likely moving away
+			newRock.ApplyImpulse(rl.Vector2Scale(bulletVelocity, game.Tuning.Bullet.Mass), newRock.Mass())
 		}
 	}
 	// Spawn shrapnel in random directions and lifespans
 	sheet := gameobjects.LoadSpriteSheet("shrapnel.png", 5, 1)
-	for range utils.RndIntInRange(int(r.size)+2, int(r.size*2)+4) {
-		frame := int(utils.RndIntInRange(0, 4))
-		shrapnel := NewShrapnel(r.Position, sheet, uint(utils.RndIntInRange(300, 600)), frame)
-		game.World.Objects.Add(&shrapnel)
+	for range game.Random.RndIntInRange(int(r.size)+2, int(r.size*2)+4) {
+		frame := int(game.Random.RndIntInRange(0, 4))
+		shrapnel := NewShrapnel(game, r.Position, sheet, uint(game.Random.RndIntInRange(300, 600)), frame)
+		game.World.Objects.Add(shrapnel)
 	}
+	game.explode(r, by)
 	// Notify other services
-	game.EventBus.Publish("rock:destroyed", r.size)
+	events.Publish(game.EventBus, events.RockDestroyed{
+		Size:     r.size,
+		Position: r.Position,
+		Velocity: r.Velocity,
+		By:       causeOf(by),
+		Player:   playerOf(by),
+	})
 
 	return nil
 }
+
+// splitOffset returns where the ith of count rocks of the given radius, split from one hit by something
+// moving in the direction, goes relative to where it was hit. They're spread evenly round a circle
+// starting from the direction, just far enough out that neighbours don't overlap, so rocks that bounce
+// off each other don't all collide the moment they're split.
+func splitOffset(direction rl.Vector2, i, count int, radius float32) rl.Vector2 {
+	direction = rl.Vector2Normalize(direction)
+	if direction == (rl.Vector2{}) {
+		direction = rl.Vector2{X: 1}
+	}
+	distance := radius
+	if count > 1 {
+		// Neighbours are 2 sin(pi/count) times the distance apart, and need to be two radii apart, plus
+		// a little to spare for rounding
+		distance = radius / float32(math.Sin(math.Pi/float64(count))) * 1.01
+	}
+	angle := 2 * math.Pi * float32(i) / float32(count)
+	return rl.Vector2Scale(rl.Vector2Rotate(direction, angle), distance)
+}
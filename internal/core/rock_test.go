@@ -3,12 +3,17 @@ package core
 import (
 	"avoid_the_space_rocks/internal/gameobjects"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestNewRock(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
 	position := rl.NewVector2(100, 100)
-	rock := NewRock(RockMedium, position)
+	rock := NewRock(game, RockMedium, position)
 
 	if rock.Position != position {
 		t.Errorf("Expected position %v, got %v", position, rock.Position)
@@ -23,9 +28,26 @@ func TestNewRock(t *testing.T) {
 	}
 }
 
+func TestRock_GetShape(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	rock := NewRock(game, RockBig, rl.NewVector2(100, 100))
+	hitbox := rock.GetHitbox()
+
+	circle, ok := rock.GetShape().(*gameobjects.Circle)
+	if !ok || circle.Center != rock.Position || circle.Radius <= 0 || circle.Radius >= hitbox.Width/2 {
+		t.Errorf("Expected a circle at %v inside the sprite %v, got %v", rock.Position, hitbox, rock.GetShape())
+	}
+	if gameobjects.Overlaps(circle, gameobjects.Circle{Center: rl.NewVector2(hitbox.X+2, hitbox.Y+2), Radius: 1}) {
+		t.Errorf("Expected the corner of the sprite to be clear of the rock")
+	}
+}
+
 func TestRock_GetHitbox(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
 	position := rl.NewVector2(100, 100)
-	rock := NewRock(RockMedium, position)
+	rock := NewRock(game, RockMedium, position)
 	expectedHitbox := rock.spritesheet.GetRectangle(position)
 
 	hitbox := rock.GetHitbox()
@@ -35,8 +57,10 @@ func TestRock_GetHitbox(t *testing.T) {
 }
 
 func TestRock_OnRockCollision(t *testing.T) {
-	rock := NewRock(RockMedium, rl.NewVector2(100, 100))
-	rock2 := NewRock(RockSmall, rl.NewVector2(100, 100))
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	rock := NewRock(game, RockMedium, rl.NewVector2(100, 100))
+	rock2 := NewRock(game, RockSmall, rl.NewVector2(100, 100))
 
 	err := rock.OnCollision(&rock2)
 	if err != nil {
@@ -51,8 +75,10 @@ func TestRock_OnRockCollision(t *testing.T) {
 }
 
 func TestRock_OnSpaceshipCollision(t *testing.T) {
-	rock := NewRock(RockMedium, rl.NewVector2(100, 100))
-	spaceship := NewSpaceship()
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	rock := NewRock(game, RockMedium, rl.NewVector2(100, 100))
+	spaceship := NewSpaceship(game, 0)
 	spaceship.Position = rl.NewVector2(100, 100)
 
 	err := rock.OnCollision(&spaceship)
@@ -66,8 +92,10 @@ func TestRock_OnSpaceshipCollision(t *testing.T) {
 }
 
 func TestRock_OnAlienCollision(t *testing.T) {
-	rock := NewRock(RockMedium, rl.NewVector2(100, 100))
-	alien := NewAlien(AlienBig, rl.NewVector2(100, 100))
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	rock := NewRock(game, RockMedium, rl.NewVector2(100, 100))
+	alien := NewAlien(game, AlienBig, rl.NewVector2(100, 100))
 
 	err := rock.OnCollision(&alien)
 	if err != nil {
@@ -80,9 +108,10 @@ func TestRock_OnAlienCollision(t *testing.T) {
 }
 
 func TestRock_OnDestruction(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
 
 	// To start assert the game has no small rocks
-	game := GetGame()
 	if game.World.Objects.Any(
 		func(obj gameobjects.GameObject) bool {
 			return obj.(*Rock).size == RockSmall
@@ -90,10 +119,10 @@ func TestRock_OnDestruction(t *testing.T) {
 		t.Errorf("Expected game.World.Objects to not contain small rocks")
 	}
 
-	rock := NewRock(RockMedium, rl.NewVector2(100, 100))
+	rock := NewRock(game, RockMedium, rl.NewVector2(100, 100))
 	bulletVelocity := rl.NewVector2(1, 1)
 
-	err := rock.OnDestruction(bulletVelocity)
+	err := rock.OnDestruction(nil, bulletVelocity)
 	if err != nil {
 		t.Errorf("Unexpected error during destruction: %v", err)
 	}
@@ -115,3 +144,194 @@ func TestRock_OnDestruction(t *testing.T) {
 		t.Errorf("Expected game.World.Objects to contain only small rocks")
 	}
 }
+
+func TestRock_BouncesInBilliards(t *testing.T) {
+	t.Parallel()
+	for _, mode := range []Mode{ModeClassic, ModeBilliards} {
+		game := NewGame(800, 600, 1)
+		game.Mode = mode
+		big := NewRock(game, RockBig, rl.NewVector2(400, 300))
+		small := NewRock(game, RockSmall, rl.NewVector2(460, 310))
+		big.Velocity, small.Velocity = rl.NewVector2(30, 0), rl.NewVector2(-60, 0)
+		momentum := func() rl.Vector2 {
+			return rl.Vector2Add(rl.Vector2Scale(big.Velocity, big.Mass()), rl.Vector2Scale(small.Velocity, small.Mass()))
+		}
+		energy := func() float32 {
+			return big.Mass()*rl.Vector2LengthSqr(big.Velocity)/2 + small.Mass()*rl.Vector2LengthSqr(small.Velocity)/2
+		}
+		beforeMomentum, beforeEnergy := momentum(), energy()
+
+		if err := big.OnCollision(&small); err != nil {
+			t.Fatalf("Unexpected error colliding: %v", err)
+		}
+		if err := small.OnCollision(&big); err != nil {
+			t.Fatalf("Unexpected error colliding: %v", err)
+		}
+
+		if mode == ModeClassic {
+			if big.Velocity != rl.NewVector2(30, 0) || small.Velocity != rl.NewVector2(-60, 0) {
+				t.Errorf("Expected rocks to pass through each other in Classic, got %v and %v", big.Velocity, small.Velocity)
+			}
+			continue
+		}
+		if small.Velocity.X <= 0 || big.Velocity.X >= 30 {
+			t.Errorf("Expected the small rock knocked back and the big one slowed, got %v and %v", small.Velocity, big.Velocity)
+		}
+		if after := momentum(); rl.Vector2Distance(after, beforeMomentum) > 0.01 {
+			t.Errorf("Expected momentum %v to be kept, got %v", beforeMomentum, after)
+		}
+		if after := energy(); math.Abs(float64(after-beforeEnergy)) > 0.01*float64(beforeEnergy) {
+			t.Errorf("Expected energy %v to be kept, got %v", beforeEnergy, after)
+		}
+	}
+}
+
+func TestRock_BouncesAcrossEdges(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.Mode = ModeBilliards
+	left := NewRock(game, RockMedium, rl.NewVector2(10, 300))
+	right := NewRock(game, RockMedium, rl.NewVector2(780, 300))
+	left.Velocity, right.Velocity = rl.NewVector2(-20, 0), rl.NewVector2(20, 0)
+
+	// Heading towards each other across the edge, equal rocks swap velocities
+	if err := left.OnCollision(&right); err != nil {
+		t.Fatalf("Unexpected error colliding: %v", err)
+	}
+	if left.Velocity != rl.NewVector2(20, 0) || right.Velocity != rl.NewVector2(-20, 0) {
+		t.Errorf("Expected the rocks to bounce apart across the edge, got %v and %v", left.Velocity, right.Velocity)
+	}
+}
+
+func TestRock_SplitRocksDontCollide(t *testing.T) {
+	t.Parallel()
+	for split := range 20 {
+		game := NewGame(800, 600, uint64(split))
+		game.Mode = ModeBilliards
+		game.Level = 10
+		rock := NewRock(game, RockBig, rl.NewVector2(400, 300))
+		if err := rock.OnDestruction(nil, rl.NewVector2(0, -500)); err != nil {
+			t.Fatalf("Unexpected error destroying: %v", err)
+		}
+
+		_, added := game.World.Objects.Contents()
+		var children []*Rock
+		for _, obj := range added {
+			if child, ok := obj.(*Rock); ok {
+				children = append(children, child)
+			}
+		}
+		if len(children) < 2 {
+			t.Fatalf("Expected the big rock to split into several, got %d", len(children))
+		}
+		for i, a := range children {
+			for _, b := range children[:i] {
+				if gameobjects.Overlaps(a.GetShape(), b.GetShape()) {
+					t.Errorf("Expected split rocks apart, got %v and %v in %d pieces", a.Position, b.Position, len(children))
+				}
+			}
+		}
+	}
+}
+
+func TestRock_ExplosionPushesNeighbours(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.Mode = ModeZen
+	game.World.Initialize(game)
+	game.World.Objects.Update(0) // Bring the spaceship into the world
+	ship := game.World.Spaceships[0]
+	ship.Position, ship.Velocity = rl.NewVector2(400, 300), rl.Vector2{}
+
+	near := NewRock(game, RockTiny, rl.NewVector2(350, 300))
+	if err := near.OnDestruction(nil, rl.Vector2{}); err != nil {
+		t.Fatalf("Unexpected error during destruction: %v", err)
+	}
+	if ship.Velocity.X <= 0 || ship.Velocity.Y != 0 {
+		t.Errorf("Expected the blast to push the spaceship straight away from it, got %v", ship.Velocity)
+	}
+
+	pushed := ship.Velocity
+	far := NewRock(game, RockTiny, rl.NewVector2(400, 300+2*game.Tuning.Explosion.Radius))
+	if err := far.OnDestruction(nil, rl.Vector2{}); err != nil {
+		t.Fatalf("Unexpected error during destruction: %v", err)
+	}
+	if ship.Velocity != pushed {
+		t.Errorf("Expected a blast out of reach to leave the spaceship alone, got %v", ship.Velocity)
+	}
+}
+
+func TestRock_DustOnSplit(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.World.Initialize(game)
+	game.Level = game.Tuning.Rock.SplitFromLevel.Big
+
+	// Medium rocks don't split until a later level than big ones, so they leave only shrapnel
+	medium := NewRock(game, RockMedium, rl.NewVector2(100, 100))
+	_ = medium.OnDestruction(nil, rl.NewVector2(1, 0))
+	if n := game.World.Particles.Len(); n != 0 {
+		t.Errorf("Expected no dust from a rock that doesn't split, got %d particles", n)
+	}
+
+	big := NewRock(game, RockBig, rl.NewVector2(100, 100))
+	_ = big.OnDestruction(nil, rl.NewVector2(1, 0))
+	if n := game.World.Particles.Len(); n == 0 {
+		t.Errorf("Expected a cloud of dust when a rock splits")
+	}
+}
+
+func TestRock_SplitsIntoMaxPieces(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	data := `{"rock": {"min_pieces": 4, "max_pieces": 4, "split_from_level": {"big": 1}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Unexpected error writing tuning: %v", err)
+	}
+	tuning, err := LoadTuning(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading tuning: %v", err)
+	}
+	game := NewGame(800, 600, 1)
+	game.Tuning = tuning
+	game.Level = 1
+
+	rock := NewRock(game, RockBig, rl.NewVector2(100, 100))
+	if err := rock.OnDestruction(nil, rl.NewVector2(1, 0)); err != nil {
+		t.Fatalf("Unexpected error during destruction: %v", err)
+	}
+	game.World.Objects.Update(0.1)
+	pieces := 0
+	game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
+		if r, ok := obj.(*Rock); ok && r.size == RockMedium {
+			pieces++
+		}
+	})
+	if pieces != 4 {
+		t.Errorf("Expected the rock to split into exactly 4 pieces, got %d", pieces)
+	}
+}
+
+func TestRock_ShotExplosionPushesNeighbours(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.Mode = ModeZen
+	game.World.Initialize(game)
+	game.World.Objects.Update(0) // Bring the spaceship into the world
+	ship := game.World.Spaceships[0]
+	ship.Position, ship.Velocity = rl.NewVector2(400, 300), rl.Vector2{}
+
+	// Shot during the collision check, where the collection sets off the blast
+	rock := NewRock(game, RockTiny, rl.NewVector2(350, 300))
+	rock.Velocity = rl.Vector2{}
+	game.World.Objects.Add(&rock)
+	game.World.Objects.Add(NewBullet(game, rock.Position, rl.Vector2{}, ship))
+	game.World.Objects.Update(0)
+
+	if rock.IsAlive() {
+		t.Fatalf("Expected the bullet to destroy the rock")
+	}
+	if ship.Velocity.X <= 0 || math.Abs(float64(ship.Velocity.Y)) > 1e-3 {
+		t.Errorf("Expected the blast to push the spaceship straight away from it, got %v", ship.Velocity)
+	}
+}
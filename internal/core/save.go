@@ -0,0 +1,468 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// SaveVersion is the version of the saved game format. Bump it whenever the format changes, and add a
+// migration from the previous version to saveMigrations so older saves still load.
+const SaveVersion = 4
+
+// saveMigrations upgrade a decoded save from the version it's keyed by to the next version.
+var saveMigrations = map[int]func(save map[string]any) error{
+	// Games saved before difficulty presets were all Normal
+	1: func(save map[string]any) error {
+		save["difficulty"] = "normal"
+		return nil
+	},
+	// Games saved before there were other modes were all Classic
+	2: func(save map[string]any) error {
+		save["mode"] = "classic"
+		return nil
+	},
+	// Games saved before players could fly together had the one spaceship, with all the lives and points
+	3: func(save map[string]any) error {
+		ship, ok := save["spaceship"].(map[string]any)
+		if !ok {
+			return errors.New("no spaceship")
+		}
+		ship["lives"] = save["lives"]
+		ship["score"] = save["score"]
+		save["spaceships"] = []any{ship}
+		delete(save, "spaceship")
+		delete(save, "lives")
+		return nil
+	},
+}
+
+// ErrCannotSaveYet is returned when saving while the game is between states that can't be saved: the
+// level is starting, or a spaceship is exploding, respawning, or in hyperspace.
+var ErrCannotSaveYet = errors.New("the game can only be saved while the level is under way and the spaceships are flying")
+
+// savedGame is everything needed to carry on a game exactly where it left off. Outside of the
+// moments ErrCannotSaveYet covers, the only timers running are the alien spawner and the aliens'
+// runners, so those are all the scheduler state there is to save.
+type savedGame struct {
+	Version      int              `json:"version"`
+	Width        float32          `json:"width"`
+	Height       float32          `json:"height"`
+	Random       []byte           `json:"random"`
+	Clock        time.Duration    `json:"clock"`
+	Level        int              `json:"level"`
+	Rocks        int              `json:"rocks"`
+	Score        uint             `json:"score"`
+	Difficulty   Difficulty       `json:"difficulty"`
+	Mode         Mode             `json:"mode"`
+	FriendlyFire bool             `json:"friendly_fire,omitempty"`
+	Spaceships   []savedSpaceship `json:"spaceships"`
+	Objects      []savedObject    `json:"objects"`     // In update order; a spaceship is just its kind and player
+	NewObjects   []savedObject    `json:"new_objects"` // Added since the last update
+	AlienSpawner time.Duration    `json:"alien_spawner"`
+	Alien        int              `json:"alien"`      // Index of the alien the spawner is watching, counting Objects then NewObjects; -1 if none
+	AlienGone    bool             `json:"alien_gone"` // The alien the spawner was watching has gone, so it skips its next spawn
+}
+
+type savedObject struct {
+	Kind          string                 `json:"kind"`
+	Body          *gameobjects.Rigidbody `json:"body,omitempty"`
+	Alive         bool                   `json:"alive,omitempty"`
+	Size          int                    `json:"size,omitempty"`
+	RotationSpeed float32                `json:"rotation_speed,omitempty"`
+	BulletDrift   float32                `json:"bullet_drift,omitempty"`
+	Runner        *time.Duration         `json:"runner,omitempty"` // Game time until the alien next acts
+	PlayerFired   bool                   `json:"player_fired,omitempty"`
+	Player        int                    `json:"player,omitempty"` // Whose spaceship it is, or who fired the bullet
+	AgeMs         uint                   `json:"age_ms,omitempty"`
+	LifespanMs    uint                   `json:"lifespan_ms,omitempty"`
+	Frame         int                    `json:"frame,omitempty"`
+	Sheet         *savedSheet            `json:"sheet,omitempty"`
+	FuelBurning   bool                   `json:"fuel_burning,omitempty"`
+}
+
+type savedSpaceship struct {
+	savedObject
+	Lives int  `json:"lives"`
+	Score uint `json:"score"`
+	Out   bool `json:"out,omitempty"`
+}
+
+type savedSheet struct {
+	File string `json:"file"`
+	Rows int    `json:"rows"`
+	Cols int    `json:"cols"`
+}
+
+const (
+	kindSpaceship = "spaceship"
+	kindRock      = "rock"
+	kindAlien     = "alien"
+	kindBullet    = "bullet"
+	kindShrapnel  = "shrapnel"
+)
+
+// CanSave returns true if the game is in a state that can be saved right now.
+func (g *Game) CanSave() bool {
+	return g.checkCanSave() == nil
+}
+
+// checkCanSave makes sure every spaceship is either flying or out of the game, with at least one flying,
+// so there are no respawns waiting on timers that aren't saved.
+func (g *Game) checkCanSave() error {
+	if g.Over || !g.LevelUnderway() || g.Overlay != nil {
+		return ErrCannotSaveYet
+	}
+	objects, newObjects := g.World.Objects.Contents()
+	flying := false
+	for _, ship := range g.World.Spaceships {
+		if ship.Out {
+			continue
+		}
+		if !ship.Alive || ship.InHyperspace {
+			return ErrCannotSaveYet
+		}
+		// The spaceship may still be waiting for a clear spot to spawn in
+		if !slices.Contains(objects, gameobjects.GameObject(ship)) && !slices.Contains(newObjects, gameobjects.GameObject(ship)) {
+			return ErrCannotSaveYet
+		}
+		flying = true
+	}
+	if !flying {
+		return ErrCannotSaveYet
+	}
+	return nil
+}
+
+// Save writes the game to w so LoadGame can carry it on exactly. Call it between simulation ticks.
+func (g *Game) Save(w io.Writer) error {
+	if err := g.checkCanSave(); err != nil {
+		return err
+	}
+	random, err := g.Random.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	spawnerDue, _ := g.alienSpawner.Remaining()
+	saved := savedGame{
+		Version:      SaveVersion,
+		Width:        g.World.Width,
+		Height:       g.World.Height,
+		Random:       random,
+		Clock:        g.Scheduler.Now(),
+		Level:        g.Level,
+		Rocks:        g.Rocks,
+		Score:        g.Score,
+		Difficulty:   g.Difficulty,
+		Mode:         g.Mode,
+		FriendlyFire: g.FriendlyFire,
+		AlienSpawner: spawnerDue,
+		Alien:        -1,
+	}
+	for _, ship := range g.World.Spaceships {
+		saved.Spaceships = append(saved.Spaceships, savedSpaceship{savedObject: saveObject(ship), Lives: ship.Lives, Score: ship.Score, Out: ship.Out})
+	}
+
+	objects, newObjects := g.World.Objects.Contents()
+	all := append(objects, newObjects...)
+	for idx, obj := range all {
+		if ship, ok := obj.(*Spaceship); ok {
+			saved.Objects = append(saved.Objects, savedObject{Kind: kindSpaceship, Player: ship.Player})
+		} else {
+			s, err := saveGameObject(obj)
+			if err != nil {
+				return err
+			}
+			saved.Objects = append(saved.Objects, s)
+		}
+		if g.alien != nil && obj == gameobjects.GameObject(g.alien) {
+			saved.Alien = idx
+		}
+	}
+	saved.NewObjects = saved.Objects[len(objects):]
+	saved.Objects = saved.Objects[:len(objects)]
+	saved.AlienGone = g.alien != nil && saved.Alien < 0
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(saved)
+}
+
+// SaveFile saves the game to a file, replacing any previous save only once the new one is written.
+func (g *Game) SaveFile(path string) error {
+	if err := g.checkCanSave(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if err := g.Save(temp); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// LoadGame reads a game written by Save. Saves from older versions of the format are migrated; saves
+// from newer versions are rejected. The game needs its observers registered before it carries on.
+func LoadGame(r io.Reader) (*Game, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("not a saved game: %w", err)
+	}
+	switch {
+	case header.Version < 1:
+		return nil, errors.New("not a saved game: no version")
+	case header.Version > SaveVersion:
+		return nil, fmt.Errorf("saved by a newer version of the game (format %d, this reads up to %d)", header.Version, SaveVersion)
+	case header.Version < SaveVersion:
+		if data, err = migrateSave(data, header.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	var saved savedGame
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("reading saved game: %w", err)
+	}
+	random := &utils.Random{}
+	if err := random.UnmarshalBinary(saved.Random); err != nil {
+		return nil, fmt.Errorf("reading saved game: %w", err)
+	}
+
+	game := newGame(saved.Width, saved.Height, random)
+	game.Scheduler.now = saved.Clock
+	game.Level = saved.Level
+	game.Rocks = saved.Rocks
+	game.Score = saved.Score
+	game.Difficulty = saved.Difficulty
+	game.startingLives = saved.Difficulty.scale().Lives
+	game.Mode = saved.Mode
+	game.FriendlyFire = saved.FriendlyFire
+	game.Ships = len(saved.Spaceships)
+	if game.Ships == 0 {
+		return nil, errors.New("reading saved game: no spaceships")
+	}
+	game.World.clear()
+	game.World.Spaceships = make([]*Spaceship, game.Ships)
+	for i, s := range saved.Spaceships {
+		if s.Body == nil {
+			return nil, fmt.Errorf("reading saved game: spaceship %d has no body", i)
+		}
+		ship := NewSpaceship(game, i)
+		ship.Rigidbody = *s.Body
+		ship.Alive = s.Alive
+		ship.FuelBurning = s.FuelBurning
+		ship.Lives = s.Lives
+		ship.Score = s.Score
+		ship.Out = s.Out
+		game.World.Spaceships[i] = &ship
+	}
+
+	// The spawner was scheduled before any of the aliens' runners, so restore it first
+	game.alienSpawner = AlienSpawner(game)
+	game.alienSpawner.resumeIn(saved.AlienSpawner)
+
+	all := make([]gameobjects.GameObject, 0, len(saved.Objects)+len(saved.NewObjects))
+	for _, s := range append(saved.Objects, saved.NewObjects...) {
+		obj, err := game.loadGameObject(s)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, obj)
+	}
+	game.World.Objects.Restore(all[:len(saved.Objects)], all[len(saved.Objects):])
+
+	if saved.Alien >= 0 && saved.Alien < len(all) {
+		alien, ok := all[saved.Alien].(*Alien)
+		if !ok {
+			return nil, fmt.Errorf("reading saved game: object %d is not an alien", saved.Alien)
+		}
+		game.alien = alien
+	} else if saved.AlienGone {
+		// Stands in for the alien that's gone, so the spawner waits before the next one
+		game.alien = &Alien{}
+	}
+	return game, nil
+}
+
+// LoadGameFile reads a game saved with SaveFile.
+func LoadGameFile(path string) (*Game, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	game, err := LoadGame(file)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+	return game, nil
+}
+
+// migrateSave brings a save from an older version of the format up to SaveVersion.
+func migrateSave(data []byte, version int) ([]byte, error) {
+	var save map[string]any
+	if err := json.Unmarshal(data, &save); err != nil {
+		return nil, err
+	}
+	for ; version < SaveVersion; version++ {
+		migrate, ok := saveMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("saved games from format %d can no longer be loaded", version)
+		}
+		if err := migrate(save); err != nil {
+			return nil, fmt.Errorf("migrating saved game from format %d: %w", version, err)
+		}
+		save["version"] = version + 1
+	}
+	return json.Marshal(save)
+}
+
+func saveObject(s *Spaceship) savedObject {
+	body := s.Rigidbody
+	return savedObject{Kind: kindSpaceship, Body: &body, Alive: s.Alive, FuelBurning: s.FuelBurning}
+}
+
+// saveGameObject records everything about the object that affects how the game plays out.
+func saveGameObject(obj gameobjects.GameObject) (savedObject, error) {
+	switch o := obj.(type) {
+	case *Rock:
+		body := o.Rigidbody
+		return savedObject{
+			Kind:          kindRock,
+			Body:          &body,
+			Alive:         o.isAlive,
+			Size:          int(o.size),
+			RotationSpeed: o.rotationSpeed,
+		}, nil
+	case *Alien:
+		body := o.Rigidbody
+		saved := savedObject{
+			Kind:        kindAlien,
+			Body:        &body,
+			Alive:       o.isAlive,
+			Size:        int(o.size),
+			BulletDrift: o.bulletDrift,
+		}
+		if remaining, ok := o.runner.Remaining(); ok {
+			saved.Runner = &remaining
+		}
+		return saved, nil
+	case *Bullet:
+		body := o.Rigidbody
+		saved := savedObject{
+			Kind:        kindBullet,
+			Body:        &body,
+			Alive:       o.isAlive,
+			PlayerFired: o.owner != nil,
+			AgeMs:       o.ageMs,
+		}
+		if o.owner != nil {
+			saved.Player = o.owner.Player
+		}
+		return saved, nil
+	case *Shrapnel:
+		body := o.Rigidbody
+		file, rows, cols := o.spritesheet.Layout()
+		return savedObject{
+			Kind:          kindShrapnel,
+			Body:          &body,
+			RotationSpeed: o.rotationSpeed,
+			AgeMs:         o.ageMs,
+			LifespanMs:    o.lifespanMs,
+			Frame:         o.frame,
+			Sheet:         &savedSheet{File: file, Rows: rows, Cols: cols},
+		}, nil
+	default:
+		return savedObject{}, fmt.Errorf("can't save object %v of type %T", obj, obj)
+	}
+}
+
+// loadGameObject recreates an object saved by saveGameObject in this game.
+func (g *Game) loadGameObject(s savedObject) (gameobjects.GameObject, error) {
+	if s.Kind == kindSpaceship || (s.Kind == kindBullet && s.PlayerFired) {
+		if s.Player < 0 || s.Player >= len(g.World.Spaceships) {
+			return nil, fmt.Errorf("reading saved game: no player %d", s.Player)
+		}
+	}
+	if s.Kind == kindSpaceship {
+		return g.World.Spaceships[s.Player], nil
+	}
+	if s.Body == nil {
+		return nil, fmt.Errorf("reading saved game: %s has no body", s.Kind)
+	}
+	switch s.Kind {
+	case kindRock:
+		if s.Size < int(RockTiny) || s.Size > int(RockBig) {
+			return nil, fmt.Errorf("reading saved game: no rock of size %d", s.Size)
+		}
+		return &Rock{
+			Rigidbody:     *s.Body,
+			spritesheet:   gameobjects.LoadSpriteSheet(rockSpriteFile[s.Size], 1, 1),
+			rotationSpeed: s.RotationSpeed,
+			isAlive:       s.Alive,
+			size:          RockSize(s.Size),
+			game:          g,
+		}, nil
+	case kindAlien:
+		if s.Size < int(AlienSmall) || s.Size > int(AlienBig) {
+			return nil, fmt.Errorf("reading saved game: no alien of size %d", s.Size)
+		}
+		alien := NewAlien(g, AlienSize(s.Size), s.Body.Position)
+		alien.Rigidbody = *s.Body
+		alien.isAlive = s.Alive
+		alien.bulletDrift = s.BulletDrift
+		if s.Runner != nil {
+			alien.runner = AlienRunner(g, &alien)
+			alien.runner.resumeIn(*s.Runner)
+		}
+		return &alien, nil
+	case kindBullet:
+		var owner *Spaceship
+		if s.PlayerFired {
+			owner = g.World.Spaceships[s.Player]
+		}
+		bullet := NewBullet(g, s.Body.Position, s.Body.Velocity, owner)
+		bullet.Rigidbody = *s.Body
+		bullet.isAlive = s.Alive
+		bullet.ageMs = s.AgeMs
+		return bullet, nil
+	case kindShrapnel:
+		if s.Sheet == nil {
+			return nil, errors.New("reading saved game: shrapnel has no sprite sheet")
+		}
+		return &Shrapnel{
+			Rigidbody:     *s.Body,
+			spritesheet:   gameobjects.LoadSpriteSheet(s.Sheet.File, s.Sheet.Rows, s.Sheet.Cols),
+			rotationSpeed: s.RotationSpeed,
+			lifespanMs:    s.LifespanMs,
+			ageMs:         s.AgeMs,
+			frame:         s.Frame,
+			game:          g,
+		}, nil
+	default:
+		return nil, fmt.Errorf("reading saved game: unknown kind of object %q", s.Kind)
+	}
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"strings"
+	"testing"
+)
+
+const saveTestDelta = 1.0 / 120
+
+// playUntilSaveable plays a level with the spaceship firing while it turns until an alien is on the
+// playfield, then blows up one of the rocks so there's shrapnel about too.
+func playUntilSaveable(t *testing.T, game *Game) {
+	game.World.Initialize(game)
+	game.StartLevel()
+	for tick := 0; game.alien == nil || !game.alien.IsAlive(); tick++ {
+		if tick > 60*120 {
+			t.Fatalf("Expected an alien within a minute of play")
+		}
+		game.World.Spaceships[0].RotateLeft(saveTestDelta)
+		if tick%60 == 0 {
+			game.World.Spaceships[0].Fire()
+		}
+		game.Update(saveTestDelta)
+	}
+	destroyed := false
+	game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
+		if rock, ok := obj.(*Rock); ok && rock.IsAlive() && !destroyed {
+			_ = rock.OnDestruction(nil, rl.NewVector2(1, 0))
+			destroyed = true
+		}
+	})
+	game.Update(saveTestDelta)
+	if !game.CanSave() {
+		t.Fatalf("Expected to be able to save once the level is under way")
+	}
+}
+
+// snapshot describes where everything in the game is, for comparing two games.
+func snapshot(game *Game) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v lives %d level %d rocks %d score %d\n", game.Scheduler.Now(), game.Lives(), game.Level, game.Rocks, game.Score)
+	fmt.Fprintf(&b, "spaceship %v %v\n", game.World.Spaceships[0].Position, game.World.Spaceships[0].Velocity)
+	game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
+		if obj != gameobjects.GameObject(game.World.Spaceships[0]) {
+			saved, _ := saveGameObject(obj)
+			fmt.Fprintf(&b, "%+v %+v\n", saved, *saved.Body)
+		}
+	})
+	return b.String()
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 3)
+	game.SetDifficulty(DifficultyInsane)
+	game.Mode = ModeTimeAttack
+	playUntilSaveable(t, game)
+
+	var saved bytes.Buffer
+	if err := game.Save(&saved); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+	loaded, err := LoadGame(bytes.NewReader(saved.Bytes()))
+	if err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+
+	var resaved bytes.Buffer
+	if err := loaded.Save(&resaved); err != nil {
+		t.Fatalf("Unexpected error saving the loaded game: %v", err)
+	}
+	if saved.String() != resaved.String() {
+		t.Errorf("Expected the loaded game to save identically\nsaved:\n%s\nresaved:\n%s", saved.String(), resaved.String())
+	}
+
+	// Both games should carry on exactly the same, aliens and all
+	for range 20 * 120 {
+		for _, g := range []*Game{game, loaded} {
+			g.World.Spaceships[0].RotateRight(saveTestDelta)
+			g.Update(saveTestDelta)
+		}
+	}
+	if want, got := snapshot(game), snapshot(loaded); want != got {
+		t.Errorf("Expected the loaded game to play out the same\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestSave_CannotSaveYet(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 3)
+	game.World.Initialize(game)
+	game.StartLevel()
+	if game.CanSave() {
+		t.Errorf("Expected not to be able to save while the level is starting")
+	}
+	if err := game.Save(&bytes.Buffer{}); err != ErrCannotSaveYet {
+		t.Errorf("Expected ErrCannotSaveYet, got %v", err)
+	}
+
+	for range 3 * 120 {
+		game.Update(saveTestDelta)
+	}
+	if !game.CanSave() {
+		t.Fatalf("Expected to be able to save once the level is under way")
+	}
+	game.World.Spaceships[0].InHyperspace = true
+	if game.CanSave() {
+		t.Errorf("Expected not to be able to save while the spaceship is in hyperspace")
+	}
+}
+
+func TestLoadGame_Versions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		save string
+		want string
+	}{
+		{"not json", "ASRR", "not a saved game"},
+		{"no version", `{"level": 3}`, "no version"},
+		{"newer", fmt.Sprintf(`{"version": %d}`, SaveVersion+1), "newer version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadGame(strings.NewReader(tt.save))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadGame_Version1(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 3)
+	game.SetDifficulty(DifficultyHard)
+	playUntilSaveable(t, game)
+	var saved bytes.Buffer
+	if err := game.Save(&saved); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+
+	// Saves from before difficulty presets and modes have neither, and are all Normal and Classic, with the
+	// one spaceship
+	var old map[string]any
+	if err := json.Unmarshal(saved.Bytes(), &old); err != nil {
+		t.Fatal(err)
+	}
+	old["version"] = 1
+	delete(old, "difficulty")
+	delete(old, "mode")
+	ship := old["spaceships"].([]any)[0].(map[string]any)
+	old["lives"] = ship["lives"]
+	delete(ship, "lives")
+	delete(ship, "score")
+	old["spaceship"] = ship
+	delete(old, "spaceships")
+	data, _ := json.Marshal(old)
+
+	loaded, err := LoadGame(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error loading a version 1 save: %v", err)
+	}
+	if loaded.Difficulty != DifficultyNormal || loaded.Mode != ModeClassic || loaded.Score != game.Score {
+		t.Errorf("Expected a Normal Classic game with score %d, got %v %v with %d", game.Score, loaded.Difficulty, loaded.Mode, loaded.Score)
+	}
+	ships := loaded.World.Spaceships
+	if len(ships) != 1 || ships[0].Lives != game.Lives() || ships[0].Score != game.Score {
+		t.Errorf("Expected one spaceship with %d lives and score %d, got %d spaceships", game.Lives(), game.Score, len(ships))
+	}
+}
+
+func TestMigrateSave(t *testing.T) {
+	saveMigrations[0] = func(save map[string]any) error {
+		save["lives"] = 2
+		return nil
+	}
+	defer delete(saveMigrations, 0)
+
+	data, err := migrateSave([]byte(`{"version": 0, "lives": 5, "score": 10, "spaceship": {"kind": "spaceship"}}`), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error migrating: %v", err)
+	}
+	want := fmt.Sprintf(`{"difficulty":"normal","mode":"classic","score":10,"spaceships":[{"kind":"spaceship","lives":2,"score":10}],"version":%d}`, SaveVersion)
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	if _, err := migrateSave([]byte(`{"version": -1}`), -1); err == nil {
+		t.Errorf("Expected an error migrating a save with no migration")
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler runs actions on the game clock rather than the wall clock. The clock only moves when
+// the simulation calls Advance, so everything scheduled freezes while the game is paused and
+// always runs on the simulation thread.
+type Scheduler struct {
+	now    time.Duration
+	timers []*Timer
+	nextID uint64
+	lock   sync.Mutex
+}
+
+// Timer is a scheduled action. One-shot timers have a zero interval.
+type Timer struct {
+	scheduler *Scheduler
+	id        uint64
+	due       time.Duration
+	interval  time.Duration
+	action    func()
+	cancelled bool
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		timers: make([]*Timer, 0, 10),
+	}
+}
+
+// Now returns how much game time has passed since the scheduler was created.
+func (s *Scheduler) Now() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.now
+}
+
+// After runs the action once, after the given amount of game time.
+func (s *Scheduler) After(delay time.Duration, action func()) *Timer {
+	return s.schedule(delay, 0, action)
+}
+
+// Every runs the action repeatedly, each time the given amount of game time passes.
+func (s *Scheduler) Every(interval time.Duration, action func()) *Timer {
+	if interval <= 0 {
+		panic("interval must be greater than 0")
+	}
+	return s.schedule(interval, interval, action)
+}
+
+// Advance moves the game clock forward by delta seconds and runs every action that comes due, in the
+// order they come due. Actions scheduled by other actions run in the same call if they're due.
+func (s *Scheduler) Advance(delta float32) {
+	s.lock.Lock()
+	target := s.now + time.Duration(float64(delta)*float64(time.Second))
+	for {
+		timer := s.nextDue(target)
+		if timer == nil {
+			break
+		}
+		s.now = timer.due
+		if timer.interval > 0 {
+			timer.due += timer.interval
+		} else {
+			timer.cancelled = true
+		}
+		// Run the action unlocked so it can schedule or cancel timers itself
+		s.lock.Unlock()
+		timer.action()
+		s.lock.Lock()
+	}
+	s.now = target
+	s.removeCancelled()
+	s.lock.Unlock()
+}
+
+func (s *Scheduler) schedule(delay, interval time.Duration, action func()) *Timer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nextID++
+	t := &Timer{
+		scheduler: s,
+		id:        s.nextID,
+		due:       s.now + delay,
+		interval:  interval,
+		action:    action,
+	}
+	s.timers = append(s.timers, t)
+	return t
+}
+
+// nextDue returns the live timer that is due soonest at or before target, or nil if there isn't one.
+// Timers due at the same moment run in the order they were scheduled. Must hold the lock.
+func (s *Scheduler) nextDue(target time.Duration) *Timer {
+	var next *Timer
+	for _, t := range s.timers {
+		if t.cancelled || t.due > target {
+			continue
+		}
+		if next == nil || t.due < next.due || (t.due == next.due && t.id < next.id) {
+			next = t
+		}
+	}
+	return next
+}
+
+// removeCancelled drops all the cancelled and finished timers. Must hold the lock.
+func (s *Scheduler) removeCancelled() {
+	live := s.timers[:0]
+	for _, t := range s.timers {
+		if !t.cancelled {
+			live = append(live, t)
+		}
+	}
+	clear(s.timers[len(live):])
+	s.timers = live
+}
+
+// Cancel stops the timer from running again. Safe to call on a nil timer, more than once, and from
+// within the timer's own action.
+func (t *Timer) Cancel() {
+	if t == nil {
+		return
+	}
+	t.scheduler.lock.Lock()
+	defer t.scheduler.lock.Unlock()
+	t.cancelled = true
+}
+
+// Remaining returns how much game time is left until the timer next runs, or false if it never will
+// because it has been cancelled or has finished. Safe to call on a nil timer.
+func (t *Timer) Remaining() (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.scheduler.lock.Lock()
+	defer t.scheduler.lock.Unlock()
+	if t.cancelled {
+		return 0, false
+	}
+	return t.due - t.scheduler.now, true
+}
+
+// resumeIn sets the timer to next run after the given amount of game time, keeping its interval.
+func (t *Timer) resumeIn(remaining time.Duration) {
+	t.scheduler.lock.Lock()
+	defer t.scheduler.lock.Unlock()
+	t.due = t.scheduler.now + remaining
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduler_After(t *testing.T) {
+	t.Parallel()
+	scheduler := NewScheduler()
+	ran := 0
+	scheduler.After(time.Second, func() {
+		ran++
+	})
+
+	scheduler.Advance(0.5)
+	if ran != 0 {
+		t.Errorf("Expected action not to run before it's due, ran %d times", ran)
+	}
+	scheduler.Advance(0.5)
+	if ran != 1 {
+		t.Errorf("Expected action to run once when due, ran %d times", ran)
+	}
+	scheduler.Advance(5)
+	if ran != 1 {
+		t.Errorf("Expected one-shot action to run only once, ran %d times", ran)
+	}
+	if len(scheduler.timers) != 0 {
+		t.Errorf("Expected finished timer to be removed, have %d", len(scheduler.timers))
+	}
+}
+
+func TestScheduler_Every(t *testing.T) {
+	t.Parallel()
+	scheduler := NewScheduler()
+	ran := 0
+	scheduler.Every(100*time.Millisecond, func() {
+		ran++
+	})
+
+	for range 10 {
+		scheduler.Advance(0.05)
+	}
+	if ran != 5 {
+		t.Errorf("Expected action to run 5 times in half a second, ran %d times", ran)
+	}
+
+	// A long step catches up on every interval it missed
+	scheduler.Advance(0.5)
+	if ran != 10 {
+		t.Errorf("Expected action to run 10 times in a second, ran %d times", ran)
+	}
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	t.Parallel()
+	scheduler := NewScheduler()
+	ran := 0
+	timer := scheduler.Every(100*time.Millisecond, func() {
+		ran++
+	})
+
+	scheduler.Advance(0.25)
+	timer.Cancel()
+	timer.Cancel()
+	scheduler.Advance(1)
+	if ran != 2 {
+		t.Errorf("Expected action to stop running after cancel, ran %d times", ran)
+	}
+
+	// Cancelling a nil timer is harmless
+	var none *Timer
+	none.Cancel()
+}
+
+func TestScheduler_CancelFromAction(t *testing.T) {
+	t.Parallel()
+	scheduler := NewScheduler()
+	ran := 0
+	var timer *Timer
+	timer = scheduler.Every(100*time.Millisecond, func() {
+		ran++
+		if ran == 3 {
+			timer.Cancel()
+		}
+	})
+
+	scheduler.Advance(1)
+	if ran != 3 {
+		t.Errorf("Expected action to cancel itself after 3 runs, ran %d times", ran)
+	}
+}
+
+func TestScheduler_Order(t *testing.T) {
+	t.Parallel()
+	scheduler := NewScheduler()
+	order := make([]string, 0, 4)
+	scheduler.After(300*time.Millisecond, func() { order = append(order, "third") })
+	scheduler.After(100*time.Millisecond, func() {
+		order = append(order, "first")
+		// Scheduled from an action and due within the same advance
+		scheduler.After(100*time.Millisecond, func() { order = append(order, "second") })
+	})
+	scheduler.After(300*time.Millisecond, func() { order = append(order, "fourth") })
+
+	scheduler.Advance(1)
+	want := []string{"first", "second", "third", "fourth"}
+	if len(order) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestScheduler_FrozenWithoutAdvance(t *testing.T) {
+	t.Parallel()
+	scheduler := NewScheduler()
+	ran := false
+	scheduler.After(time.Millisecond, func() {
+		ran = true
+	})
+
+	// Wall clock time passing doesn't matter; only the game clock does
+	time.Sleep(5 * time.Millisecond)
+	if ran {
+		t.Errorf("Expected action not to run without the game clock advancing")
+	}
+	if scheduler.Now() != 0 {
+		t.Errorf("Expected game clock to be at 0, got %v", scheduler.Now())
+	}
+}
@@ -2,7 +2,7 @@ package core
 
 import (
 	"avoid_the_space_rocks/internal/gameobjects"
-	"avoid_the_space_rocks/internal/utils"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -13,34 +13,40 @@ type Shrapnel struct {
 	lifespanMs    uint    // How long the shrapnel lives in ms
 	ageMs         uint    // How long the shrapnel has been alive
 	frame         int
+	game          *Game
 }
 
 var _ gameobjects.GameObject = (*Shrapnel)(nil)
+var _ gameobjects.Recyclable = (*Shrapnel)(nil)
 
-// NewShrapnel creates a new piece of shrapnel with random direction and lifetime
-func NewShrapnel(position rl.Vector2, sheet *gameobjects.SpriteSheet, lifespan uint, frame int) Shrapnel {
-	shrapnel := Shrapnel{
+// NewShrapnel creates a new piece of shrapnel with random direction and lifetime, from the game's pool
+func NewShrapnel(game *Game, position rl.Vector2, sheet *gameobjects.SpriteSheet, lifespan uint, frame int) *Shrapnel {
+	random := game.Random
+	maxSpeed := game.Tuning.Shrapnel.MaxSpeed
+	shrapnel := game.shrapnel.Get()
+	*shrapnel = Shrapnel{
 		spritesheet: sheet,
 		Rigidbody: gameobjects.Rigidbody{
 			Velocity: rl.Vector2{
-				X: utils.RndFloat32InRange(-shrapnelMaxSpeed, shrapnelMaxSpeed),
-				Y: utils.RndFloat32InRange(-shrapnelMaxSpeed, shrapnelMaxSpeed),
+				X: random.RndFloat32InRange(-maxSpeed, maxSpeed),
+				Y: random.RndFloat32InRange(-maxSpeed, maxSpeed),
 			},
 			Transform: gameobjects.Transform{
 				Position: position,
 				Rotation: rl.Vector2{
-					X: utils.RndFloat32InRange(-1.0, 1.0),
-					Y: utils.RndFloat32InRange(-1.0, 1.0),
+					X: random.RndFloat32InRange(-1.0, 1.0),
+					Y: random.RndFloat32InRange(-1.0, 1.0),
 				},
 			},
 		},
-		rotationSpeed: utils.RndFloat32(shrapnelMaxRotate),
+		rotationSpeed: random.RndFloat32(turnsToRadians(game.Tuning.Shrapnel.MaxRotate)),
 		lifespanMs:    lifespan,
 		ageMs:         0,
 		frame:         frame,
+		game:          game,
 	}
 	// Half of 'em rotate counterclockwise
-	if utils.Chance(0.5) {
+	if random.Chance(0.5) {
 		shrapnel.rotationSpeed = -shrapnel.rotationSpeed
 	}
 	return shrapnel
@@ -48,17 +54,16 @@ func NewShrapnel(position rl.Vector2, sheet *gameobjects.SpriteSheet, lifespan u
 
 // Update applies physics to the bullet so it moves per its velocity.
 func (s *Shrapnel) Update(delta float32) error {
-	game := GetGame()
 	s.Rotation = rl.Vector2Rotate(s.Rotation, s.rotationSpeed*delta)
 	s.Rigidbody.ApplyPhysics(delta)
-	s.Position = game.World.Wraparound(s.Position)
+	s.Position = s.game.World.Wraparound(s.Position)
 	s.ageMs += uint(delta * 1000)
 	return nil
 }
 
 // Draw renders the bullet to the screen.
-func (s *Shrapnel) Draw() error {
-	return s.spritesheet.Draw(s.frame, 0, s.Position, s.Rotation)
+func (s *Shrapnel) Draw(p platform.Platform) error {
+	return s.spritesheet.DrawWrapped(p, s.frame, 0, s.Position, s.Rotation, s.game.World.Width, s.game.World.Height)
 }
 
 // IsAlive returns true if the bullet is still alive. Always dead after its lifetime.
@@ -69,3 +74,8 @@ func (s *Shrapnel) IsAlive() bool {
 func (b *Shrapnel) IsEnemy() bool {
 	return false
 }
+
+// Recycle returns the piece to the game's pool for the next explosion.
+func (s *Shrapnel) Recycle() {
+	s.game.shrapnel.Put(s)
+}
@@ -1,8 +1,9 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/core/events"
 	"avoid_the_space_rocks/internal/gameobjects"
-	"avoid_the_space_rocks/internal/utils"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"math"
 	"time"
@@ -11,52 +12,76 @@ import (
 type Spaceship struct {
 	gameobjects.Rigidbody
 	Spritesheet  *gameobjects.SpriteSheet
+	Player       int  // Index of the player flying it in World.Spaceships
+	Lives        int  // Lives the player has left, counting this one while it flies
+	Score        uint // Points the player has scored themselves
+	Out          bool // Out of lives while the other players play on, so it won't be back
 	FuelBurning  bool // Is the user burning fuel to accelerate?
 	Alive        bool
 	InHyperspace bool
+	game         *Game
+	exhaust      gameobjects.Emitter
+
+	// The triangle GetShape hands out, kept here along with its corners so it isn't allocated every tick
+	shape   gameobjects.Polygon
+	corners [3]rl.Vector2
 }
 
 var _ gameobjects.Collidable = (*Spaceship)(nil)
 var _ gameobjects.Destructible = (*Spaceship)(nil)
 var _ gameobjects.GameObject = (*Spaceship)(nil)
+var _ gameobjects.Pushable = (*Spaceship)(nil)
+var _ gameobjects.Exploder = (*Spaceship)(nil)
 
-// NewSpaceship creates a new spaceship with the default sprite sheet and initial values.
-func NewSpaceship() Spaceship {
+// NewSpaceship creates a new spaceship for the given player with the default sprite sheet and initial values.
+func NewSpaceship(game *Game, player int) Spaceship {
 	sheet := gameobjects.LoadSpriteSheet("spaceship.png", 7, 1)
 	ship := Spaceship{
 		Spritesheet: sheet,
+		Player:      player,
 		Rigidbody: gameobjects.Rigidbody{
-			MaxVelocity: shipMaxSpeed,
+			MaxVelocity: game.Tuning.Ship.MaxSpeed,
 		},
 		FuelBurning:  false,
 		InHyperspace: false,
+		game:         game,
+		exhaust:      gameobjects.Emitter{Style: exhaust, Rate: 90},
 	}
 	return ship
 }
 
 // Update applies physics to the spaceship, updating its position and velocity.
 func (s *Spaceship) Update(delta float32) error {
-	game := GetGame()
+	tuning := &s.game.Tuning.Ship
+	s.MaxVelocity = tuning.MaxSpeed
 	if s.FuelBurning {
-		s.Acceleration = rl.Vector2Scale(s.Rotation, shipFuelBoost)
+		s.Acceleration = rl.Vector2Scale(s.Rotation, tuning.FuelBoost)
+		s.Drag = 0
 	} else {
-		// Decrease the magnitude of the velocity vector by shipDecaySpeed per second
+		// Coast to a stop, losing some of the velocity every second
 		s.Acceleration = rl.Vector2{}
-		s.Velocity = rl.Vector2Scale(s.Velocity, 1-shipDecaySpeed*delta)
+		s.Drag = tuning.Drag
 	}
 	s.Rigidbody.ApplyPhysics(delta)
-	s.Position = game.World.Wraparound(s.Position)
+	s.Position = s.game.World.Wraparound(s.Position)
+	if s.FuelBurning && !s.InHyperspace {
+		tail := s.ToWorld(rl.Vector2{X: -s.Spritesheet.GetSize().X / 2})
+		s.exhaust.Emit(&s.game.World.Particles, delta, tail, rl.Vector2Negate(s.Rotation), s.Velocity)
+	} else {
+		s.exhaust.Stop()
+	}
 	return nil
 }
 
-// Spawn places the spaceship at the center of the playfield at the start of level. This function may take time
-// to return because it waits until the spaceship can spawn safely (i.e., not in the middle of a rock).
-// After ten seconds it will spawn anyway, but this is a last resort.
+// Spawn places the spaceship pointing up across the middle of the playfield, the ships spread evenly
+// from left to right, so a lone ship spawns in the center. The spaceship may take time to appear because
+// it waits until it can spawn safely (i.e., not in the middle of a rock). After ten seconds of game time
+// it will spawn anyway, but this is a last resort.
 func (s *Spaceship) Spawn() {
-	game := GetGame()
+	game := s.game
 	s.Alive = true
 	s.Position = rl.Vector2{
-		X: game.World.Width / 2,
+		X: game.World.Width * float32(s.Player+1) / float32(len(game.World.Spaceships)+1),
 		Y: game.World.Height / 2,
 	}
 	s.Velocity = rl.Vector2{}
@@ -65,36 +90,40 @@ func (s *Spaceship) Spawn() {
 
 	// Wait up to ten seconds until spawning won't make the ship explode immediately
 	extendedLocation := gameobjects.ExtendRectangle(s.GetHitbox(), 0.5)
-	dangerous := game.World.Objects.IsRectangleOccupied(extendedLocation)
+	if !game.World.Objects.IsRectangleOccupied(extendedLocation) {
+		game.World.Objects.Add(s)
+		return
+	}
 	elapsed := time.Duration(0)
 	tick := 100 * time.Millisecond
-	for dangerous && elapsed < 10*time.Second {
-		time.Sleep(tick)
+	var waiting *Timer
+	waiting = game.Scheduler.Every(tick, func() {
 		elapsed += tick
-		dangerous = game.World.Objects.IsRectangleOccupied(extendedLocation)
-	}
-	game.World.Objects.Add(s)
+		if game.World.Objects.IsRectangleOccupied(extendedLocation) && elapsed < 10*time.Second {
+			return
+		}
+		waiting.Cancel()
+		game.World.Objects.Add(s)
+	})
 }
 
 // Draw draws the spaceship at its current position and rotation.
-func (s *Spaceship) Draw() error {
+func (s *Spaceship) Draw(p platform.Platform) error {
 	if !s.InHyperspace {
 		frame := s.frameIndex()
-		return s.Spritesheet.Draw(frame, 0, s.Position, s.Rotation)
+		return s.Spritesheet.DrawWrapped(p, frame, 0, s.Position, s.Rotation, s.game.World.Width, s.game.World.Height)
 	}
 	return nil
 }
 
-// RotateLeft rotates the spaceship to the left the standard amount.
-func (s *Spaceship) RotateLeft() {
-	delta := rl.GetFrameTime()
-	s.Rotation = rl.Vector2Rotate(s.Rotation, -shipRotateSpeed*delta)
+// RotateLeft rotates the spaceship to the left the standard amount over delta seconds.
+func (s *Spaceship) RotateLeft(delta float32) {
+	s.Rotation = rl.Vector2Rotate(s.Rotation, -turnsToRadians(s.game.Tuning.Ship.RotateSpeed)*delta)
 }
 
-// RotateRight rotates the spaceship to the right the standard amount.
-func (s *Spaceship) RotateRight() {
-	delta := rl.GetFrameTime()
-	s.Rotation = rl.Vector2Rotate(s.Rotation, shipRotateSpeed*delta)
+// RotateRight rotates the spaceship to the right the standard amount over delta seconds.
+func (s *Spaceship) RotateRight(delta float32) {
+	s.Rotation = rl.Vector2Rotate(s.Rotation, turnsToRadians(s.game.Tuning.Ship.RotateSpeed)*delta)
 }
 
 // Fire creates a new bullet with the spaceship's current position and rotation.
@@ -104,18 +133,21 @@ func (s *Spaceship) Fire() {
 	bulletOffset := float32(math.Max(float64(hitbox.Width), float64(hitbox.Height))) / 2
 	startPos := rl.Vector2Add(s.Position, rl.Vector2Scale(s.Rotation, bulletOffset))
 
-	b := NewBullet(startPos, s.Rotation, true)
-	b.Velocity = rl.Vector2Add(rl.Vector2Scale(s.Rotation, bulletSpeed), s.Velocity)
+	b := NewBullet(s.game, startPos, s.Rotation, s)
+	muzzleVelocity := rl.Vector2Scale(s.Rotation, s.game.Tuning.Bullet.Speed)
+	b.Velocity = rl.Vector2Add(muzzleVelocity, s.Velocity)
+	if s.game.Tuning.Ship.Recoil {
+		// Whatever pushes the bullet forward pushes the spaceship back just as hard
+		s.ApplyImpulse(rl.Vector2Scale(muzzleVelocity, -s.game.Tuning.Bullet.Mass), s.Mass())
+	}
 
-	game := GetGame()
-	game.World.Objects.Add(&b)
-	game.EventBus.Publish("spaceship:fire")
+	s.game.World.Objects.Add(b)
+	events.Publish(s.game.EventBus, events.SpaceshipFired{Player: s.Player, Position: b.Position, Velocity: b.Velocity})
 }
 
 // EnterHyperspace causes the spaceship to jump to a random location on the playfield.
 func (s *Spaceship) EnterHyperspace() {
-	game := GetGame()
-	game.EventBus.Publish("spaceship:enter_hyperspace")
+	events.Publish(s.game.EventBus, events.SpaceshipEnteredHyperspace{Player: s.Player, Position: s.Position})
 }
 
 // IsAlive returns whether the spaceship is currently alive.
@@ -128,6 +160,16 @@ func (s *Spaceship) IsEnemy() bool {
 	return false
 }
 
+// Mass returns how hard the spaceship is to push around.
+func (s *Spaceship) Mass() float32 {
+	return s.game.Tuning.Ship.Mass
+}
+
+// Blast returns the explosion the spaceship goes off with when it's destroyed.
+func (s *Spaceship) Blast() (rl.Vector2, float32, float32) {
+	return s.game.blast(s.Position)
+}
+
 // OnCollision is called when the spaceship is hitting something else.
 func (s *Spaceship) OnCollision(_ gameobjects.Collidable) error {
 	// Spaceship is always considered the anvil so we always do nothing here
@@ -139,11 +181,24 @@ func (s *Spaceship) GetHitbox() rl.Rectangle {
 	return s.Spritesheet.GetRectangle(s.Position)
 }
 
+// GetShape returns the spaceship's outline for collision detection: a triangle from its nose, which
+// points along its rotation, back to the corners of its tail.
+func (s *Spaceship) GetShape() gameobjects.Shape {
+	half := rl.Vector2Scale(s.Spritesheet.GetSize(), 0.5)
+	s.corners = [3]rl.Vector2{
+		s.ToWorld(rl.Vector2{X: half.X}),
+		s.ToWorld(rl.Vector2{X: -half.X, Y: half.Y}),
+		s.ToWorld(rl.Vector2{X: -half.X, Y: -half.Y}),
+	}
+	s.shape.Points = s.corners[:]
+	return &s.shape
+}
+
 // frameIndex returns the index of the correct frame to use in the sprite sheet. There are two
-// fuel burning frames, so the index is either 0, 1, or 2.
+// fuel burning frames, which alternate every half second of game time, so the index is either 0, 1, or 2.
 func (s *Spaceship) frameIndex() int {
 	if s.FuelBurning {
-		t := rl.GetTime()
+		t := s.game.Scheduler.Now().Seconds()
 		if t-math.Floor(t) < 0.5 {
 			return 1
 		} else {
@@ -155,18 +210,19 @@ func (s *Spaceship) frameIndex() int {
 
 // OnDestruction handles the destruction of the spaceship, causing pieces to fly around.
 // This is called by the rock's OnCollision method when it hits this spaceship.
-func (s *Spaceship) OnDestruction(_ rl.Vector2) error {
-	if s.InHyperspace {
+func (s *Spaceship) OnDestruction(by gameobjects.Collidable, _ rl.Vector2) error {
+	game := s.game
+	if !s.Alive || s.InHyperspace || !game.Mode.rules().ShipCanBreak {
 		return nil
 	}
-	game := GetGame()
 	s.Alive = false
 	// Spawn the pieces flying away
 	for i := range 4 {
-		piece := NewShrapnel(s.Position, s.Spritesheet, uint(utils.RndIntInRange(1000, 2000)), i+3)
-		game.World.Objects.Add(&piece)
+		piece := NewShrapnel(game, s.Position, s.Spritesheet, uint(game.Random.RndIntInRange(1000, 2000)), i+3)
+		game.World.Objects.Add(piece)
 	}
+	game.explode(s, by)
 	// Notify other services
-	game.EventBus.Publish("spaceship:destroyed")
+	events.Publish(game.EventBus, events.SpaceshipDestroyed{Player: s.Player, Position: s.Position, By: causeOf(by)})
 	return nil
 }
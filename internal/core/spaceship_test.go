@@ -0,0 +1,94 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	"os"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestMain(m *testing.M) {
+	// Change to the project root directory so the sprites load and objects have their real sizes
+	if err := os.Chdir("../.."); err != nil {
+		panic("failed to change to project root directory: " + err.Error())
+	}
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestSpaceship_GetShape(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	ship := NewSpaceship(game, 0)
+	ship.Position, ship.Rotation = rl.NewVector2(100, 100), rl.Vector2{X: 0, Y: -1}
+
+	// Pointing up the screen, the nose is above the middle and the tail corners below either side
+	shape, ok := ship.GetShape().(*gameobjects.Polygon)
+	if !ok || len(shape.Points) != 3 {
+		t.Fatalf("Expected the spaceship to be a triangle, got %v", ship.GetShape())
+	}
+	nose, left, right := shape.Points[0], shape.Points[1], shape.Points[2]
+	if nose.X != 100 || nose.Y >= 100 || left.Y <= 100 || right.Y <= 100 || left.X == right.X {
+		t.Errorf("Expected the nose up and the tail down, got %v", shape.Points)
+	}
+
+	// A bullet beside the nose, inside the sprite but clear of the triangle, misses
+	hitbox := ship.GetHitbox()
+	corner := gameobjects.Circle{Center: rl.NewVector2(hitbox.X+2, hitbox.Y+2), Radius: 1}
+	if gameobjects.Overlaps(shape, corner) {
+		t.Errorf("Expected the corner of the sprite beside the nose to be clear of the spaceship")
+	}
+	if !gameobjects.Overlaps(shape, gameobjects.Circle{Center: ship.Position, Radius: 1}) {
+		t.Errorf("Expected the middle of the spaceship to be inside it")
+	}
+
+	// Turned to the right, the nose follows
+	ship.Rotation = rl.Vector2{X: 1, Y: 0}
+	if nose := ship.GetShape().(*gameobjects.Polygon).Points[0]; nose.X <= 100 || nose.Y != 100 {
+		t.Errorf("Expected the nose to point right, got %v", nose)
+	}
+}
+
+func TestSpaceship_Recoil(t *testing.T) {
+	t.Parallel()
+	for _, recoil := range []bool{false, true} {
+		game := NewGame(800, 600, 1)
+		game.Tuning.Ship.Recoil = recoil
+		game.World.Initialize(game)
+		ship := game.World.Spaceships[0]
+		ship.Rotation, ship.Velocity = rl.Vector2{X: 1, Y: 0}, rl.Vector2{}
+
+		ship.Fire()
+
+		want := rl.Vector2{}
+		if recoil {
+			want.X = -game.Tuning.Bullet.Speed * game.Tuning.Bullet.Mass / game.Tuning.Ship.Mass
+		}
+		if rl.Vector2Distance(ship.Velocity, want) > 0.001 {
+			t.Errorf("With recoil %v, expected firing to leave the spaceship moving at %v, got %v", recoil, want, ship.Velocity)
+		}
+	}
+}
+
+func TestSpaceship_Exhaust(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.World.Initialize(game)
+	ship := game.World.Spaceships[0]
+
+	for range 30 {
+		_ = ship.Update(1.0 / 60)
+	}
+	if n := game.World.Particles.Len(); n != 0 {
+		t.Errorf("Expected no exhaust while coasting, got %d particles", n)
+	}
+
+	ship.FuelBurning = true
+	for range 30 {
+		_ = ship.Update(1.0 / 60)
+	}
+	if n := game.World.Particles.Len(); n < 40 || n > 50 {
+		t.Errorf("Expected half a second of exhaust while burning fuel, got %d particles", n)
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Sprite is how an object looks at the moment: the frame of its sprite sheet, where it is, and which way
+// it faces. Drawing the frame there draws the object, which is all something showing the game from afar
+// needs to know about it.
+type Sprite struct {
+	Sheet    *gameobjects.SpriteSheet
+	Row      int
+	Col      int
+	Position rl.Vector2
+	Rotation rl.Vector2
+}
+
+// SpriteOf returns how the object looks right now, or false if it isn't drawn, such as a spaceship in
+// hyperspace or something this doesn't know how to show.
+func SpriteOf(obj gameobjects.GameObject) (Sprite, bool) {
+	switch o := obj.(type) {
+	case *Spaceship:
+		if o.InHyperspace {
+			return Sprite{}, false
+		}
+		return Sprite{Sheet: o.Spritesheet, Row: o.frameIndex(), Position: o.Position, Rotation: o.Rotation}, true
+	case *Rock:
+		return Sprite{Sheet: o.spritesheet, Position: o.Position, Rotation: o.Rotation}, true
+	case *Alien:
+		row, col, err := o.spritesheet.FrameLocation(o.frameIndex())
+		if err != nil {
+			row, col = 0, 0
+		}
+		return Sprite{Sheet: o.spritesheet, Row: row, Col: col, Position: o.Position, Rotation: o.Rotation}, true
+	case *Bullet:
+		return Sprite{Sheet: o.spritesheet, Position: o.Position, Rotation: o.Rotation}, true
+	case *Shrapnel:
+		return Sprite{Sheet: o.spritesheet, Row: o.frame, Position: o.Position, Rotation: o.Rotation}, true
+	default:
+		return Sprite{}, false
+	}
+}
+
+// SpriteSheets returns every sprite sheet the game's objects are drawn from, always in the same order,
+// so something showing the game from afar can load them all up front and refer to them by index.
+func SpriteSheets() []*gameobjects.SpriteSheet {
+	sheets := []*gameobjects.SpriteSheet{gameobjects.LoadSpriteSheet("spaceship.png", 7, 1)}
+	for _, file := range rockSpriteFile {
+		sheets = append(sheets, gameobjects.LoadSpriteSheet(file, 1, 1))
+	}
+	for _, file := range alienSpriteFile {
+		sheets = append(sheets, gameobjects.LoadSpriteSheet(file.filename, file.row, file.col))
+	}
+	return append(sheets,
+		gameobjects.LoadSpriteSheet("bullet.png", 1, 1),
+		gameobjects.LoadSpriteSheet("shrapnel.png", 5, 1))
+}
+
+// Draw draws the sprite's frame on p at its position and rotation, and across any edge of a playfield of
+// the given size it hangs over.
+func (s Sprite) Draw(p platform.Platform, width, height float32) error {
+	return s.Sheet.DrawWrapped(p, s.Row, s.Col, s.Position, s.Rotation, width, height)
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"avoid_the_space_rocks/internal/gameobjects"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"slices"
+	"testing"
+)
+
+func TestSpriteOf(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	position := rl.NewVector2(100, 100)
+
+	ship := NewSpaceship(game, 0)
+	ship.Position, ship.Rotation = position, rl.Vector2{X: 0, Y: -1}
+	sprite, ok := SpriteOf(&ship)
+	if !ok || sprite.Sheet != ship.Spritesheet || sprite.Position != position || sprite.Rotation != ship.Rotation {
+		t.Errorf("Expected the spaceship's sprite at %v, got %+v", position, sprite)
+	}
+	ship.InHyperspace = true
+	if _, ok := SpriteOf(&ship); ok {
+		t.Errorf("Expected no sprite for a spaceship in hyperspace")
+	}
+
+	rock := NewRock(game, RockBig, position)
+	shrapnel := NewShrapnel(game, position, rock.spritesheet, 100, 3)
+	if sprite, ok := SpriteOf(shrapnel); !ok || sprite.Row != 3 {
+		t.Errorf("Expected the shrapnel's frame to be row 3, got %+v", sprite)
+	}
+
+	// Everything is drawn from a sheet on the list
+	sheets := SpriteSheets()
+	alien := NewAlien(game, AlienSmall, position)
+	bullet := NewBullet(game, position, rl.Vector2{}, nil)
+	for _, sheet := range []*gameobjects.SpriteSheet{rock.spritesheet, ship.Spritesheet, alien.spritesheet, bullet.spritesheet} {
+		if !slices.Contains(sheets, sheet) {
+			t.Errorf("Expected %v among the sprite sheets %v", sheet, sheets)
+		}
+	}
+}
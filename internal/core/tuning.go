@@ -0,0 +1,241 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Tuning holds the numbers that decide how the game feels, so they can be tweaked in a file rather than
+// in the code. Rotations are in turns per second and angles in degrees, which are easier to reason
+// about than radians; everything else is in worldspace units, seconds, and milliseconds. Masses are
+// relative to a tiny rock's, which is 1, and impulses are mass times units per second.
+type Tuning struct {
+	Ship      ShipTuning      `json:"ship"`
+	Bullet    BulletTuning    `json:"bullet"`
+	Shrapnel  ShrapnelTuning  `json:"shrapnel"`
+	Rock      RockTuning      `json:"rock"`
+	Alien     AlienTuning     `json:"alien"`
+	Explosion ExplosionTuning `json:"explosion"`
+}
+
+type ShipTuning struct {
+	RotateSpeed    float32 `json:"rotate_speed"`     // Turns per second
+	MaxSpeed       float32 `json:"max_speed"`        // Units per second
+	Drag           float32 `json:"drag"`             // Rate the velocity decays at per second while coasting
+	FuelBoost      float32 `json:"fuel_boost"`       // Acceleration while burning fuel, units per second per second
+	ExtraLifeEvery uint    `json:"extra_life_every"` // Points between extra lives
+	MaxLives       int     `json:"max_lives"`        // No extra lives are given beyond this many
+	Mass           float32 `json:"mass"`             // How hard the spaceship is to push around
+	Recoil         bool    `json:"recoil"`           // Firing pushes the spaceship back as hard as the bullet goes forward
+}
+
+type BulletTuning struct {
+	Speed      float32 `json:"speed"`       // Units per second
+	LifetimeMs uint    `json:"lifetime_ms"` // How long a bullet flies before it fizzles out
+	Mass       float32 `json:"mass"`        // How hard a bullet pushes what it hits, and the rocks it splits
+}
+
+type ShrapnelTuning struct {
+	MaxSpeed  float32 `json:"max_speed"`  // Units per second
+	MaxRotate float32 `json:"max_rotate"` // Turns per second
+}
+
+type RockTuning struct {
+	MaxSpeed  float32 `json:"max_speed"`  // Units per second for a tiny rock; bigger rocks are slower
+	MaxRotate float32 `json:"max_rotate"` // Turns per second, though rocks only reach a quarter of it
+	MaxCount  int     `json:"max_count"`  // Rocks on the playfield before they stop multiplying when split
+
+	// The first level on which rocks of each size split into smaller rocks when destroyed. Tiny rocks
+	// never split.
+	SplitFromLevel struct {
+		Small  int `json:"small"`
+		Medium int `json:"medium"`
+		Big    int `json:"big"`
+	} `json:"split_from_level"`
+	// A split rock breaks into between MinPieces and MaxPieces rocks, either included. MaxPieces goes up
+	// by one every LevelsPerExtraPiece levels once the level is high enough to need it.
+	MinPieces           int `json:"min_pieces"`
+	MaxPieces           int `json:"max_pieces"`
+	LevelsPerExtraPiece int `json:"levels_per_extra_piece"`
+}
+
+type AlienTuning struct {
+	MaxSpeed         float32 `json:"max_speed"`           // Units per second for a small alien; big ones are half as fast
+	MaxBulletDrift   float32 `json:"max_bullet_drift"`    // Degrees a big alien's aim can be off by; small ones are three times as accurate
+	MinActionDelayMs int     `json:"min_action_delay_ms"` // Aliens never act more often than this, however high the level
+	Mass             float32 `json:"mass"`                // How hard an alien is to push around
+}
+
+// ExplosionTuning is the blast when a rock, alien, or spaceship is destroyed, which pushes away whatever
+// is nearby: hardest right by it, fading to nothing at the radius.
+type ExplosionTuning struct {
+	Impulse float32 `json:"impulse"` // Push on something right at the middle of the blast
+	Radius  float32 `json:"radius"`  // Units from the middle the blast reaches
+}
+
+// DefaultTuning returns the tuning the game is designed around.
+func DefaultTuning() *Tuning {
+	t := &Tuning{
+		Ship: ShipTuning{
+			RotateSpeed:    1.5,
+			MaxSpeed:       400,
+			Drag:           1,
+			FuelBoost:      3000,
+			ExtraLifeEvery: 10_000,
+			MaxLives:       20,
+			Mass:           4,
+		},
+		Bullet: BulletTuning{
+			Speed:      500,
+			LifetimeMs: 1250,
+			Mass:       0.2,
+		},
+		Shrapnel: ShrapnelTuning{
+			MaxSpeed:  500,
+			MaxRotate: 6,
+		},
+		Rock: RockTuning{
+			MaxSpeed:            200,
+			MaxRotate:           3,
+			MaxCount:            30,
+			MinPieces:           2,
+			MaxPieces:           3,
+			LevelsPerExtraPiece: 2,
+		},
+		Alien: AlienTuning{
+			MaxSpeed:         400,
+			MaxBulletDrift:   45,
+			MinActionDelayMs: 500,
+			Mass:             8,
+		},
+		Explosion: ExplosionTuning{
+			Impulse: 400,
+			Radius:  120,
+		},
+	}
+	t.Rock.SplitFromLevel.Small = 4
+	t.Rock.SplitFromLevel.Medium = 3
+	t.Rock.SplitFromLevel.Big = 2
+	return t
+}
+
+// LoadTuning reads a tuning file. Anything the file leaves out keeps its default, so a file only needs
+// the numbers being tweaked.
+func LoadTuning(path string) (*Tuning, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := ParseTuning(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// ParseTuning reads tuning from JSON over the defaults and validates it.
+func ParseTuning(data []byte) (*Tuning, error) {
+	t := DefaultTuning()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(t); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("line %d: %w", 1+bytes.Count(data[:syntaxErr.Offset], []byte("\n")), err)
+		}
+		return nil, err
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate returns an error naming every setting that's out of range, or nil if they all make sense.
+func (t *Tuning) Validate() error {
+	var errs []error
+	positive := func(name string, value float64) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be more than 0, got %v", name, value))
+		}
+	}
+	atLeast := func(name string, value, least int) {
+		if value < least {
+			errs = append(errs, fmt.Errorf("%s must be at least %d, got %d", name, least, value))
+		}
+	}
+
+	positive("ship.rotate_speed", float64(t.Ship.RotateSpeed))
+	positive("ship.max_speed", float64(t.Ship.MaxSpeed))
+	if t.Ship.Drag < 0 {
+		errs = append(errs, fmt.Errorf("ship.drag can't be negative, got %v", t.Ship.Drag))
+	}
+	positive("ship.fuel_boost", float64(t.Ship.FuelBoost))
+	positive("ship.extra_life_every", float64(t.Ship.ExtraLifeEvery))
+	atLeast("ship.max_lives", t.Ship.MaxLives, 1)
+	positive("ship.mass", float64(t.Ship.Mass))
+
+	positive("bullet.speed", float64(t.Bullet.Speed))
+	positive("bullet.lifetime_ms", float64(t.Bullet.LifetimeMs))
+	if t.Bullet.Mass < 0 {
+		errs = append(errs, fmt.Errorf("bullet.mass can't be negative, got %v", t.Bullet.Mass))
+	}
+
+	positive("shrapnel.max_speed", float64(t.Shrapnel.MaxSpeed))
+	positive("shrapnel.max_rotate", float64(t.Shrapnel.MaxRotate))
+
+	positive("rock.max_speed", float64(t.Rock.MaxSpeed))
+	positive("rock.max_rotate", float64(t.Rock.MaxRotate))
+	atLeast("rock.max_count", t.Rock.MaxCount, 1)
+	atLeast("rock.split_from_level.small", t.Rock.SplitFromLevel.Small, 1)
+	atLeast("rock.split_from_level.medium", t.Rock.SplitFromLevel.Medium, 1)
+	atLeast("rock.split_from_level.big", t.Rock.SplitFromLevel.Big, 1)
+	atLeast("rock.min_pieces", t.Rock.MinPieces, 1)
+	atLeast("rock.max_pieces", t.Rock.MaxPieces, t.Rock.MinPieces)
+	atLeast("rock.levels_per_extra_piece", t.Rock.LevelsPerExtraPiece, 1)
+
+	positive("alien.max_speed", float64(t.Alien.MaxSpeed))
+	if t.Alien.MaxBulletDrift < 0 || t.Alien.MaxBulletDrift > 180 {
+		errs = append(errs, fmt.Errorf("alien.max_bullet_drift must be between 0 and 180 degrees, got %v", t.Alien.MaxBulletDrift))
+	}
+	atLeast("alien.min_action_delay_ms", t.Alien.MinActionDelayMs, 1)
+	positive("alien.mass", float64(t.Alien.Mass))
+
+	if t.Explosion.Impulse < 0 {
+		errs = append(errs, fmt.Errorf("explosion.impulse can't be negative, got %v", t.Explosion.Impulse))
+	}
+	if t.Explosion.Radius < 0 {
+		errs = append(errs, fmt.Errorf("explosion.radius can't be negative, got %v", t.Explosion.Radius))
+	}
+
+	return errors.Join(errs...)
+}
+
+// turnsToRadians converts turns per second to the radians per second the physics works in.
+func turnsToRadians(turns float32) float32 {
+	return float32(float64(turns) * 2 * math.Pi)
+}
+
+// degreesToRadians converts an angle in degrees to radians.
+func degreesToRadians(degrees float32) float32 {
+	return float32(float64(degrees) * math.Pi / 180)
+}
+
+// splitFromLevel returns the first level on which rocks of the given size split when destroyed, or
+// false if they never do.
+func (r *RockTuning) splitFromLevel(size RockSize) (int, bool) {
+	switch size {
+	case RockSmall:
+		return r.SplitFromLevel.Small, true
+	case RockMedium:
+		return r.SplitFromLevel.Medium, true
+	case RockBig:
+		return r.SplitFromLevel.Big, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadTuning_ShippedFileIsDefault(t *testing.T) {
+	t.Parallel()
+	tuning, err := LoadTuning("assets/tuning.json")
+	if err != nil {
+		t.Fatalf("Unexpected error loading the shipped tuning: %v", err)
+	}
+	if !reflect.DeepEqual(tuning, DefaultTuning()) {
+		t.Errorf("Expected the shipped tuning to match the defaults, got %+v", tuning)
+	}
+}
+
+func TestParseTuning_Partial(t *testing.T) {
+	t.Parallel()
+	tuning, err := ParseTuning([]byte(`{"ship": {"max_lives": 5}, "rock": {"split_from_level": {"big": 1}}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing: %v", err)
+	}
+	want := DefaultTuning()
+	want.Ship.MaxLives = 5
+	want.Rock.SplitFromLevel.Big = 1
+	if !reflect.DeepEqual(tuning, want) {
+		t.Errorf("Expected only the given settings to change, got %+v", tuning)
+	}
+}
+
+func TestParseTuning_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{"syntax", "{\n  \"ship\": {\n    \"max_lives\": 5,\n  }\n}", []string{"line 4"}},
+		{"unknown field", `{"ship": {"max_live": 5}}`, []string{`unknown field "max_live"`}},
+		{"wrong type", `{"bullet": {"speed": "fast"}}`, []string{"bullet.speed"}},
+		{"out of range", `{"ship": {"rotate_speed": -1}, "rock": {"min_pieces": 4}, "alien": {"max_bullet_drift": 200}}`,
+			[]string{"ship.rotate_speed must be more than 0, got -1", "rock.max_pieces must be at least 4, got 3", "alien.max_bullet_drift"}},
+		{"weightless", `{"ship": {"mass": 0}, "bullet": {"mass": -1}, "explosion": {"radius": -5}}`,
+			[]string{"ship.mass must be more than 0", "bullet.mass can't be negative", "explosion.radius can't be negative"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTuning([]byte(tt.json))
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected the error to mention %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGame_Tuning(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.Tuning = DefaultTuning()
+	game.Tuning.Bullet.LifetimeMs = 10
+	owner := NewSpaceship(game, 0)
+	bullet := NewBullet(game, rl.NewVector2(0, 0), rl.NewVector2(0, 0), &owner)
+	game.World.Objects.Add(bullet)
+	game.Update(0.02)
+	if bullet.IsAlive() {
+		t.Errorf("Expected the bullet to use the game's tuning and fizzle out after 10ms")
+	}
+}
@@ -2,46 +2,88 @@ package core
 
 import (
 	"avoid_the_space_rocks/internal/gameobjects"
-	random "avoid_the_space_rocks/internal/utils"
+	"avoid_the_space_rocks/internal/utils"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 // The World object represents the state of the game within the playfield
 type World struct {
-	Width     float32 // Width of the playfield in worldspace
-	Height    float32 // Height of the playfield in worldspace
-	Spaceship Spaceship
-	Objects   gameobjects.GameObjectCollection
+	Width      float32      // Width of the playfield in worldspace
+	Height     float32      // Height of the playfield in worldspace
+	Spaceships []*Spaceship // One for each player, in player order
+	Objects    gameobjects.GameObjectCollection
+	Particles  gameobjects.ParticleSystem // Effects, which are only for show and play no part in the game
+	random     *utils.Random
 }
 
-func NewWorld(width, height float32) *World {
+func NewWorld(width, height float32, random *utils.Random) *World {
 	w := World{
 		Width:  width,
 		Height: height,
+		random: random,
 	}
 	return &w
 }
 
-func (w *World) Initialize() {
-	// Spaceship starts in the middle pointing up
+// Initialize clears the playfield and spawns a spaceship into it for each of the game's ships, each
+// with the lives the game starts with.
+func (w *World) Initialize(game *Game) {
+	w.clear()
+	w.Spaceships = make([]*Spaceship, max(1, game.Ships))
+	for i := range w.Spaceships {
+		ship := NewSpaceship(game, i)
+		ship.Lives = game.startingLives
+		w.Spaceships[i] = &ship
+		ship.Spawn()
+	}
+}
+
+// clear empties the playfield of objects and effects, leaving effects off if they were.
+func (w *World) clear() {
 	w.Objects = gameobjects.NewGameObjectCollection()
-	w.Spaceship = NewSpaceship()
-	go w.Spaceship.Spawn()
+	w.Objects.Wrap(w.Width, w.Height)
+	off := w.Particles.Off
+	w.Particles = gameobjects.NewParticleSystem()
+	w.Particles.Wrap(w.Width, w.Height)
+	w.Particles.Off = off
 }
 
-// Wraparound returns the position of the given position, wrapping around the edges of the playfield
-func (w *World) Wraparound(p rl.Vector2) rl.Vector2 {
-	if p.X < 0 {
-		p.X = w.Width
-	} else if p.X > w.Width {
-		p.X = 0
+// NearestSpaceship returns the living spaceship closest to the given position, or nil if none are alive.
+// Ships in hyperspace count, since they'll be back in a moment.
+func (w *World) NearestSpaceship(p rl.Vector2) *Spaceship {
+	var nearest *Spaceship
+	for _, ship := range w.Spaceships {
+		if ship.IsAlive() && (nearest == nil || rl.Vector2Distance(p, ship.Position) < rl.Vector2Distance(p, nearest.Position)) {
+			nearest = ship
+		}
 	}
-	if p.Y < 0 {
-		p.Y = w.Height
-	} else if p.Y > w.Height {
-		p.Y = 0
+	return nearest
+}
+
+// Wraparound returns the position of the given position, wrapping around the edges of the playfield.
+// Something that has gone past an edge comes back in that far past the opposite edge, so it carries on
+// moving smoothly.
+func (w *World) Wraparound(p rl.Vector2) rl.Vector2 {
+	return rl.Vector2{X: gameobjects.WrapAround(p.X, w.Width), Y: gameobjects.WrapAround(p.Y, w.Height)}
+}
+
+// Towards returns the shortest way from one position to another, which may be across an edge.
+func (w *World) Towards(from, to rl.Vector2) rl.Vector2 {
+	return rl.Vector2{X: shortest(to.X-from.X, w.Width), Y: shortest(to.Y-from.Y, w.Height)}
+}
+
+// shortest returns the shorter of the distances d, or d the other way round a playfield of the size.
+func shortest(d, size float32) float32 {
+	switch {
+	case size <= 0:
+		return d
+	case d > size/2:
+		return d - size
+	case d < -size/2:
+		return d + size
+	default:
+		return d
 	}
-	return p
 }
 
 // IsOutsideEdges returns true if the given position is outside the edges of the playfield
@@ -52,22 +94,22 @@ func (w *World) IsOutsideEdges(p rl.Vector2) bool {
 // RandomBorderPosition returns a random position on the border of the playfield, each
 // equally likely.
 func (w *World) RandomBorderPosition() rl.Vector2 {
-	if random.Chance(0.5) {
+	if w.random.Chance(0.5) {
 		return rl.Vector2{
-			X: random.RndFloat32(w.Width),
-			Y: random.Choice([]float32{0, w.Height}),
+			X: w.random.RndFloat32(w.Width),
+			Y: w.random.Choice([]float32{0, w.Height}),
 		}
 	}
 	return rl.Vector2{
-		X: random.Choice([]float32{0, w.Width}),
-		Y: random.RndFloat32(w.Height),
+		X: w.random.Choice([]float32{0, w.Width}),
+		Y: w.random.RndFloat32(w.Height),
 	}
 }
 
 // RandomPosition returns a random position within the playfield.
 func (w *World) RandomPosition() rl.Vector2 {
 	return rl.Vector2{
-		X: random.RndFloat32(w.Width),
-		Y: random.RndFloat32(w.Height),
+		X: w.random.RndFloat32(w.Width),
+		Y: w.random.RndFloat32(w.Height),
 	}
 }
@@ -1,21 +1,26 @@
 package core
 
 import (
+	"avoid_the_space_rocks/internal/utils"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"testing"
 )
 
 func TestWraparound(t *testing.T) {
-	world := NewWorld(800, 600)
+	t.Parallel()
+	world := NewWorld(800, 600, utils.NewRandom(1))
 
 	tests := []struct {
 		input    rl.Vector2
 		expected rl.Vector2
 	}{
-		{input: rl.Vector2{X: -10, Y: 300}, expected: rl.Vector2{X: 800, Y: 300}},
-		{input: rl.Vector2{X: 810, Y: 300}, expected: rl.Vector2{X: 0, Y: 300}},
-		{input: rl.Vector2{X: 400, Y: -10}, expected: rl.Vector2{X: 400, Y: 600}},
-		{input: rl.Vector2{X: 400, Y: 610}, expected: rl.Vector2{X: 400, Y: 0}},
+		{input: rl.Vector2{X: -10, Y: 300}, expected: rl.Vector2{X: 790, Y: 300}},
+		{input: rl.Vector2{X: 810, Y: 300}, expected: rl.Vector2{X: 10, Y: 300}},
+		{input: rl.Vector2{X: 400, Y: -10}, expected: rl.Vector2{X: 400, Y: 590}},
+		{input: rl.Vector2{X: 400, Y: 610}, expected: rl.Vector2{X: 400, Y: 10}},
+		{input: rl.Vector2{X: -10, Y: 605}, expected: rl.Vector2{X: 790, Y: 5}},
+		{input: rl.Vector2{X: 800, Y: 0}, expected: rl.Vector2{X: 800, Y: 0}},
+		{input: rl.Vector2{X: 1700, Y: 300}, expected: rl.Vector2{X: 100, Y: 300}},
 	}
 
 	for _, test := range tests {
@@ -27,7 +32,8 @@ func TestWraparound(t *testing.T) {
 }
 
 func TestIsOutsideEdges(t *testing.T) {
-	world := NewWorld(800, 600)
+	t.Parallel()
+	world := NewWorld(800, 600, utils.NewRandom(1))
 
 	tests := []struct {
 		input    rl.Vector2
@@ -47,3 +53,85 @@ func TestIsOutsideEdges(t *testing.T) {
 		}
 	}
 }
+
+func TestRandomBorderPosition_SameSeed(t *testing.T) {
+	t.Parallel()
+	world1 := NewWorld(800, 600, utils.NewRandom(1234))
+	world2 := NewWorld(800, 600, utils.NewRandom(1234))
+
+	for range 100 {
+		p1 := world1.RandomBorderPosition()
+		p2 := world2.RandomBorderPosition()
+		if p1 != p2 {
+			t.Fatalf("Expected same positions from the same seed, got %v and %v", p1, p2)
+		}
+		if p1.X != 0 && p1.X != 800 && p1.Y != 0 && p1.Y != 600 {
+			t.Errorf("Expected %v to be on the border", p1)
+		}
+	}
+}
+
+func TestInitialize_SpaceshipsSpreadOut(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.Ships = 2
+	game.SetDifficulty(DifficultyEasy)
+	game.World.Initialize(game)
+
+	ships := game.World.Spaceships
+	if len(ships) != 2 {
+		t.Fatalf("Expected 2 spaceships, got %d", len(ships))
+	}
+	for i, want := range []rl.Vector2{{X: 800.0 / 3, Y: 300}, {X: 1600.0 / 3, Y: 300}} {
+		if ships[i].Player != i || ships[i].Position != want || ships[i].Lives != 5 {
+			t.Errorf("Expected player %d's spaceship at %v with 5 lives, got player %d at %v with %d",
+				i, want, ships[i].Player, ships[i].Position, ships[i].Lives)
+		}
+	}
+	if game.Lives() != 10 {
+		t.Errorf("Expected 10 lives between them, got %d", game.Lives())
+	}
+
+	if nearest := game.World.NearestSpaceship(rl.Vector2{X: 700, Y: 100}); nearest != ships[1] {
+		t.Errorf("Expected the second spaceship to be nearest the right edge, got %v", nearest)
+	}
+	ships[1].Alive = false
+	if nearest := game.World.NearestSpaceship(rl.Vector2{X: 700, Y: 100}); nearest != ships[0] {
+		t.Errorf("Expected the first spaceship to be nearest once the second is gone, got %v", nearest)
+	}
+}
+
+func TestWorld_CollidesAcrossEdges(t *testing.T) {
+	t.Parallel()
+	game := NewGame(800, 600, 1)
+	game.World.Initialize(game)
+	ship := game.World.Spaceships[0]
+	ship.Position, ship.Velocity = rl.Vector2{X: 5, Y: 300}, rl.Vector2{}
+
+	// The rock's middle is at the far side, but it hangs over onto the spaceship's
+	rock := NewRock(game, RockBig, rl.Vector2{X: 790, Y: 300})
+	rock.Velocity = rl.Vector2{}
+	game.World.Objects.Add(&rock)
+	game.World.Objects.Update(0)
+
+	if ship.IsAlive() {
+		t.Errorf("Expected the rock hanging over the edge to hit the spaceship at %v", ship.Position)
+	}
+}
+
+func TestTowards(t *testing.T) {
+	t.Parallel()
+	world := NewWorld(800, 600, utils.NewRandom(1))
+	tests := []struct {
+		from, to, want rl.Vector2
+	}{
+		{rl.Vector2{X: 100, Y: 100}, rl.Vector2{X: 150, Y: 80}, rl.Vector2{X: 50, Y: -20}},
+		{rl.Vector2{X: 10, Y: 300}, rl.Vector2{X: 790, Y: 300}, rl.Vector2{X: -20, Y: 0}},
+		{rl.Vector2{X: 400, Y: 590}, rl.Vector2{X: 400, Y: 5}, rl.Vector2{X: 0, Y: 15}},
+	}
+	for _, tt := range tests {
+		if got := world.Towards(tt.from, tt.to); got != tt.want {
+			t.Errorf("Towards(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package gameobjects
 
 import (
+	"avoid_the_space_rocks/internal/platform"
 	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"math"
@@ -9,12 +10,14 @@ import (
 
 type SpriteSheet struct {
 	name        string
-	texture     rl.Texture2D // The texture with the packed sprites
-	frameWidth  int          // Width of each frame in pixels
-	frameHeight int          // Height of each frame pixels
-	rows        int          // the number of rows in the spritesheet
-	cols        int          // the number of columns in the spritesheet
-	origin      rl.Vector2   // The middle of the sprite (for rotation)
+	texture     platform.Texture  // The texture with the packed sprites
+	loadedOn    platform.Platform // The platform the texture was loaded on, if it has been
+	frameWidth  int               // Width of each frame in pixels
+	frameHeight int               // Height of each frame pixels
+	rows        int               // the number of rows in the spritesheet
+	cols        int               // the number of columns in the spritesheet
+	origin      rl.Vector2        // The middle of the sprite (for rotation)
+	loadFailed  bool              // The texture couldn't be loaded to measure it
 }
 
 type SpriteManager struct {
@@ -51,55 +54,155 @@ func LoadSpriteSheet(file string, rows, cols int) *SpriteSheet {
 	return &s
 }
 
-// populateTexture loads a spritesheet from the saved filename, or returns an error if it can't.
-// It initializes it with the specified rows and columns. SpriteSheets are cached.
-func (s *SpriteSheet) populateTexture() error {
+// populateSize reads the size of the spritesheet from the saved filename, or returns an error if it
+// can't. It splits it into frames with the specified rows and columns. SpriteSheets are cached.
+func (s *SpriteSheet) populateSize() error {
 	spriteManager.mapLock.Lock()
 	defer spriteManager.mapLock.Unlock()
 	if s.frameWidth != 0 {
-		// Already loaded; just return
+		// Already measured; just return
 		return nil
 	}
-	sheetTexture := rl.LoadTexture("assets/sprites/" + s.name)
-	if int(sheetTexture.Width)%s.cols != 0 || int(sheetTexture.Height)%s.rows != 0 {
+	width, height, err := platform.ImageSize("assets/sprites/" + s.name)
+	if err != nil {
+		s.loadFailed = true
+		return err
+	}
+	if int(width)%s.cols != 0 || int(height)%s.rows != 0 {
+		s.loadFailed = true
 		return fmt.Errorf("spritesheet of dimensions (%d,%d) can't be broken into %d rows and %d cols",
-			sheetTexture.Width, sheetTexture.Height, s.rows, s.cols)
+			width, height, s.rows, s.cols)
 	}
-	s.texture = sheetTexture
-	s.frameWidth = int(sheetTexture.Width) / s.cols
-	s.frameHeight = int(sheetTexture.Height) / s.rows
+	s.frameWidth = int(width) / s.cols
+	s.frameHeight = int(height) / s.rows
 	s.origin = rl.NewVector2(float32(s.frameWidth)/2, float32(s.frameHeight)/2)
-	spriteManager.spritesMap[s.name] = s
 	return nil
 }
 
+// textureOn returns the spritesheet's texture as loaded on p, loading it first if it hasn't been
+// loaded there yet.
+func (s *SpriteSheet) textureOn(p platform.Platform) (platform.Texture, error) {
+	spriteManager.mapLock.Lock()
+	defer spriteManager.mapLock.Unlock()
+	if s.loadedOn == p {
+		return s.texture, nil
+	}
+	texture, err := p.LoadTexture("assets/sprites/" + s.name)
+	if err != nil {
+		return platform.Texture{}, err
+	}
+	s.texture, s.loadedOn = texture, p
+	return texture, nil
+}
+
+// measure reads the sprite's size if it hasn't been yet. Hitboxes come from the size, so it has to be
+// known before the simulation first asks rather than whenever the sprite is first drawn, or games
+// would play out differently depending on what was drawn before them. It doesn't need a platform.
+func (s *SpriteSheet) measure() {
+	if s.frameWidth == 0 && !s.loadFailed {
+		if err := s.populateSize(); err != nil {
+			platform.Log(rl.LogWarning, "error loading spritesheet %s: %v", s.name, err)
+		}
+	}
+}
+
+// Layout returns the file the spritesheet comes from and how its frames are arranged, which is
+// everything LoadSpriteSheet needs to load it again.
+func (s *SpriteSheet) Layout() (file string, rows, cols int) {
+	return s.name, s.rows, s.cols
+}
+
 func (s *SpriteSheet) String() string {
 	return fmt.Sprintf("%s (%dx%d)", s.name, s.frameWidth, s.frameHeight)
 }
 
-// Draw the sprite at the given frame at the given location and rotation
-func (s *SpriteSheet) Draw(frameRow, frameCol int, loc, rot rl.Vector2) error {
+// Draw the sprite at the given frame at the given location and rotation on p
+func (s *SpriteSheet) Draw(p platform.Platform, frameRow, frameCol int, loc, rot rl.Vector2) error {
+	texture, frame, destination, origin, rotationDegrees, err := s.placement(p, frameRow, frameCol, loc, rot, 1)
+	if err != nil {
+		return err
+	}
+	p.DrawTexture(texture, frame, destination, origin, rotationDegrees)
+	return nil
+}
+
+// DrawTinted draws the sprite like Draw, but scaled about its middle and coloured by the tint, which
+// multiplies the sprite's own colours: a white sprite comes out the tint's colour, and the tint's
+// alpha fades it.
+func (s *SpriteSheet) DrawTinted(p platform.Platform, frameRow, frameCol int, loc, rot rl.Vector2, scale float32, tint rl.Color) error {
+	texture, frame, destination, origin, rotationDegrees, err := s.placement(p, frameRow, frameCol, loc, rot, scale)
+	if err != nil {
+		return err
+	}
+	p.DrawTextureTinted(texture, frame, destination, origin, rotationDegrees, tint)
+	return nil
+}
+
+// placement returns the texture loaded on p, the frame's rectangle in it, and the rectangle, origin, and
+// rotation in degrees to draw it at the given location and rotation, scaled about its middle.
+func (s *SpriteSheet) placement(p platform.Platform, frameRow, frameCol int, loc, rot rl.Vector2, scale float32) (texture platform.Texture, frame, destination rl.Rectangle, origin rl.Vector2, rotationDegrees float32, err error) {
 	if s.frameWidth == 0 {
-		// Texture hasn't been loaded yet, so load it now
-		if err := s.populateTexture(); err != nil {
-			return err
+		// Texture hasn't been measured yet, so measure it now
+		if err := s.populateSize(); err != nil {
+			return platform.Texture{}, rl.Rectangle{}, rl.Rectangle{}, rl.Vector2{}, 0, err
 		}
 	}
-	frame, err := s.frame(frameRow, frameCol)
+	texture, err = s.textureOn(p)
 	if err != nil {
-		return err
+		return platform.Texture{}, rl.Rectangle{}, rl.Rectangle{}, rl.Vector2{}, 0, err
+	}
+	frame, err = s.frame(frameRow, frameCol)
+	if err != nil {
+		return platform.Texture{}, rl.Rectangle{}, rl.Rectangle{}, rl.Vector2{}, 0, err
 	}
-	destination := rl.Rectangle{
+	destination = rl.Rectangle{
 		X:      loc.X,
 		Y:      loc.Y,
-		Width:  float32(s.frameWidth),
-		Height: float32(s.frameHeight),
+		Width:  float32(s.frameWidth) * scale,
+		Height: float32(s.frameHeight) * scale,
+	}
+	rotationDegrees = float32(math.Atan2(float64(rot.Y), float64(rot.X)) * 180 / math.Pi)
+	return texture, frame, destination, rl.Vector2Scale(s.origin, scale), rotationDegrees, nil
+}
+
+// DrawWrapped draws the sprite like Draw, and again on the far side of each edge of a playfield of the
+// given size that it hangs over, so something crossing an edge is seen leaving one side as it arrives
+// at the other rather than vanishing.
+func (s *SpriteSheet) DrawWrapped(p platform.Platform, frameRow, frameCol int, loc, rot rl.Vector2, width, height float32) error {
+	if err := s.Draw(p, frameRow, frameCol, loc, rot); err != nil {
+		return err
+	}
+	// However it's turned, the sprite stays within half its diagonal of its middle
+	reach := rl.Vector2Length(s.GetSize()) / 2
+	dx, dy := wrappedOffset(loc.X, reach, width), wrappedOffset(loc.Y, reach, height)
+	for i, offset := range [...]rl.Vector2{{X: dx}, {Y: dy}, {X: dx, Y: dy}} {
+		// Over a side edge, across from it; over the top or bottom, below or above; over a corner, all three
+		if (i != 1 && dx == 0) || (i != 0 && dy == 0) {
+			continue
+		}
+		if err := s.Draw(p, frameRow, frameCol, rl.Vector2Add(loc, offset), rot); err != nil {
+			return err
+		}
 	}
-	rotationDegrees := float32(math.Atan2(float64(rot.Y), float64(rot.X)) * 180 / math.Pi)
-	rl.DrawTexturePro(s.texture, frame, destination, s.origin, rotationDegrees, rl.Black)
 	return nil
 }
 
+// wrappedOffset returns how far to move something, along one axis of the given size, to show the part of
+// it that reaches past an edge on the other side: a whole size one way or the other, or nothing if it's
+// clear of both edges.
+func wrappedOffset(middle, reach, size float32) float32 {
+	switch {
+	case size <= 0:
+		return 0
+	case middle-reach < 0:
+		return size
+	case middle+reach > size:
+		return -size
+	default:
+		return 0
+	}
+}
+
 // frame returns the rectangle for the given frame in the spritesheet
 func (s *SpriteSheet) frame(row, col int) (rl.Rectangle, error) {
 	if row < 0 || row >= int(s.rows) || col < 0 || col >= int(s.cols) {
@@ -125,6 +228,7 @@ func (s *SpriteSheet) FrameLocation(f int) (int, int, error) {
 
 // GetSize returns the size of the sprite in pixels as a vector
 func (s *SpriteSheet) GetSize() rl.Vector2 {
+	s.measure()
 	return rl.Vector2{
 		X: float32(s.frameWidth),
 		Y: float32(s.frameHeight),
@@ -133,6 +237,7 @@ func (s *SpriteSheet) GetSize() rl.Vector2 {
 
 // GetRectangle returns the bounding rectangle where this sprite will be drawn centered at center
 func (s *SpriteSheet) GetRectangle(center rl.Vector2) rl.Rectangle {
+	s.measure()
 	return rl.Rectangle{
 		X:      center.X - float32(s.frameWidth)/2,
 		Y:      center.Y - float32(s.frameHeight)/2,
@@ -1,18 +1,47 @@
 package gameobjects
 
 import (
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"os"
+	"slices"
 	"testing"
 )
 
 func TestMain(m *testing.M) {
-	rl.InitWindow(800, 600, "Test")
-	defer rl.CloseWindow()
+	// Change to the project root directory so the sprites load; no window is needed
+	if err := os.Chdir("../.."); err != nil {
+		panic("failed to change to project root directory: " + err.Error())
+	}
 	code := m.Run()
 	os.Exit(code)
 }
 
+func TestSpriteSheet_Draw(t *testing.T) {
+	sheet := LoadSpriteSheet("alien_big.png", 2, 2)
+
+	if err := sheet.Draw(platform.NewHeadless(), 1, 1, rl.NewVector2(50, 50), rl.NewVector2(1, 0)); err != nil {
+		t.Fatalf("Unexpected error drawing: %v", err)
+	}
+	if sheet.GetSize() != rl.NewVector2(54, 32) {
+		t.Errorf("Expected the texture to load with 54x32 frames, got %v", sheet.GetSize())
+	}
+
+	missing := LoadSpriteSheet("non_existent_file.png", 1, 1)
+	if err := missing.Draw(platform.NewHeadless(), 0, 0, rl.NewVector2(50, 50), rl.NewVector2(1, 0)); err == nil {
+		t.Errorf("Expected an error drawing a missing sprite sheet")
+	}
+}
+
+func TestSpriteSheet_MeasuredBeforeDrawing(t *testing.T) {
+	sheet := LoadSpriteSheet("bullet.png", 1, 1)
+
+	rect := sheet.GetRectangle(rl.NewVector2(50, 50))
+	if rect.Width == 0 || rect.Height == 0 {
+		t.Errorf("Expected the sprite to be measured without being drawn, got %v", rect)
+	}
+}
+
 func TestSpriteSheet_frame(t *testing.T) {
 	sheet := LoadSpriteSheet("alien_big.png", 2, 2)
 
@@ -95,3 +124,68 @@ func TestLoadSpriteSheet_Cache(t *testing.T) {
 		t.Errorf("Expected the same pointer, got different pointers")
 	}
 }
+
+func TestWrappedOffset(t *testing.T) {
+	tests := []struct {
+		name                string
+		middle, reach, size float32
+		want                float32
+	}{
+		{"clear of both edges", 400, 50, 800, 0},
+		{"over the near edge", 30, 50, 800, 800},
+		{"over the far edge", 790, 50, 800, -800},
+		{"just touching an edge", 50, 50, 800, 0},
+		{"no playfield", 30, 50, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := wrappedOffset(tt.middle, tt.reach, tt.size); got != tt.want {
+			t.Errorf("Expected something %s to be shown %v away, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// drawRecorder is a headless platform that remembers where it was asked to draw, and in what tint.
+type drawRecorder struct {
+	*platform.Headless
+	destinations []rl.Rectangle
+	tints        []rl.Color
+}
+
+func (d *drawRecorder) DrawTexture(_ platform.Texture, _, destination rl.Rectangle, _ rl.Vector2, _ float32) {
+	d.destinations = append(d.destinations, destination)
+}
+
+func (d *drawRecorder) DrawTextureTinted(_ platform.Texture, _, destination rl.Rectangle, _ rl.Vector2, _ float32, tint rl.Color) {
+	d.destinations = append(d.destinations, destination)
+	d.tints = append(d.tints, tint)
+}
+
+func TestSpriteSheet_DrawWrapped(t *testing.T) {
+	t.Parallel()
+	recorder := &drawRecorder{Headless: platform.NewHeadless()}
+
+	sheet := LoadSpriteSheet("rock_big.png", 1, 1)
+	tests := []struct {
+		name string
+		loc  rl.Vector2
+		want []rl.Vector2
+	}{
+		{"in the middle", rl.Vector2{X: 400, Y: 300}, []rl.Vector2{{X: 400, Y: 300}}},
+		{"over the left edge", rl.Vector2{X: 10, Y: 300}, []rl.Vector2{{X: 10, Y: 300}, {X: 810, Y: 300}}},
+		{"over the bottom edge", rl.Vector2{X: 400, Y: 590}, []rl.Vector2{{X: 400, Y: 590}, {X: 400, Y: -10}}},
+		{"over the top right corner", rl.Vector2{X: 790, Y: 10}, []rl.Vector2{{X: 790, Y: 10}, {X: -10, Y: 10}, {X: 790, Y: 610}, {X: -10, Y: 610}}},
+	}
+	for _, tt := range tests {
+		recorder.destinations = nil
+		if err := sheet.DrawWrapped(recorder, 0, 0, tt.loc, rl.Vector2{X: 1}, 800, 600); err != nil {
+			t.Fatalf("Unexpected error drawing: %v", err)
+		}
+		var got []rl.Vector2
+		for _, destination := range recorder.destinations {
+			got = append(got, rl.Vector2{X: destination.X, Y: destination.Y})
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Expected a rock %s drawn at %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package gameobjects
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"testing"
+	"time"
+)
+
+// pushableObject is an object with a body and a mass, which explosions push around.
+type pushableObject struct {
+	MockGameObject
+	Rigidbody
+	mass float32
+}
+
+func (p *pushableObject) Mass() float32 { return p.mass }
+
+func TestExplode(t *testing.T) {
+	at := func(x, y, mass float32) *pushableObject {
+		return &pushableObject{
+			MockGameObject: MockGameObject{alive: true},
+			Rigidbody:      Rigidbody{Transform: Transform{Position: rl.Vector2{X: x, Y: y}}},
+			mass:           mass,
+		}
+	}
+	near, heavy, far := at(150, 100, 1), at(100, 150, 4), at(300, 100, 1)
+	edge, dead := at(1014, 100, 1), at(100, 50, 1)
+	dead.alive = false
+	collection := collectionOf([]GameObject{near, heavy, far, edge, dead, &notCollidable{alive: true}}, true)
+
+	// A blast reaching 100 units, pushing with 100 at its middle
+	collection.Explode(rl.Vector2{X: 100, Y: 100}, 100, 100)
+
+	if near.Velocity != (rl.Vector2{X: 50}) {
+		t.Errorf("Expected something halfway out pushed straight away with half the impulse, got %v", near.Velocity)
+	}
+	if heavy.Velocity != (rl.Vector2{Y: 12.5}) {
+		t.Errorf("Expected something four times as heavy pushed a quarter as fast, got %v", heavy.Velocity)
+	}
+	if far.Velocity != (rl.Vector2{}) || dead.Velocity != (rl.Vector2{}) {
+		t.Errorf("Expected nothing out of reach or dead pushed, got %v and %v", far.Velocity, dead.Velocity)
+	}
+
+	// Just inside the left edge, in reach of something just inside the right edge
+	collection.Explode(rl.Vector2{X: 5, Y: 100}, 100, 100)
+	if edge.Velocity.X >= 0 || edge.Velocity.Y != 0 {
+		t.Errorf("Expected the blast to reach across the edge and push left, got %v", edge.Velocity)
+	}
+}
+
+// explodingObject blows up the first time it's hit, while someone else is waiting to restore the
+// collection.
+type explodingObject struct {
+	MockGameObject
+	collection *GameObjectCollection
+}
+
+func (e *explodingObject) OnCollision(_ Collidable) error {
+	if !e.alive {
+		return nil
+	}
+	e.alive = false
+	waiting := make(chan struct{})
+	go func() {
+		close(waiting)
+		e.collection.Restore(nil, nil)
+	}()
+	<-waiting
+	time.Sleep(10 * time.Millisecond) // Give the restore time to start waiting for the lock
+	return nil
+}
+
+func (e *explodingObject) Blast() (rl.Vector2, float32, float32) {
+	return rl.Vector2{}, 100, 100
+}
+
+func TestExplode_DestroyedInCollision(t *testing.T) {
+	collection := NewGameObjectCollection()
+	neighbour := &pushableObject{
+		MockGameObject: MockGameObject{alive: true, hitbox: rl.NewRectangle(5, 5, 10, 10)},
+		Rigidbody:      Rigidbody{Transform: Transform{Position: rl.Vector2{X: 50}}},
+		mass:           1,
+	}
+	collection.Add(&explodingObject{MockGameObject: MockGameObject{alive: true, hitbox: rl.NewRectangle(0, 0, 10, 10)}, collection: &collection})
+	collection.Add(neighbour)
+
+	done := make(chan struct{})
+	go func() {
+		collection.Update(0.1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the blast of something destroyed in a collision not to wait on the lock")
+	}
+	if neighbour.Velocity.X <= 0 || neighbour.Velocity.Y != 0 {
+		t.Errorf("Expected the blast to push the neighbour straight away, got %v", neighbour.Velocity)
+	}
+}
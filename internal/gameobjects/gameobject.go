@@ -1,24 +1,40 @@
 package gameobjects
 
 import (
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"slices"
 	"sync"
 )
 
 type GameObject interface {
 	Update(delta float32) error
-	Draw() error
+	Draw(p platform.Platform) error
 	IsAlive() bool
 	IsEnemy() bool
 }
 
 type Collidable interface {
 	OnCollision(other Collidable) error
+	// GetHitbox returns the rectangle the object is drawn in, used to keep things from appearing on top
+	// of each other.
 	GetHitbox() rl.Rectangle
+	// GetShape returns the object's outline, turned to face its rotation, used to find what it hits.
+	GetShape() Shape
 }
 
 type Destructible interface {
-	OnDestruction(direction rl.Vector2) error
+	// OnDestruction is called when the object is destroyed by another, which may be nil if it wasn't
+	// destroyed by anything on the playfield. Direction is the velocity of whatever hit it.
+	OnDestruction(by Collidable, direction rl.Vector2) error
+}
+
+// Exploder is an object that blasts whatever is near it away when it's destroyed. When a collision
+// destroys it, the collection sets off the blast once both objects have handled the collision; when
+// something else does, it's up to whatever destroyed it to call Explode.
+type Exploder interface {
+	// Blast returns where the blast is centered, how hard it pushes there, and how far it reaches.
+	Blast() (center rl.Vector2, impulse, radius float32)
 }
 
 type GameObjectCollection struct {
@@ -26,6 +42,12 @@ type GameObjectCollection struct {
 	newObjects     []GameObject
 	objectsLock    sync.RWMutex
 	newObjectsLock sync.RWMutex
+
+	grid          *grid       // Broad phase for collision checking; nil until the playfield's size is known
+	width, height float32     // Size of the playfield the objects wrap around, once known
+	candidates    []int       // Reused by collision checking to save allocating every tick
+	shapes        []Shape     // The shape of each object during a collision check, or nil if not collidable
+	image         shapeBuffer // Reused by collision checking for shapes moved across an edge
 }
 
 func NewGameObjectCollection() GameObjectCollection {
@@ -35,6 +57,14 @@ func NewGameObjectCollection() GameObjectCollection {
 	}
 }
 
+// Wrap tells the collection the size of the playfield its objects wrap around, so collision checking
+// can sort them into a grid across it rather than check every object against every other, and can
+// find objects touching across an edge.
+func (c *GameObjectCollection) Wrap(width, height float32) {
+	c.grid = newGrid(width, height)
+	c.width, c.height = width, height
+}
+
 // Add adds a game object to the collection.
 func (c *GameObjectCollection) Add(obj GameObject) {
 	c.newObjectsLock.Lock()
@@ -54,7 +84,7 @@ func (c *GameObjectCollection) Update(delta float32) {
 	defer c.objectsLock.RUnlock()
 	for idx, obj := range c.objects {
 		if err := obj.Update(delta); err != nil {
-			rl.TraceLog(rl.LogError, "error updating object %d %v: %v", idx, obj, err)
+			platform.Log(rl.LogError, "error updating object %d %v: %v", idx, obj, err)
 		}
 	}
 
@@ -62,14 +92,14 @@ func (c *GameObjectCollection) Update(delta float32) {
 	c.collisionCheck()
 }
 
-// Draw all the objects in the collection.
-func (c *GameObjectCollection) Draw() {
+// Draw all the objects in the collection on p.
+func (c *GameObjectCollection) Draw(p platform.Platform) {
 	c.objectsLock.RLock()
 	defer c.objectsLock.RUnlock()
 
 	for idx, obj := range c.objects {
-		if err := obj.Draw(); err != nil {
-			rl.TraceLog(rl.LogError, "error drawing object %d %v: %v", idx, obj, err)
+		if err := obj.Draw(p); err != nil {
+			platform.Log(rl.LogError, "error drawing object %d %v: %v", idx, obj, err)
 		}
 	}
 }
@@ -117,6 +147,28 @@ func (c *GameObjectCollection) ForEach(action func(GameObject)) {
 	}
 }
 
+// Contents returns copies of the objects in the collection, in update order, and of the objects added
+// since the last update, which join at the next.
+func (c *GameObjectCollection) Contents() (objects, newObjects []GameObject) {
+	c.objectsLock.RLock()
+	c.newObjectsLock.RLock()
+	defer c.objectsLock.RUnlock()
+	defer c.newObjectsLock.RUnlock()
+
+	return slices.Clone(c.objects), slices.Clone(c.newObjects)
+}
+
+// Restore replaces everything in the collection with objects and newObjects, as returned by Contents.
+func (c *GameObjectCollection) Restore(objects, newObjects []GameObject) {
+	c.objectsLock.Lock()
+	c.newObjectsLock.Lock()
+	defer c.objectsLock.Unlock()
+	defer c.newObjectsLock.Unlock()
+
+	c.objects = append(make([]GameObject, 0, max(100, len(objects))), objects...)
+	c.newObjects = append(make([]GameObject, 0, max(100, len(newObjects))), newObjects...)
+}
+
 // IsRectangleOccupied returns true if the given rectangle overlaps with any collidable, live object in the playfield.
 func (c *GameObjectCollection) IsRectangleOccupied(area rl.Rectangle) bool {
 	c.objectsLock.RLock()
@@ -124,7 +176,7 @@ func (c *GameObjectCollection) IsRectangleOccupied(area rl.Rectangle) bool {
 
 	for idx := range c.objects {
 		if collidable := c.getCollidable(idx); collidable != nil {
-			if rl.CheckCollisionRecs(area, collidable.GetHitbox()) {
+			if overlaps(area, collidable.GetHitbox()) {
 				return true
 			}
 		}
@@ -132,17 +184,53 @@ func (c *GameObjectCollection) IsRectangleOccupied(area rl.Rectangle) bool {
 	return false
 }
 
+// Explode pushes every live Pushable object within the radius of the center directly away from it,
+// like the blast of an explosion: an impulse of the given strength right at the center, fading to
+// nothing at the radius. Once the playfield's size is known, the blast reaches across its edges.
+// Don't call it from anything the collection calls, such as a collision handler, which already has the
+// collection locked.
+func (c *GameObjectCollection) Explode(center rl.Vector2, impulse, radius float32) {
+	c.objectsLock.RLock()
+	defer c.objectsLock.RUnlock()
+	c.explodeLocked(center, impulse, radius)
+}
+
+// explodeLocked does the work of Explode, for a caller already holding the lock.
+func (c *GameObjectCollection) explodeLocked(center rl.Vector2, impulse, radius float32) {
+	for _, obj := range c.objects {
+		pushable, ok := obj.(Pushable)
+		if !ok || !obj.IsAlive() {
+			continue
+		}
+		position := pushable.Body().Position
+		if c.width != 0 && c.height != 0 {
+			position.X += nearestOffset(position.X, center.X, c.width)
+			position.Y += nearestOffset(position.Y, center.Y, c.height)
+		}
+		away := rl.Vector2Subtract(position, center)
+		distance := rl.Vector2Length(away)
+		if distance == 0 || distance >= radius {
+			continue
+		}
+		Push(pushable, rl.Vector2Scale(away, impulse*(1-distance/radius)/distance))
+	}
+}
+
 // removeDead removes all dead objects from the collection, after which the collection will be a full
-// slice of alive objects.
+// slice of alive objects. Recyclable objects go back to their pools.
 func (c *GameObjectCollection) removeDead() {
 	c.objectsLock.Lock()
 	defer c.objectsLock.Unlock()
 
 	for i := len(c.objects) - 1; i >= 0; i-- {
-		if !c.objects[i].IsAlive() {
+		if obj := c.objects[i]; !obj.IsAlive() {
 			// Replace the current element with the one at the end
 			c.objects[i] = c.objects[len(c.objects)-1]
+			c.objects[len(c.objects)-1] = nil
 			c.objects = c.objects[:len(c.objects)-1]
+			if recyclable, ok := obj.(Recyclable); ok {
+				recyclable.Recycle()
+			}
 		}
 	}
 }
@@ -159,31 +247,119 @@ func (c *GameObjectCollection) birthNew() {
 }
 
 // CollisionCheck checks for collisions between all the Collidable objects in the
-// collection. When two objects collide they have their OnCollision methods called.
-// TODO: Use spatial partitioning so it's not O(n^2)
+// collection. When two objects' shapes overlap they have their OnCollision methods called.
+// Once the collection knows the size of the playfield, only objects sharing a grid cell
+// are checked against each other, but the pairs and the order they're handled in are
+// exactly the same as checking every pair, so games play out the same either way.
 func (c *GameObjectCollection) collisionCheck() {
+	c.shapes = c.shapes[:0]
+	for i := range c.objects {
+		var shape Shape
+		if collidable := c.getCollidable(i); collidable != nil {
+			shape = collidable.GetShape()
+		}
+		c.shapes = append(c.shapes, shape)
+	}
+	if c.grid == nil {
+		c.collisionCheckAll()
+		return
+	}
+	c.grid.reset(len(c.objects))
+	for i, shape := range c.shapes {
+		if shape != nil {
+			c.grid.insert(i, shape.Bounds())
+		}
+	}
 	for i := len(c.objects) - 1; i >= 0; i-- {
 		hammer := c.getCollidable(i)
 		if hammer == nil {
 			continue
 		}
-		for j := i - 1; j >= 0; j-- {
-			anvil := c.getCollidable(j)
-			if anvil == nil {
-				continue
+		c.candidates = c.grid.candidates(i, c.shapes[i].Bounds(), c.candidates[:0])
+		for _, j := range c.candidates {
+			if anvil := c.getCollidable(j); anvil != nil {
+				c.collide(i, hammer, j, anvil)
 			}
-			if rl.CheckCollisionRecs(hammer.GetHitbox(), anvil.GetHitbox()) {
-				if err := hammer.OnCollision(anvil); err != nil {
-					rl.TraceLog(rl.LogError, "error handling collision between %d %v and %d %v: %v", i, hammer, j, anvil, err)
-				}
-				if err := anvil.OnCollision(hammer); err != nil {
-					rl.TraceLog(rl.LogError, "error handling collision between %d %v and %d %v: %v", j, anvil, i, hammer, err)
-				}
+		}
+	}
+}
+
+// collisionCheckAll checks every pair of Collidable objects for collisions, the slow way.
+func (c *GameObjectCollection) collisionCheckAll() {
+	for i := len(c.objects) - 1; i >= 0; i-- {
+		hammer := c.getCollidable(i)
+		if hammer == nil {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if anvil := c.getCollidable(j); anvil != nil {
+				c.collide(i, hammer, j, anvil)
 			}
 		}
 	}
 }
 
+// collide calls both objects' OnCollision methods if their shapes overlap, either where they are or,
+// once the playfield's size is known, across an edge.
+func (c *GameObjectCollection) collide(i int, hammer Collidable, j int, anvil Collidable) {
+	if Overlaps(c.shapes[i], c.nearestImage(c.shapes[j], c.shapes[i])) {
+		// The hammer may have been destroyed by an earlier collision this check, and has blasted already
+		hammerAlive := c.objects[i].IsAlive()
+		if err := hammer.OnCollision(anvil); err != nil {
+			platform.Log(rl.LogError, "error handling collision between %d %v and %d %v: %v", i, hammer, j, anvil, err)
+		}
+		if err := anvil.OnCollision(hammer); err != nil {
+			platform.Log(rl.LogError, "error handling collision between %d %v and %d %v: %v", j, anvil, i, hammer, err)
+		}
+		if hammerAlive {
+			c.blastIfDestroyed(i)
+		}
+		c.blastIfDestroyed(j)
+	}
+}
+
+// blastIfDestroyed sets off the blast of the object if it's an Exploder and no longer alive.
+func (c *GameObjectCollection) blastIfDestroyed(idx int) {
+	if exploder, ok := c.objects[idx].(Exploder); ok && !c.objects[idx].IsAlive() {
+		c.explodeLocked(exploder.Blast())
+	}
+}
+
+// nearestImage returns the shape where it appears closest to the other shape, on a playfield that wraps
+// around: an object just past the right edge is also just before the left. Anything smaller than half
+// the playfield can only overlap the other at its nearest image, so that's the only one to check. A
+// moved image only lasts until the next call.
+func (c *GameObjectCollection) nearestImage(shape, other Shape) Shape {
+	if c.width == 0 || c.height == 0 {
+		return shape
+	}
+	offset := rl.Vector2{
+		X: nearestOffset(center(shape.Bounds()).X, center(other.Bounds()).X, c.width),
+		Y: nearestOffset(center(shape.Bounds()).Y, center(other.Bounds()).Y, c.height),
+	}
+	if offset == (rl.Vector2{}) {
+		return shape
+	}
+	return c.image.move(shape, offset)
+}
+
+// nearestOffset returns how far to move from, a whole size at a time, to be closest to to.
+func nearestOffset(from, to, size float32) float32 {
+	switch {
+	case from-to > size/2:
+		return -size
+	case to-from > size/2:
+		return size
+	default:
+		return 0
+	}
+}
+
+// center returns the middle of the rectangle.
+func center(rect rl.Rectangle) rl.Vector2 {
+	return rl.Vector2{X: rect.X + rect.Width/2, Y: rect.Y + rect.Height/2}
+}
+
 // getCollidable returns an interface on a game object that is both collidable and alive, or nil.
 func (c *GameObjectCollection) getCollidable(idx int) Collidable {
 	obj := c.objects[idx]
@@ -195,6 +371,12 @@ func (c *GameObjectCollection) getCollidable(idx int) Collidable {
 	}
 }
 
+// overlaps returns true if the two rectangles overlap; rectangles that only touch don't.
+func overlaps(r1, r2 rl.Rectangle) bool {
+	return r1.X < r2.X+r2.Width && r1.X+r1.Width > r2.X &&
+		r1.Y < r2.Y+r2.Height && r1.Y+r1.Height > r2.Y
+}
+
 // ExtendRectangle increases the size of a rectangle by a percentage, keeping the center point the same.
 func ExtendRectangle(rect rl.Rectangle, percentage float32) rl.Rectangle {
 	widthIncrease := rect.Width * percentage
@@ -1,6 +1,7 @@
 package gameobjects
 
 import (
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"testing"
 )
@@ -16,7 +17,7 @@ func (m *MockGameObject) Update(_ float32) error {
 	return nil
 }
 
-func (m *MockGameObject) Draw() error {
+func (m *MockGameObject) Draw(_ platform.Platform) error {
 	return nil
 }
 
@@ -36,6 +37,10 @@ func (m *MockGameObject) GetHitbox() rl.Rectangle {
 	return m.hitbox
 }
 
+func (m *MockGameObject) GetShape() Shape {
+	return RectangleShape(m.hitbox)
+}
+
 func TestGameObjectCollectionUpdate(t *testing.T) {
 	collection := NewGameObjectCollection()
 
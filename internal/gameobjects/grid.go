@@ -0,0 +1,95 @@
+package gameobjects
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
+	"slices"
+)
+
+// cellSize is the width and height of each grid cell in worldspace. It's a little smaller than a big
+// rock, so most objects cover between one and four cells.
+const cellSize = 64
+
+// grid is the broad phase of collision checking. It sorts objects into square cells across the
+// playfield, so each object need only be checked against the others in the cells it covers rather than
+// against everything. The playfield wraps around at its edges, and so do the cells: a hitbox hanging
+// over the right edge also covers the leftmost cells, as the object will be there in a moment.
+type grid struct {
+	cols, rows int
+	cells      [][]int // Index of every object covering each cell, in ascending order
+	seen       []int   // For each object, the last object it was a candidate for, so it's only listed once
+}
+
+// newGrid returns an empty grid covering a playfield of the given size.
+func newGrid(width, height float32) *grid {
+	cols := max(1, int(math.Ceil(float64(width)/cellSize)))
+	rows := max(1, int(math.Ceil(float64(height)/cellSize)))
+	return &grid{cols: cols, rows: rows, cells: make([][]int, cols*rows)}
+}
+
+// reset empties the grid, ready for up to n objects.
+func (g *grid) reset(n int) {
+	for i := range g.cells {
+		g.cells[i] = g.cells[i][:0]
+	}
+	g.seen = slices.Grow(g.seen[:0], n)[:n]
+	for i := range g.seen {
+		g.seen[i] = -1
+	}
+}
+
+// insert adds the object at the index to every cell its hitbox covers. Objects must be inserted in
+// ascending order of index.
+func (g *grid) insert(idx int, hitbox rl.Rectangle) {
+	g.forEachCell(hitbox, func(cell int) {
+		g.cells[cell] = append(g.cells[cell], idx)
+	})
+}
+
+// candidates appends to out every object before the one at the index that shares a cell with its
+// hitbox, in descending order of index, and returns the result. Every object whose hitbox overlaps
+// this one's is among them.
+func (g *grid) candidates(idx int, hitbox rl.Rectangle, out []int) []int {
+	start := len(out)
+	g.forEachCell(hitbox, func(cell int) {
+		for _, other := range g.cells[cell] {
+			if other >= idx {
+				break
+			}
+			if g.seen[other] != idx {
+				g.seen[other] = idx
+				out = append(out, other)
+			}
+		}
+	})
+	slices.SortFunc(out[start:], func(a, b int) int { return b - a })
+	return out
+}
+
+// forEachCell calls the action on every cell the hitbox covers, each once. A hitbox that isn't a number
+// overlaps nothing, so it covers no cells.
+func (g *grid) forEachCell(hitbox rl.Rectangle, action func(cell int)) {
+	firstCol, cols := span(hitbox.X, hitbox.Width, g.cols)
+	firstRow, rows := span(hitbox.Y, hitbox.Height, g.rows)
+	for r := range rows {
+		row := (firstRow + r) % g.rows
+		for c := range cols {
+			action(row*g.cols + (firstCol+c)%g.cols)
+		}
+	}
+}
+
+// span returns the first of n cells, wrapped around, that the stretch from start covers, and how many
+// cells it covers, which is never more than all n.
+func span(start, size float32, n int) (first, count int) {
+	if size < 0 {
+		start, size = start+size, -size
+	}
+	from := math.Floor(float64(start) / cellSize)
+	to := math.Floor(float64(start+size) / cellSize)
+	if math.IsNaN(from) || math.IsNaN(to) || math.IsInf(from, 0) || math.IsInf(to, 0) {
+		return 0, 0
+	}
+	first = int(math.Mod(math.Mod(from, float64(n))+float64(n), float64(n)))
+	return first, int(min(max(0, to-from+1), float64(n)))
+}
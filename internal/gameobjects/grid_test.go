@@ -0,0 +1,160 @@
+package gameobjects
+
+import (
+	"avoid_the_space_rocks/internal/platform"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// collisionLog records the order collisions are handled in.
+type collisionLog []string
+
+// fragileObject is a collidable that records its collisions, and that breaks, so stops being alive, on
+// its first if it's fragile. Breaking partway through a check changes which later pairs are handled,
+// so the order pairs are handled in shows.
+type fragileObject struct {
+	MockGameObject
+	fragile bool
+	log     *collisionLog
+}
+
+func (f *fragileObject) OnCollision(other Collidable) error {
+	*f.log = append(*f.log, f.name+">"+other.(*fragileObject).name)
+	if f.fragile {
+		f.alive = false
+	}
+	return nil
+}
+
+// notCollidable is an object that never collides with anything.
+type notCollidable struct {
+	alive bool
+}
+
+func (n *notCollidable) Update(_ float32) error       { return nil }
+func (n *notCollidable) Draw(platform.Platform) error { return nil }
+func (n *notCollidable) IsAlive() bool                { return n.alive }
+func (n *notCollidable) IsEnemy() bool                { return false }
+
+const (
+	benchWidth  = 1024
+	benchHeight = 768
+)
+
+// randomObjects returns count objects scattered across the playfield, some hanging over its edges,
+// some dead, some fragile, and some not collidable at all, the same ones every time for a seed.
+func randomObjects(seed uint64, count int, log *collisionLog) []GameObject {
+	r := rand.New(rand.NewPCG(seed, 0))
+	objects := make([]GameObject, count)
+	for i := range objects {
+		if r.IntN(20) == 0 {
+			objects[i] = &notCollidable{alive: true}
+			continue
+		}
+		size := 4 + r.Float32()*120
+		x := r.Float32()*(benchWidth+2*size) - 2*size
+		y := r.Float32()*(benchHeight+2*size) - 2*size
+		objects[i] = &fragileObject{
+			MockGameObject: MockGameObject{
+				name:   fmt.Sprint(i),
+				alive:  r.IntN(10) != 0,
+				hitbox: rl.NewRectangle(x, y, size, size*(0.5+r.Float32())),
+			},
+			fragile: r.IntN(3) == 0,
+			log:     log,
+		}
+	}
+	return objects
+}
+
+// collectionOf returns a collection holding the objects on a playfield that wraps around, ready for
+// collision checking, using the grid if asked to and otherwise checking every pair.
+func collectionOf(objects []GameObject, useGrid bool) *GameObjectCollection {
+	collection := NewGameObjectCollection()
+	collection.Wrap(benchWidth, benchHeight)
+	if !useGrid {
+		collection.grid = nil
+	}
+	collection.objects = append(collection.objects, objects...)
+	return &collection
+}
+
+func TestCollisionCheck_GridMatchesEveryPair(t *testing.T) {
+	for seed := range uint64(20) {
+		var all, grid collisionLog
+		collectionOf(randomObjects(seed, 300, &all), false).collisionCheck()
+		collectionOf(randomObjects(seed, 300, &grid), true).collisionCheck()
+		if len(all) == 0 {
+			t.Fatalf("Expected some collisions for seed %d", seed)
+		}
+		if !slices.Equal(all, grid) {
+			t.Errorf("Expected the grid to handle the same collisions in the same order for seed %d;\nevery pair: %v\ngrid:       %v",
+				seed, all, grid)
+		}
+	}
+}
+
+func TestCollisionCheck_WrapsAroundEdges(t *testing.T) {
+	var log collisionLog
+	objects := []GameObject{
+		&fragileObject{MockGameObject: MockGameObject{name: "left", alive: true, hitbox: rl.NewRectangle(-10, 100, 20, 20)}, log: &log},
+		&fragileObject{MockGameObject: MockGameObject{name: "right", alive: true, hitbox: rl.NewRectangle(1000, 100, 30, 20)}, log: &log},
+		&fragileObject{MockGameObject: MockGameObject{name: "huge", alive: true, hitbox: rl.NewRectangle(-5000, -5000, 1e4, 1e4)}, log: &log},
+	}
+	g := newGrid(benchWidth, benchHeight)
+	g.reset(len(objects))
+	for i, obj := range objects {
+		g.insert(i, obj.(Collidable).GetHitbox())
+	}
+	// Across the edge from each other, the left and right objects share the cells at both edges
+	if candidates := g.candidates(1, objects[1].(Collidable).GetHitbox(), nil); !slices.Equal(candidates, []int{0}) {
+		t.Errorf("Expected the object over the right edge to be checked against the one over the left, got %v", candidates)
+	}
+	// Something bigger than the playfield covers every cell once
+	if candidates := g.candidates(2, objects[2].(Collidable).GetHitbox(), nil); !slices.Equal(candidates, []int{1, 0}) {
+		t.Errorf("Expected the huge object to be checked against both others, got %v", candidates)
+	}
+
+	// Everything collides with the huge one, and the rectangles either side collide across the edge
+	collectionOf(objects, true).collisionCheck()
+	if !slices.Equal(log, collisionLog{"huge>right", "right>huge", "huge>left", "left>huge", "right>left", "left>right"}) {
+		t.Errorf("Expected collisions with the huge object and across the edge, got %v", log)
+	}
+}
+
+func BenchmarkCollisionCheck(b *testing.B) {
+	for _, count := range []int{100, 1_000, 10_000} {
+		for _, useGrid := range []bool{false, true} {
+			name := fmt.Sprintf("%d objects/every pair", count)
+			if useGrid {
+				name = fmt.Sprintf("%d objects/grid", count)
+			}
+			b.Run(name, func(b *testing.B) {
+				// Small objects spread across the playfield, like shrapnel and bullets; nothing breaks, so
+				// every iteration does the same work
+				r := rand.New(rand.NewPCG(uint64(count), 0))
+				var log collisionLog
+				objects := make([]GameObject, count)
+				for i := range objects {
+					objects[i] = &fragileObject{
+						MockGameObject: MockGameObject{
+							alive:  true,
+							hitbox: rl.NewRectangle(r.Float32()*benchWidth, r.Float32()*benchHeight, 8, 8),
+						},
+						log: &log,
+					}
+				}
+				collection := collectionOf(objects, useGrid)
+				b.ResetTimer()
+				for range b.N {
+					log = log[:0]
+					collection.collisionCheck()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package gameobjects
+
+import (
+	"avoid_the_space_rocks/internal/platform"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// ParticleStyle is how the particles of an effect are sent out, look, and move over their lives. Each
+// particle's colour, alpha, and scale go smoothly from the start values to the end ones as it ages, so a
+// spark can glow, shrink, and fade away. Styles are shared by every particle sent out in them, so don't
+// change one while its particles are about.
+type ParticleStyle struct {
+	Sheet      *SpriteSheet // Drawn tinted by the colour, so a white sprite takes on the colour exactly
+	StartColor rl.Color
+	EndColor   rl.Color
+	StartScale float32
+	EndScale   float32
+
+	MinLifetime float32 // Seconds
+	MaxLifetime float32
+	MinSpeed    float32 // Units per second out from the emitter; negative speeds head back in
+	MaxSpeed    float32
+	Spread      float32 // Radians either side of the emitter's direction particles go in; Pi for all round
+	StartRadius float32 // How far from the emitter particles start, out along the way they go
+	Drag        float32 // Rate the velocity decays at per second, as for a Rigidbody
+}
+
+// particle is a single speck of an effect. Particles are plain values kept together in one slice, so
+// thousands of them cost no more garbage than the slice.
+type particle struct {
+	style    *ParticleStyle
+	position rl.Vector2
+	velocity rl.Vector2
+	age      float32 // Seconds
+	lifetime float32
+}
+
+// ParticleSystem holds the particles of every effect on a playfield, moving them and drawing them all
+// in one go. Particles are only for show: they're not game objects, so they never collide with anything
+// and aren't saved, and they pick their directions and lifetimes from their own random source rather
+// than the game's, so adding effects never changes how a game plays out.
+type ParticleSystem struct {
+	Off           bool // Nobody sees this playfield, so particles sent out are dropped straight away
+	particles     []particle
+	width, height float32 // Size of the playfield particles wrap around, once known
+	random        *rand.Rand
+	lock          sync.RWMutex
+}
+
+// Emitter sends out particles in a style continuously, at a steady rate however long or short the
+// steps it's run for. Something trailing particles keeps an emitter and runs it every update it emits.
+type Emitter struct {
+	Style *ParticleStyle
+	Rate  float32 // Particles per second
+	owed  float32 // Fraction of a particle left over from the steps so far
+}
+
+// NewParticleSystem returns an empty particle system with room for a busy playfield's worth of
+// particles. The zero value works too, growing as it needs to.
+func NewParticleSystem() ParticleSystem {
+	return ParticleSystem{particles: make([]particle, 0, 500)}
+}
+
+// Wrap tells the particle system the size of the playfield, so particles going past an edge come back
+// in at the opposite one like everything else.
+func (p *ParticleSystem) Wrap(width, height float32) {
+	p.width, p.height = width, height
+}
+
+// Burst sends out count particles in the style all at once from the position, heading in the direction
+// give or take the style's spread, on top of the velocity of whatever sent them.
+func (p *ParticleSystem) Burst(style *ParticleStyle, count int, position, direction, velocity rl.Vector2) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.Off {
+		return
+	}
+
+	direction = rl.Vector2Normalize(direction)
+	if direction == (rl.Vector2{}) {
+		direction = rl.Vector2{X: 1}
+	}
+	for range count {
+		heading := rl.Vector2Rotate(direction, p.between(-style.Spread, style.Spread))
+		p.particles = append(p.particles, particle{
+			style:    style,
+			position: rl.Vector2Add(position, rl.Vector2Scale(heading, style.StartRadius)),
+			velocity: rl.Vector2Add(velocity, rl.Vector2Scale(heading, p.between(style.MinSpeed, style.MaxSpeed))),
+			lifetime: p.between(style.MinLifetime, style.MaxLifetime),
+		})
+	}
+}
+
+// between returns a random number from lo up to hi.
+func (p *ParticleSystem) between(lo, hi float32) float32 {
+	if p.random == nil {
+		p.random = rand.New(rand.NewPCG(0, 0))
+	}
+	return lerp(lo, hi, p.random.Float32())
+}
+
+// Update ages the particles by delta seconds, moving them and dropping those that have lived out
+// their lifetimes.
+func (p *ParticleSystem) Update(delta float32) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	live := p.particles[:0]
+	for _, part := range p.particles {
+		part.age += delta
+		if part.age >= part.lifetime {
+			continue
+		}
+		if part.style.Drag > 0 {
+			part.velocity = rl.Vector2Scale(part.velocity, float32(math.Exp(float64(-part.style.Drag*delta))))
+		}
+		part.position = rl.Vector2Add(part.position, rl.Vector2Scale(part.velocity, delta))
+		part.position = rl.Vector2{X: WrapAround(part.position.X, p.width), Y: WrapAround(part.position.Y, p.height)}
+		live = append(live, part)
+	}
+	p.particles = live
+}
+
+// Draw draws every particle on pl, its colour and size part way from its style's start values to the end
+// ones as far as it is through its life. Effects mostly share a sprite sheet, and drawing from the same
+// texture one after another lets the renderer send them to the screen as one batch.
+func (p *ParticleSystem) Draw(pl platform.Platform) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for idx, part := range p.particles {
+		style := part.style
+		t := part.age / part.lifetime
+		tint := lerpColor(style.StartColor, style.EndColor, t)
+		scale := lerp(style.StartScale, style.EndScale, t)
+		if err := style.Sheet.DrawTinted(pl, 0, 0, part.position, rl.Vector2{X: 1}, scale, tint); err != nil {
+			// Every particle after would likely fail the same way, so say so once
+			platform.Log(rl.LogError, "error drawing particle %d: %v", idx, err)
+			return
+		}
+	}
+}
+
+// lerp returns the value t of the way from a to b.
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+// lerpColor returns the colour t of the way from a to b, alpha and all.
+func lerpColor(a, b rl.Color, t float32) rl.Color {
+	channel := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(lerp(float32(a), float32(b), t))))
+	}
+	return rl.Color{R: channel(a.R, b.R), G: channel(a.G, b.G), B: channel(a.B, b.B), A: channel(a.A, b.A)}
+}
+
+// Len returns how many particles there are.
+func (p *ParticleSystem) Len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.particles)
+}
+
+// Emit runs the emitter for delta seconds, sending the particles due in that time into the system from
+// the position as Burst does.
+func (e *Emitter) Emit(system *ParticleSystem, delta float32, position, direction, velocity rl.Vector2) {
+	e.owed += e.Rate * delta
+	count := int(e.owed)
+	e.owed -= float32(count)
+	if count > 0 {
+		system.Burst(e.Style, count, position, direction, velocity)
+	}
+}
+
+// Stop forgets any part of a particle left over, so the emitter starts afresh next time it's run.
+func (e *Emitter) Stop() {
+	e.owed = 0
+}
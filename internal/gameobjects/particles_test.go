@@ -0,0 +1,127 @@
+package gameobjects
+
+import (
+	"avoid_the_space_rocks/internal/platform"
+	"math"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// testStyle sends particles out to the right, within a quarter turn either way, for a second or two.
+var testStyle = &ParticleStyle{
+	Sheet:       LoadSpriteSheet("bullet.png", 1, 1),
+	StartColor:  rl.NewColor(0, 0, 0, 255),
+	EndColor:    rl.NewColor(200, 100, 0, 0),
+	StartScale:  1,
+	EndScale:    3,
+	MinLifetime: 1,
+	MaxLifetime: 2,
+	MinSpeed:    50,
+	MaxSpeed:    100,
+	Spread:      math.Pi / 4,
+}
+
+func TestParticleSystem_Burst(t *testing.T) {
+	var system ParticleSystem
+	system.Burst(testStyle, 100, rl.Vector2{X: 100, Y: 100}, rl.Vector2{X: 2}, rl.Vector2{Y: 10})
+	if system.Len() != 100 {
+		t.Fatalf("Expected 100 particles, got %d", system.Len())
+	}
+	for _, part := range system.particles {
+		own := rl.Vector2Subtract(part.velocity, rl.Vector2{Y: 10})
+		speed, angle := rl.Vector2Length(own), math.Atan2(float64(own.Y), float64(own.X))
+		if speed < 49.9 || speed > 100.1 || math.Abs(angle) > math.Pi/4+0.001 {
+			t.Errorf("Expected particles to go right at 50 to 100 on top of the emitter's velocity, got %v", part.velocity)
+		}
+		if part.lifetime < 1 || part.lifetime > 2 || part.position != (rl.Vector2{X: 100, Y: 100}) {
+			t.Errorf("Expected particles to start at the emitter and last one to two seconds, got %+v", part)
+		}
+	}
+}
+
+func TestParticleSystem_Update(t *testing.T) {
+	var system ParticleSystem
+	system.Wrap(800, 600)
+	dragged := *testStyle
+	dragged.Drag = 1
+	system.Burst(testStyle, 10, rl.Vector2{X: 790, Y: 300}, rl.Vector2{X: 1}, rl.Vector2{})
+	system.Burst(&dragged, 10, rl.Vector2{X: 400, Y: 300}, rl.Vector2{X: 1}, rl.Vector2{})
+	speeds := make([]float32, system.Len())
+	for i, part := range system.particles {
+		speeds[i] = rl.Vector2Length(part.velocity)
+	}
+
+	system.Update(0.5)
+	for i, part := range system.particles {
+		if part.position.X < 0 || part.position.X > 800 {
+			t.Errorf("Expected particles to wrap around the playfield, got one at %v", part.position)
+		}
+		if speed := rl.Vector2Length(part.velocity); (part.style == &dragged) != (speed < speeds[i]) {
+			t.Errorf("Expected only particles with drag to slow down, got %v from %v", speed, speeds[i])
+		}
+	}
+
+	// Every particle has lived out its lifetime after two seconds
+	system.Update(0.75)
+	system.Update(0.75)
+	if system.Len() != 0 {
+		t.Errorf("Expected every particle gone after its lifetime, got %d left", system.Len())
+	}
+}
+
+func TestParticleSystem_Converge(t *testing.T) {
+	var system ParticleSystem
+	converge := &ParticleStyle{Sheet: testStyle.Sheet, MinLifetime: 1, MaxLifetime: 1, MinSpeed: -200, MaxSpeed: -200, Spread: math.Pi, StartRadius: 200}
+	system.Burst(converge, 20, rl.Vector2{X: 400, Y: 300}, rl.Vector2{X: 1}, rl.Vector2{})
+	for _, part := range system.particles {
+		if d := rl.Vector2Distance(part.position, rl.Vector2{X: 400, Y: 300}); math.Abs(float64(d-200)) > 0.01 {
+			t.Errorf("Expected particles to start in a ring 200 out, got one %v out", d)
+		}
+	}
+	for range 99 {
+		system.Update(0.01)
+	}
+	if system.Len() != 20 {
+		t.Fatalf("Expected the particles to live a whole second, got %d left", system.Len())
+	}
+	for _, part := range system.particles {
+		if d := rl.Vector2Distance(part.position, rl.Vector2{X: 400, Y: 300}); d > 2.01 {
+			t.Errorf("Expected particles to reach the middle as they die, got one %v out", d)
+		}
+	}
+}
+
+func TestParticleSystem_Draw(t *testing.T) {
+	t.Parallel()
+	recorder := &drawRecorder{Headless: platform.NewHeadless()}
+
+	var system ParticleSystem
+	system.Burst(testStyle, 1, rl.Vector2{X: 100, Y: 100}, rl.Vector2{X: 1}, rl.Vector2{})
+	system.particles[0].lifetime = 2
+	system.Update(1)
+	system.Draw(recorder)
+
+	// Halfway through its life, it's halfway from its start colour and size to its end ones
+	size := testStyle.Sheet.GetSize()
+	if len(recorder.tints) != 1 || recorder.tints[0] != rl.NewColor(100, 50, 0, 128) {
+		t.Errorf("Expected the particle tinted halfway between its colours, got %v", recorder.tints)
+	}
+	if len(recorder.destinations) != 1 || recorder.destinations[0].Width != 2*size.X {
+		t.Errorf("Expected the particle drawn at twice the sprite's size, got %v", recorder.destinations)
+	}
+}
+
+func TestEmitter_Emit(t *testing.T) {
+	// However the time's divided up, a second at 30 particles a second is 30 particles
+	for _, steps := range []int{1, 7, 60, 1000} {
+		var system ParticleSystem
+		emitter := Emitter{Style: testStyle, Rate: 30}
+		for range steps {
+			emitter.Emit(&system, 1/float32(steps), rl.Vector2{}, rl.Vector2{X: 1}, rl.Vector2{})
+		}
+		if n := system.Len(); n < 29 || n > 30 {
+			t.Errorf("Expected 30 particles in a second of %d steps, got %d", steps, n)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package gameobjects
+
+import (
+	"sync"
+)
+
+// Recyclable is a game object that came from a Pool and goes back to it once the collection is done
+// with it. The collection recycles an object when it removes it for being dead, which is the first
+// moment nothing in the collection refers to it any more; an object that dies while still waiting in
+// the newly added set joins the collection first and is recycled at the following update, so it's
+// never handed out again while it's pending. Nothing outside the collection should hold on to a
+// recyclable object past the update it dies in.
+type Recyclable interface {
+	Recycle()
+}
+
+// Pool keeps objects of one type that have been recycled, so new ones can reuse them rather than be
+// allocated. It suits objects that come and go many times a second, like bullets and shrapnel, which
+// would otherwise leave a steady stream of garbage behind. The zero value is an empty pool ready to use.
+type Pool[T any] struct {
+	free []*T
+	lock sync.Mutex
+}
+
+// Get returns an object set to its zero value, reusing a recycled one if there is one.
+func (p *Pool[T]) Get() *T {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.free) == 0 {
+		return new(T)
+	}
+	obj := p.free[len(p.free)-1]
+	p.free[len(p.free)-1] = nil
+	p.free = p.free[:len(p.free)-1]
+	return obj
+}
+
+// Put gives the object back to the pool to be reused. It's reset to its zero value straight away, so
+// it holds on to nothing it pointed at and anything still using it by mistake finds it dead.
+func (p *Pool[T]) Put(obj *T) {
+	var zero T
+	*obj = zero
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.free = append(p.free, obj)
+}
+
+// Len returns how many recycled objects are waiting to be reused.
+func (p *Pool[T]) Len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.free)
+}
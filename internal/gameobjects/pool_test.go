@@ -0,0 +1,67 @@
+package gameobjects
+
+import (
+	"testing"
+)
+
+// pooledObject is a short-lived object that goes back to its pool when the collection is done with it.
+type pooledObject struct {
+	MockGameObject
+	pool     *Pool[pooledObject]
+	recycled *int
+}
+
+func (p *pooledObject) Recycle() {
+	*p.recycled++
+	p.pool.Put(p)
+}
+
+func TestPool(t *testing.T) {
+	var pool Pool[MockGameObject]
+	first := pool.Get()
+	if *first != (MockGameObject{}) {
+		t.Errorf("Expected a new object to be zeroed, got %v", first)
+	}
+	first.name, first.alive = "bullet", true
+
+	pool.Put(first)
+	if *first != (MockGameObject{}) || pool.Len() != 1 {
+		t.Errorf("Expected the object put back to be zeroed and kept, got %v with %d kept", first, pool.Len())
+	}
+	if again := pool.Get(); again != first || pool.Len() != 0 {
+		t.Errorf("Expected the object put back to be reused")
+	}
+	if other := pool.Get(); other == first {
+		t.Errorf("Expected a new object once the pool is empty")
+	}
+}
+
+func TestGameObjectCollection_RecyclesOnceRemoved(t *testing.T) {
+	var pool Pool[pooledObject]
+	recycled := 0
+	get := func(alive bool) *pooledObject {
+		obj := pool.Get()
+		*obj = pooledObject{MockGameObject: MockGameObject{alive: alive}, pool: &pool, recycled: &recycled}
+		return obj
+	}
+	collection := NewGameObjectCollection()
+	living, stillborn := get(true), get(false)
+	collection.Add(living)
+	collection.Add(stillborn)
+
+	// Dead before it joined, so it's only recycled once it's joined and been removed again
+	collection.Update(0.1)
+	if recycled != 0 || pool.Len() != 0 {
+		t.Errorf("Expected nothing recycled while waiting to join, got %d", recycled)
+	}
+	collection.Update(0.1)
+	if recycled != 1 || pool.Len() != 1 {
+		t.Errorf("Expected the dead object recycled after joining, got %d recycled and %d pooled", recycled, pool.Len())
+	}
+
+	living.alive = false
+	collection.Update(0.1)
+	if recycled != 2 || pool.Len() != 2 || len(collection.objects) != 0 {
+		t.Errorf("Expected the object recycled once it died, got %d recycled, %d pooled, and %d left", recycled, pool.Len(), len(collection.objects))
+	}
+}
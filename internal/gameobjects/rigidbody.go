@@ -3,25 +3,86 @@ package gameobjects
 import (
 	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
 )
 
 type Rigidbody struct {
 	Transform
-	Acceleration rl.Vector2
-	Velocity     rl.Vector2
-	MaxVelocity  float32 // The maximum magnitude of the velocity vector
+	Acceleration rl.Vector2 // Change in velocity per second
+	Velocity     rl.Vector2 // Change in position per second
+	MaxVelocity  float32    // The maximum magnitude of the velocity vector
+	Drag         float32    // Rate the velocity decays at per second, applied continuously: 1 loses about 63% a second
+	LastMove     rl.Vector2 // How far the last step of physics moved the object
+}
+
+// Pushable is an object with a body that impulses can push around, heavier ones less than lighter.
+// Masses are relative to a tiny rock's, which is 1.
+type Pushable interface {
+	Body() *Rigidbody
+	Mass() float32
 }
 
 func (rb *Rigidbody) String() string {
 	return fmt.Sprintf("vel (%f,%f)", rb.Velocity.X, rb.Velocity.Y)
 }
 
-// ApplyPhysics applies acceleration to the velocity and then moves the object
+// Body returns the rigidbody itself, so anything embedding one need only add Mass to be Pushable.
+func (rb *Rigidbody) Body() *Rigidbody {
+	return rb
+}
+
+// ApplyImpulse changes the body's velocity all at once, as a blow or a blast would: by the impulse,
+// which is mass times velocity, divided by the body's mass. Something without mass can't be pushed.
+func (rb *Rigidbody) ApplyImpulse(impulse rl.Vector2, mass float32) {
+	if mass <= 0 {
+		return
+	}
+	rb.Velocity = rl.Vector2Add(rb.Velocity, rl.Vector2Scale(impulse, 1/mass))
+}
+
+// Push applies the impulse to the object's body.
+func Push(p Pushable, impulse rl.Vector2) {
+	p.Body().ApplyImpulse(impulse, p.Mass())
+}
+
+// ApplyPhysics advances the object by delta seconds. It uses semi-implicit Euler integration:
+// acceleration and drag update the velocity first, then the new velocity moves the object. That
+// keeps the motion stable and close to the same whatever the step size.
 func (rb *Rigidbody) ApplyPhysics(delta float32) {
-	rb.Velocity = rl.Vector2Add(rb.Velocity, rb.Acceleration)
+	rb.Velocity = rl.Vector2Add(rb.Velocity, rl.Vector2Scale(rb.Acceleration, delta))
+	if rb.Drag > 0 {
+		// Exponential decay so that two half steps lose exactly as much as one full step
+		rb.Velocity = rl.Vector2Scale(rb.Velocity, float32(math.Exp(float64(-rb.Drag*delta))))
+	}
 	if rb.MaxVelocity > 0 {
 		rb.Velocity = rl.Vector2ClampValue(rb.Velocity, 0, rb.MaxVelocity)
 	}
-	move := rl.Vector2Scale(rb.Velocity, delta)
-	rb.Position = rl.Vector2Add(rb.Position, move)
+	rb.LastMove = rl.Vector2Scale(rb.Velocity, delta)
+	rb.Position = rl.Vector2Add(rb.Position, rb.LastMove)
+}
+
+// Bounce changes the velocities of two bodies of the given masses that have run into each other as a
+// perfectly elastic collision would, so both momentum and energy are kept. The normal points from a
+// towards b, and only the parts of their velocities along it change. Bodies already moving apart are
+// left alone, so a pair still overlapping after bouncing doesn't bounce straight back together. Returns
+// true if they bounced.
+func Bounce(a *Rigidbody, massA float32, b *Rigidbody, massB float32, normal rl.Vector2) bool {
+	normal = rl.Vector2Normalize(normal)
+	closing := rl.Vector2DotProduct(rl.Vector2Subtract(a.Velocity, b.Velocity), normal)
+	if closing <= 0 || massA <= 0 || massB <= 0 {
+		return false
+	}
+	exchange := 2 * closing / (massA + massB)
+	a.Velocity = rl.Vector2Subtract(a.Velocity, rl.Vector2Scale(normal, exchange*massB))
+	b.Velocity = rl.Vector2Add(b.Velocity, rl.Vector2Scale(normal, exchange*massA))
+	return true
+}
+
+// Swept returns the shape a circle of the radius around the object covered during the last step of
+// physics, from where it was to where it is now. Something small and fast can move further than its
+// own size in a step, jumping clean over whatever's in its way; colliding with its sweep instead means
+// it hits everything it passed through. The sweep starts from before any wraparound, so it hangs over
+// the edge the object crossed.
+func (rb *Rigidbody) Swept(radius float32) Capsule {
+	return Capsule{A: rl.Vector2Subtract(rb.Position, rb.LastMove), B: rb.Position, Radius: radius}
 }
@@ -1,7 +1,9 @@
 package gameobjects
 
 import (
+	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
 	"testing"
 )
 
@@ -73,6 +75,18 @@ func TestRigidbody_ApplyPhysics(t *testing.T) {
 			wantPos: rl.Vector2{X: 0.5, Y: 0}, // Position reflects half of velocity
 			wantVel: rl.Vector2{X: 1.0, Y: 0}, // Velocity capped
 		},
+		{
+			name: "acceleration scales with delta",
+			rb: Rigidbody{
+				Transform:    Transform{Position: rl.Vector2{X: 0, Y: 0}},
+				Velocity:     rl.Vector2{X: 0, Y: 0},
+				Acceleration: rl.Vector2{X: 0, Y: 4},
+				MaxVelocity:  0,
+			},
+			delta:   0.5,
+			wantPos: rl.Vector2{X: 0, Y: 1},
+			wantVel: rl.Vector2{X: 0, Y: 2},
+		},
 	}
 
 	for _, tt := range tests {
@@ -89,3 +103,125 @@ func TestRigidbody_ApplyPhysics(t *testing.T) {
 		})
 	}
 }
+
+func TestRigidbody_ApplyPhysicsDrag(t *testing.T) {
+	rb := Rigidbody{
+		Velocity: rl.Vector2{X: 100, Y: 0},
+		Drag:     0.5,
+	}
+	rb.ApplyPhysics(2.0)
+
+	want := float32(100 * math.Exp(-1))
+	if math.Abs(float64(rb.Velocity.X-want)) > 0.001 {
+		t.Errorf("Velocity = %v, want %v", rb.Velocity.X, want)
+	}
+}
+
+func TestRigidbody_Swept(t *testing.T) {
+	rb := Rigidbody{
+		Transform: Transform{Position: rl.Vector2{X: 10, Y: 20}},
+		Velocity:  rl.Vector2{X: 300, Y: -100},
+	}
+	if swept := rb.Swept(2); swept != (Capsule{A: rb.Position, B: rb.Position, Radius: 2}) {
+		t.Errorf("Expected a body that hasn't moved to sweep nowhere, got %v", swept)
+	}
+	rb.ApplyPhysics(0.1)
+	want := Capsule{A: rl.Vector2{X: 10, Y: 20}, B: rl.Vector2{X: 40, Y: 10}, Radius: 2}
+	if swept := rb.Swept(2); swept != want {
+		t.Errorf("Swept = %v, want %v", swept, want)
+	}
+}
+
+// simulateShip flies a ship-like body for a total of two seconds at the given frame rate: one
+// second of thrust and then one second of coasting under drag. Returns where it ends up.
+func simulateShip(fps int) Rigidbody {
+	rb := Rigidbody{
+		Transform:   Transform{Rotation: rl.Vector2{X: 1, Y: 0}},
+		MaxVelocity: 400,
+	}
+	delta := float32(1.0 / float64(fps))
+	for range fps {
+		rb.Acceleration = rl.Vector2Scale(rb.Rotation, 3000)
+		rb.Drag = 0
+		rb.ApplyPhysics(delta)
+	}
+	for range fps {
+		rb.Acceleration = rl.Vector2{}
+		rb.Drag = 1.0
+		rb.ApplyPhysics(delta)
+	}
+	return rb
+}
+
+func TestRigidbody_ApplyPhysicsFrameRateIndependent(t *testing.T) {
+	reference := simulateShip(60)
+	for _, fps := range []int{30, 60, 144} {
+		t.Run(fmt.Sprintf("%d fps", fps), func(t *testing.T) {
+			rb := simulateShip(fps)
+
+			// Velocity integrates exactly; position may drift by a couple of percent at 30 fps
+			if diff := math.Abs(float64(rb.Velocity.X - reference.Velocity.X)); diff > 0.01 {
+				t.Errorf("Velocity = %v, want %v", rb.Velocity.X, reference.Velocity.X)
+			}
+			if diff := math.Abs(float64(rb.Position.X-reference.Position.X)) / float64(reference.Position.X); diff > 0.02 {
+				t.Errorf("Position = %v, want within 2%% of %v", rb.Position.X, reference.Position.X)
+			}
+			if rb.Position.Y != 0 {
+				t.Errorf("Position drifted off course to %v", rb.Position)
+			}
+		})
+	}
+}
+
+func TestBounce(t *testing.T) {
+	t.Run("equal masses head on swap velocities", func(t *testing.T) {
+		a, b := Rigidbody{Velocity: rl.Vector2{X: 10}}, Rigidbody{Velocity: rl.Vector2{X: -30}}
+		if !Bounce(&a, 2, &b, 2, rl.Vector2{X: 5}) {
+			t.Fatalf("Expected bodies heading for each other to bounce")
+		}
+		if a.Velocity != (rl.Vector2{X: -30}) || b.Velocity != (rl.Vector2{X: 10}) {
+			t.Errorf("Expected the velocities swapped, got %v and %v", a.Velocity, b.Velocity)
+		}
+	})
+	t.Run("glancing blow keeps momentum and energy", func(t *testing.T) {
+		a, b := Rigidbody{Velocity: rl.Vector2{X: 50, Y: 10}}, Rigidbody{Velocity: rl.Vector2{X: -5, Y: 20}}
+		const massA, massB = 3, 7
+		momentum := func() rl.Vector2 {
+			return rl.Vector2Add(rl.Vector2Scale(a.Velocity, massA), rl.Vector2Scale(b.Velocity, massB))
+		}
+		energy := func() float32 {
+			return massA*rl.Vector2LengthSqr(a.Velocity)/2 + massB*rl.Vector2LengthSqr(b.Velocity)/2
+		}
+		beforeMomentum, beforeEnergy := momentum(), energy()
+		if !Bounce(&a, massA, &b, massB, rl.Vector2{X: 1, Y: 1}) {
+			t.Fatalf("Expected bodies heading for each other to bounce")
+		}
+		if after := momentum(); rl.Vector2Distance(after, beforeMomentum) > 0.001 {
+			t.Errorf("Momentum = %v, want %v", after, beforeMomentum)
+		}
+		if after := energy(); math.Abs(float64(after-beforeEnergy)) > 0.01 {
+			t.Errorf("Energy = %v, want %v", after, beforeEnergy)
+		}
+		if closing := rl.Vector2DotProduct(rl.Vector2Subtract(a.Velocity, b.Velocity), rl.Vector2{X: 1, Y: 1}); closing >= 0 {
+			t.Errorf("Expected the bodies to be moving apart after bouncing, closing at %v", closing)
+		}
+	})
+	t.Run("bodies moving apart don't bounce", func(t *testing.T) {
+		a, b := Rigidbody{Velocity: rl.Vector2{X: -10}}, Rigidbody{Velocity: rl.Vector2{X: 10}}
+		if Bounce(&a, 1, &b, 1, rl.Vector2{X: 1}) || a.Velocity != (rl.Vector2{X: -10}) || b.Velocity != (rl.Vector2{X: 10}) {
+			t.Errorf("Expected bodies moving apart to be left alone, got %v and %v", a.Velocity, b.Velocity)
+		}
+	})
+}
+
+func TestRigidbody_ApplyImpulse(t *testing.T) {
+	rb := Rigidbody{Velocity: rl.Vector2{X: 10}}
+	rb.ApplyImpulse(rl.Vector2{X: 40, Y: -20}, 4)
+	if rb.Velocity != (rl.Vector2{X: 20, Y: -5}) {
+		t.Errorf("Velocity = %v, want the impulse over the mass added", rb.Velocity)
+	}
+	rb.ApplyImpulse(rl.Vector2{X: 40}, 0)
+	if rb.Velocity != (rl.Vector2{X: 20, Y: -5}) {
+		t.Errorf("Expected something without mass not to be pushed, got %v", rb.Velocity)
+	}
+}
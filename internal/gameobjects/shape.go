@@ -0,0 +1,263 @@
+package gameobjects
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
+)
+
+// Shape is the outline of a collidable object in worldspace, used for the narrow phase of collision
+// checking once the broad phase has found two objects close enough to perhaps be touching. Shapes work
+// by value or by pointer; an object that keeps its shape and hands out a pointer to it saves allocating
+// a new one every time its shape is asked for.
+type Shape interface {
+	// Bounds returns the smallest rectangle that holds the whole shape.
+	Bounds() rl.Rectangle
+	// Moved returns the same shape moved by the offset.
+	Moved(by rl.Vector2) Shape
+}
+
+// Circle is a round shape.
+type Circle struct {
+	Center rl.Vector2
+	Radius float32
+}
+
+// Capsule is every point within Radius of the line from A to B: a circle swept along a line.
+type Capsule struct {
+	A, B   rl.Vector2
+	Radius float32
+}
+
+// Polygon is a convex shape with its corners in order, going either way round. Anything with fewer
+// than three corners has no inside, so overlaps nothing.
+type Polygon struct {
+	Points []rl.Vector2
+}
+
+var (
+	_ Shape = Circle{}
+	_ Shape = Capsule{}
+	_ Shape = Polygon{}
+)
+
+func (c Circle) Bounds() rl.Rectangle {
+	return rl.Rectangle{X: c.Center.X - c.Radius, Y: c.Center.Y - c.Radius, Width: 2 * c.Radius, Height: 2 * c.Radius}
+}
+
+func (c Circle) Moved(by rl.Vector2) Shape {
+	return Circle{Center: rl.Vector2Add(c.Center, by), Radius: c.Radius}
+}
+
+func (c Capsule) Bounds() rl.Rectangle {
+	x, y := min(c.A.X, c.B.X)-c.Radius, min(c.A.Y, c.B.Y)-c.Radius
+	return rl.Rectangle{X: x, Y: y, Width: max(c.A.X, c.B.X) + c.Radius - x, Height: max(c.A.Y, c.B.Y) + c.Radius - y}
+}
+
+func (c Capsule) Moved(by rl.Vector2) Shape {
+	return Capsule{A: rl.Vector2Add(c.A, by), B: rl.Vector2Add(c.B, by), Radius: c.Radius}
+}
+
+func (p Polygon) Bounds() rl.Rectangle {
+	if len(p.Points) == 0 {
+		return rl.Rectangle{}
+	}
+	lo, hi := p.Points[0], p.Points[0]
+	for _, point := range p.Points[1:] {
+		lo = rl.Vector2{X: min(lo.X, point.X), Y: min(lo.Y, point.Y)}
+		hi = rl.Vector2{X: max(hi.X, point.X), Y: max(hi.Y, point.Y)}
+	}
+	return rl.Rectangle{X: lo.X, Y: lo.Y, Width: hi.X - lo.X, Height: hi.Y - lo.Y}
+}
+
+func (p Polygon) Moved(by rl.Vector2) Shape {
+	points := make([]rl.Vector2, len(p.Points))
+	for i, point := range p.Points {
+		points[i] = rl.Vector2Add(point, by)
+	}
+	return Polygon{Points: points}
+}
+
+// RectangleShape returns the rectangle as a polygon, for objects whose hitbox is all there is to them.
+func RectangleShape(rect rl.Rectangle) Polygon {
+	return Polygon{Points: []rl.Vector2{
+		{X: rect.X, Y: rect.Y},
+		{X: rect.X + rect.Width, Y: rect.Y},
+		{X: rect.X + rect.Width, Y: rect.Y + rect.Height},
+		{X: rect.X, Y: rect.Y + rect.Height},
+	}}
+}
+
+// Overlaps returns true if the two shapes overlap. As with hitboxes, shapes that only touch don't.
+func Overlaps(a, b Shape) bool {
+	// Most pairs are nowhere near each other, which is quick to rule out
+	if !overlaps(a.Bounds(), b.Bounds()) {
+		return false
+	}
+	// A circle is a capsule that doesn't go anywhere, which leaves only three pairs to check
+	capsuleA, aIsCapsule := capsuleOf(a)
+	capsuleB, bIsCapsule := capsuleOf(b)
+	polygonA, aIsPolygon := polygonOf(a)
+	polygonB, bIsPolygon := polygonOf(b)
+	switch {
+	case aIsCapsule && bIsCapsule:
+		return segmentsCross(capsuleA.A, capsuleA.B, capsuleB.A, capsuleB.B) ||
+			segmentDistance(capsuleA.A, capsuleA.B, capsuleB.A, capsuleB.B) < capsuleA.Radius+capsuleB.Radius
+	case aIsCapsule && bIsPolygon:
+		return polygonCapsuleOverlap(polygonB, capsuleA)
+	case aIsPolygon && bIsCapsule:
+		return polygonCapsuleOverlap(polygonA, capsuleB)
+	case aIsPolygon && bIsPolygon:
+		return polygonsOverlap(polygonA, polygonB)
+	}
+	return false
+}
+
+// capsuleOf returns the shape as a capsule, or false if it's neither a capsule nor a circle.
+func capsuleOf(shape Shape) (Capsule, bool) {
+	switch s := shape.(type) {
+	case Capsule:
+		return s, true
+	case *Capsule:
+		return *s, true
+	case Circle:
+		return Capsule{A: s.Center, B: s.Center, Radius: s.Radius}, true
+	case *Circle:
+		return Capsule{A: s.Center, B: s.Center, Radius: s.Radius}, true
+	default:
+		return Capsule{}, false
+	}
+}
+
+// polygonOf returns the shape as a polygon, or false if it isn't one.
+func polygonOf(shape Shape) (Polygon, bool) {
+	switch s := shape.(type) {
+	case Polygon:
+		return s, true
+	case *Polygon:
+		return *s, true
+	default:
+		return Polygon{}, false
+	}
+}
+
+// shapeBuffer holds a moved copy of a shape, so shapes can be moved over and over while checking
+// collisions without allocating a new one each time.
+type shapeBuffer struct {
+	capsule Capsule
+	polygon Polygon
+}
+
+// move returns the shape moved by the offset, as Moved does, but kept in the buffer, so it only lasts
+// until the buffer is next used.
+func (s *shapeBuffer) move(shape Shape, by rl.Vector2) Shape {
+	if capsule, ok := capsuleOf(shape); ok {
+		s.capsule = Capsule{A: rl.Vector2Add(capsule.A, by), B: rl.Vector2Add(capsule.B, by), Radius: capsule.Radius}
+		return &s.capsule
+	}
+	if polygon, ok := polygonOf(shape); ok {
+		s.polygon.Points = s.polygon.Points[:0]
+		for _, point := range polygon.Points {
+			s.polygon.Points = append(s.polygon.Points, rl.Vector2Add(point, by))
+		}
+		return &s.polygon
+	}
+	return shape.Moved(by)
+}
+
+// polygonCapsuleOverlap returns true if the capsule reaches inside the polygon: it starts inside, or
+// its line crosses one of the polygon's edges, or passes closer to one than its radius.
+func polygonCapsuleOverlap(p Polygon, c Capsule) bool {
+	if len(p.Points) < 3 {
+		return false
+	}
+	if contains(p, c.A) {
+		return true
+	}
+	for i, from := range p.Points {
+		to := p.Points[(i+1)%len(p.Points)]
+		if segmentsCross(from, to, c.A, c.B) || segmentDistance(from, to, c.A, c.B) < c.Radius {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonsOverlap returns true if no edge of either polygon separates them, by the separating axis
+// theorem: two convex shapes don't overlap only if they fall either side of a line along one of their
+// edges.
+func polygonsOverlap(a, b Polygon) bool {
+	if len(a.Points) < 3 || len(b.Points) < 3 {
+		return false
+	}
+	return !separatedByAnEdge(a, b) && !separatedByAnEdge(b, a)
+}
+
+// separatedByAnEdge returns true if the shadows of the polygons, cast onto the normal of one of p's
+// edges, don't overlap.
+func separatedByAnEdge(p, other Polygon) bool {
+	for i, from := range p.Points {
+		edge := rl.Vector2Subtract(p.Points[(i+1)%len(p.Points)], from)
+		normal := rl.Vector2{X: -edge.Y, Y: edge.X}
+		minP, maxP := project(p, normal)
+		minOther, maxOther := project(other, normal)
+		if maxP <= minOther || maxOther <= minP {
+			return true
+		}
+	}
+	return false
+}
+
+// project returns the extent of the polygon's shadow along the axis.
+func project(p Polygon, axis rl.Vector2) (lo, hi float32) {
+	lo, hi = float32(math.Inf(1)), float32(math.Inf(-1))
+	for _, point := range p.Points {
+		d := rl.Vector2DotProduct(point, axis)
+		lo, hi = min(lo, d), max(hi, d)
+	}
+	return lo, hi
+}
+
+// contains returns true if the point is strictly inside the polygon, on the same side of every edge.
+func contains(p Polygon, point rl.Vector2) bool {
+	var sign float32
+	for i, from := range p.Points {
+		side := cross(from, p.Points[(i+1)%len(p.Points)], point)
+		if side == 0 || side*sign < 0 {
+			return false
+		}
+		sign = side
+	}
+	return true
+}
+
+// cross returns which side of the line from a to b the point is on: positive one way, negative the
+// other, and zero on the line.
+func cross(a, b, point rl.Vector2) float32 {
+	return (b.X-a.X)*(point.Y-a.Y) - (b.Y-a.Y)*(point.X-a.X)
+}
+
+// segmentsCross returns true if the lines from a1 to a2 and from b1 to b2 cross each other, each
+// passing from one side of the other to the other; just touching isn't crossing.
+func segmentsCross(a1, a2, b1, b2 rl.Vector2) bool {
+	return cross(a1, a2, b1)*cross(a1, a2, b2) < 0 && cross(b1, b2, a1)*cross(b1, b2, a2) < 0
+}
+
+// segmentDistance returns the shortest distance between the lines from a1 to a2 and from b1 to b2.
+func segmentDistance(a1, a2, b1, b2 rl.Vector2) float32 {
+	if segmentsCross(a1, a2, b1, b2) {
+		return 0
+	}
+	return min(pointSegmentDistance(a1, b1, b2), pointSegmentDistance(a2, b1, b2),
+		pointSegmentDistance(b1, a1, a2), pointSegmentDistance(b2, a1, a2))
+}
+
+// pointSegmentDistance returns the distance from the point to the nearest point on the line from a to b.
+func pointSegmentDistance(point, a, b rl.Vector2) float32 {
+	line := rl.Vector2Subtract(b, a)
+	length := rl.Vector2DotProduct(line, line)
+	if length == 0 {
+		return rl.Vector2Distance(point, a)
+	}
+	t := min(max(rl.Vector2DotProduct(rl.Vector2Subtract(point, a), line)/length, 0), 1)
+	return rl.Vector2Distance(point, rl.Vector2Add(a, rl.Vector2Scale(line, t)))
+}
@@ -0,0 +1,172 @@
+package gameobjects
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// square returns a square polygon centred on the point, turned by the angle in radians.
+func square(center rl.Vector2, side, angle float32) Polygon {
+	t := Transform{Position: center, Rotation: rl.Vector2Rotate(rl.Vector2{X: 1}, angle)}
+	half := side / 2
+	return Polygon{Points: []rl.Vector2{
+		t.ToWorld(rl.Vector2{X: -half, Y: -half}),
+		t.ToWorld(rl.Vector2{X: half, Y: -half}),
+		t.ToWorld(rl.Vector2{X: half, Y: half}),
+		t.ToWorld(rl.Vector2{X: -half, Y: half}),
+	}}
+}
+
+func TestOverlaps(t *testing.T) {
+	const diagonal = 0.7853982 // An eighth of a turn, so a square stands on a corner
+	tests := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{"circles overlapping", Circle{rl.Vector2{}, 10}, Circle{rl.Vector2{X: 15}, 10}, true},
+		{"circles touching", Circle{rl.Vector2{}, 10}, Circle{rl.Vector2{X: 20}, 10}, false},
+		{"circles apart", Circle{rl.Vector2{}, 10}, Circle{rl.Vector2{X: 30, Y: 30}, 10}, false},
+
+		{"circle beside a capsule's middle", Circle{rl.Vector2{Y: 14}, 5}, Capsule{rl.Vector2{X: -20}, rl.Vector2{X: 20}, 10}, true},
+		{"circle beyond a capsule's end", Circle{rl.Vector2{X: 34}, 5}, Capsule{rl.Vector2{X: -20}, rl.Vector2{X: 20}, 10}, true},
+		{"circle clear of a capsule", Circle{rl.Vector2{Y: 16}, 5}, Capsule{rl.Vector2{X: -20}, rl.Vector2{X: 20}, 10}, false},
+
+		{"circle inside a polygon", Circle{rl.Vector2{}, 1}, square(rl.Vector2{}, 20, 0), true},
+		{"circle over a polygon's edge", Circle{rl.Vector2{X: 14}, 5}, square(rl.Vector2{}, 20, 0), true},
+		{"circle touching a polygon's edge", Circle{rl.Vector2{X: 15}, 5}, square(rl.Vector2{}, 20, 0), false},
+		{"circle in a rectangle's corner but clear of the polygon", Circle{rl.Vector2{X: 12.5, Y: 12.5}, 3}, square(rl.Vector2{}, 20, 0), false},
+		{"circle in the corner of a turned polygon's bounds", Circle{rl.Vector2{X: 12, Y: 12}, 3}, square(rl.Vector2{}, 20, diagonal), false},
+		{"circle by the corner of a turned polygon", Circle{rl.Vector2{X: 16}, 3}, square(rl.Vector2{}, 20, diagonal), true},
+
+		{"capsules crossing", Capsule{rl.Vector2{X: -10}, rl.Vector2{X: 10}, 0}, Capsule{rl.Vector2{Y: -10}, rl.Vector2{Y: 10}, 0}, true},
+		{"capsules side by side", Capsule{rl.Vector2{X: -10}, rl.Vector2{X: 10}, 3}, Capsule{rl.Vector2{X: -10, Y: 5}, rl.Vector2{X: 10, Y: 5}, 3}, true},
+		{"capsules end to end", Capsule{rl.Vector2{X: -10}, rl.Vector2{X: 10}, 3}, Capsule{rl.Vector2{X: 20}, rl.Vector2{X: 30}, 3}, false},
+
+		{"capsule through a polygon", Capsule{rl.Vector2{X: -50}, rl.Vector2{X: 50}, 0}, square(rl.Vector2{}, 20, 0), true},
+		{"capsule inside a polygon", Capsule{rl.Vector2{X: -2}, rl.Vector2{X: 2}, 1}, square(rl.Vector2{}, 20, 0), true},
+		{"capsule reaching a polygon", Capsule{rl.Vector2{X: 13, Y: -50}, rl.Vector2{X: 13, Y: 50}, 4}, square(rl.Vector2{}, 20, 0), true},
+		{"capsule clear of a turned polygon", Capsule{rl.Vector2{X: 10, Y: 10}, rl.Vector2{X: 20, Y: 20}, 2}, square(rl.Vector2{}, 20, diagonal), false},
+
+		{"polygons overlapping", square(rl.Vector2{}, 20, 0), square(rl.Vector2{X: 15}, 20, 0), true},
+		{"polygons touching", square(rl.Vector2{}, 20, 0), square(rl.Vector2{X: 20}, 20, 0), false},
+		{"polygons with overlapping bounds", square(rl.Vector2{}, 20, diagonal), square(rl.Vector2{X: 22, Y: 22}, 20, diagonal), false},
+		{"polygon corner into an edge", square(rl.Vector2{}, 20, 0), square(rl.Vector2{X: 23}, 20, diagonal), true},
+		{"polygon inside another", square(rl.Vector2{}, 20, 0), square(rl.Vector2{}, 4, 1), true},
+		{"polygon without an inside", Polygon{Points: []rl.Vector2{{X: -50}, {X: 50}}}, square(rl.Vector2{}, 20, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlaps(tt.a, tt.b); got != tt.want {
+				t.Errorf("Overlaps(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := Overlaps(tt.b, tt.a); got != tt.want {
+				t.Errorf("Overlaps(%v, %v) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlaps_RectanglesMatchHitboxes(t *testing.T) {
+	a := rl.NewRectangle(0, 0, 10, 20)
+	for _, b := range []rl.Rectangle{
+		rl.NewRectangle(5, 5, 10, 10),
+		rl.NewRectangle(10, 0, 10, 10),
+		rl.NewRectangle(-10, 20, 10, 10),
+		rl.NewRectangle(2, 2, 2, 2),
+		rl.NewRectangle(30, 30, 5, 5),
+	} {
+		if got, want := Overlaps(RectangleShape(a), RectangleShape(b)), overlaps(a, b); got != want {
+			t.Errorf("Expected rectangles %v and %v to overlap as their hitboxes do (%v), got %v", a, b, want, got)
+		}
+	}
+}
+
+func TestShape_Bounds(t *testing.T) {
+	tests := []struct {
+		shape Shape
+		want  rl.Rectangle
+	}{
+		{Circle{rl.Vector2{X: 10, Y: 20}, 5}, rl.NewRectangle(5, 15, 10, 10)},
+		{Capsule{rl.Vector2{X: 10, Y: 30}, rl.Vector2{X: 0, Y: 20}, 2}, rl.NewRectangle(-2, 18, 14, 14)},
+		{RectangleShape(rl.NewRectangle(1, 2, 3, 4)), rl.NewRectangle(1, 2, 3, 4)},
+		{Polygon{}, rl.Rectangle{}},
+	}
+	for _, tt := range tests {
+		if got := tt.shape.Bounds(); got != tt.want {
+			t.Errorf("Expected %v to be bounded by %v, got %v", tt.shape, tt.want, got)
+		}
+	}
+}
+
+func TestTransform_ToWorld(t *testing.T) {
+	t.Run("facing along the X axis", func(t *testing.T) {
+		tr := Transform{Position: rl.Vector2{X: 100, Y: 50}, Rotation: rl.Vector2{X: 1}}
+		if got := tr.ToWorld(rl.Vector2{X: 10, Y: 5}); got != (rl.Vector2{X: 110, Y: 55}) {
+			t.Errorf("Expected the point to be offset without turning, got %v", got)
+		}
+	})
+	t.Run("facing up the screen", func(t *testing.T) {
+		tr := Transform{Position: rl.Vector2{X: 100, Y: 50}, Rotation: rl.Vector2{Y: -2}}
+		if got := tr.ToWorld(rl.Vector2{X: 10, Y: 5}); got != (rl.Vector2{X: 105, Y: 40}) {
+			t.Errorf("Expected forward to be up and the right-hand side to be right, got %v", got)
+		}
+	})
+	t.Run("without a rotation", func(t *testing.T) {
+		tr := Transform{Position: rl.Vector2{X: 100, Y: 50}}
+		if got := tr.ToWorld(rl.Vector2{X: 10}); got != (rl.Vector2{X: 110, Y: 50}) {
+			t.Errorf("Expected no rotation to face along the X axis, got %v", got)
+		}
+	})
+}
+
+func TestShape_Moved(t *testing.T) {
+	by := rl.Vector2{X: 800, Y: -600}
+	tests := []struct {
+		shape, want Shape
+	}{
+		{Circle{rl.Vector2{X: 10, Y: 620}, 5}, Circle{rl.Vector2{X: 810, Y: 20}, 5}},
+		{Capsule{rl.Vector2{X: 1, Y: 2}, rl.Vector2{X: 3, Y: 4}, 5}, Capsule{rl.Vector2{X: 801, Y: -598}, rl.Vector2{X: 803, Y: -596}, 5}},
+		{RectangleShape(rl.NewRectangle(1, 2, 3, 4)), RectangleShape(rl.NewRectangle(801, -598, 3, 4))},
+	}
+	for _, tt := range tests {
+		if got := tt.shape.Moved(by); got.Bounds() != tt.want.Bounds() {
+			t.Errorf("Expected %v moved by %v to be %v, got %v", tt.shape, by, tt.want, got)
+		}
+	}
+}
+
+func TestOverlaps_ByPointer(t *testing.T) {
+	circle := Circle{rl.Vector2{X: 10, Y: 10}, 5}
+	capsule := Capsule{rl.Vector2{X: 0, Y: 12}, rl.Vector2{X: 20, Y: 12}, 1}
+	polygon := square(rl.Vector2{X: 14, Y: 10}, 4, 0)
+	shapes := []Shape{circle, &circle, capsule, &capsule, polygon, &polygon}
+	for _, a := range shapes {
+		for _, b := range shapes {
+			if !Overlaps(a, b) {
+				t.Errorf("Expected %v to overlap %v whether by value or by pointer", a, b)
+			}
+		}
+	}
+}
+
+func TestShapeBuffer_Move(t *testing.T) {
+	var buffer shapeBuffer
+	by := rl.Vector2{X: 800, Y: -600}
+	for _, shape := range []Shape{
+		Circle{rl.Vector2{X: 10, Y: 620}, 5},
+		&Capsule{rl.Vector2{X: 1, Y: 2}, rl.Vector2{X: 3, Y: 4}, 5},
+		RectangleShape(rl.NewRectangle(1, 2, 3, 4)),
+	} {
+		if got, want := buffer.move(shape, by), shape.Moved(by); got.Bounds() != want.Bounds() {
+			t.Errorf("Expected %v moved by %v to be %v, got %v", shape, by, want, got)
+		}
+	}
+
+	// Once it's held a polygon, moving more doesn't allocate
+	var square Shape = RectangleShape(rl.NewRectangle(1, 2, 3, 4))
+	if allocs := testing.AllocsPerRun(100, func() { buffer.move(square, by) }); allocs != 0 {
+		t.Errorf("Expected moving into the buffer not to allocate, got %v allocations", allocs)
+	}
+}
@@ -3,6 +3,7 @@ package gameobjects
 import (
 	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
 )
 
 type Transform struct {
@@ -13,3 +14,29 @@ type Transform struct {
 func (t *Transform) String() string {
 	return fmt.Sprintf("pos (%f,%f) rot (%f,%f)", t.Position.X, t.Position.Y, t.Rotation.X, t.Rotation.Y)
 }
+
+// ToWorld returns where a point, given relative to the transform's position with the transform facing
+// along the X axis, is in worldspace once the transform is turned to face its rotation.
+func (t *Transform) ToWorld(local rl.Vector2) rl.Vector2 {
+	forward := rl.Vector2Normalize(t.Rotation)
+	if forward == (rl.Vector2{}) {
+		forward = rl.Vector2{X: 1}
+	}
+	return rl.Vector2{
+		X: t.Position.X + local.X*forward.X - local.Y*forward.Y,
+		Y: t.Position.Y + local.X*forward.Y + local.Y*forward.X,
+	}
+}
+
+// WrapAround returns x wrapped around into the range from 0 to size, keeping however far it went past
+// the edge, as things do going off one edge of the playfield and coming back in at the opposite one.
+func WrapAround(x, size float32) float32 {
+	if size <= 0 || (x >= 0 && x <= size) {
+		return x
+	}
+	x = float32(math.Mod(float64(x), float64(size)))
+	if x < 0 {
+		x += size
+	}
+	return x
+}
@@ -0,0 +1,98 @@
+// Package highscores keeps the best scores for each mode and difficulty. Scores are only ever compared
+// with others in the same mode on the same difficulty, since an Easy game and an Insane one aren't the
+// same contest, and nor are three minutes of Time Attack and a game of Classic.
+package highscores
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Kept is how many scores are kept for each mode and difficulty.
+const Kept = 10
+
+// Entry is a single high score.
+type Entry struct {
+	Mode       core.Mode       `json:"mode"` // Missing from files written before there were modes, so Classic
+	Difficulty core.Difficulty `json:"difficulty"`
+	Score      uint            `json:"score"`
+	Level      int             `json:"level"` // The level the game ended on
+	When       time.Time       `json:"when"`
+}
+
+// Table is the high scores for every mode and difficulty.
+type Table struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Load reads the high scores from a file. A missing file is an empty table, since nobody has played yet.
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Table{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	t := &Table{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Save writes the high scores to a file, creating its directory if needed.
+func (t *Table) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Add records a score and returns where it ranks in its mode and difficulty, starting from 1, or 0 if it
+// isn't good enough to keep. Ties go to the earlier score.
+func (t *Table) Add(e Entry) int {
+	top := t.Top(e.Mode, e.Difficulty)
+	rank := 1 + len(top)
+	for i, other := range top {
+		if e.Score > other.Score {
+			rank = i + 1
+			break
+		}
+	}
+	if rank > Kept {
+		return 0
+	}
+
+	top = slices.Insert(top, rank-1, e)
+	if len(top) > Kept {
+		top = top[:Kept]
+	}
+	t.Entries = slices.DeleteFunc(t.Entries, func(other Entry) bool {
+		return other.Mode == e.Mode && other.Difficulty == e.Difficulty
+	})
+	t.Entries = append(t.Entries, top...)
+	return rank
+}
+
+// Top returns the kept scores in a mode on a difficulty, best first.
+func (t *Table) Top(m core.Mode, d core.Difficulty) []Entry {
+	var top []Entry
+	for _, e := range t.Entries {
+		if e.Mode == m && e.Difficulty == d {
+			top = append(top, e)
+		}
+	}
+	slices.SortStableFunc(top, func(a, b Entry) int { return cmp.Compare(b.Score, a.Score) })
+	return top[:min(len(top), Kept)]
+}
@@ -0,0 +1,79 @@
+package highscores
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTable_Add(t *testing.T) {
+	table := &Table{}
+	for i := range Kept {
+		if rank := table.Add(Entry{Difficulty: core.DifficultyNormal, Score: uint(1000 * (i + 1))}); rank != 1 {
+			t.Errorf("Expected each better score to rank first, got %d", rank)
+		}
+	}
+
+	if rank := table.Add(Entry{Difficulty: core.DifficultyNormal, Score: 5500}); rank != 6 {
+		t.Errorf("Expected 5500 to rank sixth, got %d", rank)
+	}
+	if rank := table.Add(Entry{Difficulty: core.DifficultyNormal, Score: 500}); rank != 0 {
+		t.Errorf("Expected 500 not to make a full table, got rank %d", rank)
+	}
+	if rank := table.Add(Entry{Difficulty: core.DifficultyNormal, Score: 9000}); rank != 3 {
+		t.Errorf("Expected a tie to rank below the earlier score, got %d", rank)
+	}
+	if rank := table.Add(Entry{Difficulty: core.DifficultyInsane, Score: 500}); rank != 1 {
+		t.Errorf("Expected scores on other difficulties not to count, got rank %d", rank)
+	}
+	if rank := table.Add(Entry{Mode: core.ModeZen, Difficulty: core.DifficultyNormal, Score: 500}); rank != 1 {
+		t.Errorf("Expected scores in other modes not to count, got rank %d", rank)
+	}
+
+	top := table.Top(core.ModeClassic, core.DifficultyNormal)
+	if len(top) != Kept || top[0].Score != 10000 || top[Kept-1].Score != 3000 {
+		t.Errorf("Expected the best %d Normal scores from 10000 to 3000, got %v", Kept, top)
+	}
+	if len(table.Entries) != Kept+2 {
+		t.Errorf("Expected only the kept scores to be stored, got %d", len(table.Entries))
+	}
+}
+
+func TestTable_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores", "highscores.json")
+	empty, err := Load(path)
+	if err != nil || len(empty.Entries) != 0 {
+		t.Fatalf("Expected a missing file to be an empty table, got %v (%v)", empty, err)
+	}
+
+	table := &Table{}
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	table.Add(Entry{Mode: core.ModeSurvival, Difficulty: core.DifficultyHard, Score: 1234, Level: 3, When: when})
+	if err := table.Save(path); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+	top := loaded.Top(core.ModeSurvival, core.DifficultyHard)
+	if len(top) != 1 || top[0].Score != 1234 || top[0].Level != 3 || !top[0].When.Equal(when) {
+		t.Errorf("Expected the Hard Survival score to load, got %v", loaded.Entries)
+	}
+}
+
+func TestLoad_BeforeModes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "highscores.json")
+	if err := os.WriteFile(path, []byte(`{"entries": [{"difficulty": "easy", "score": 800}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+	if top := table.Top(core.ModeClassic, core.DifficultyEasy); len(top) != 1 || top[0].Score != 800 {
+		t.Errorf("Expected scores from before modes to be Classic, got %v", table.Entries)
+	}
+}
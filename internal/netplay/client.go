@@ -0,0 +1,377 @@
+package netplay
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
+	"avoid_the_space_rocks/internal/scenes/playfield"
+	"errors"
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// InterpolationDelay is how far in the past clients show everything but their own spaceship, so
+	// there are nearly always two snapshots to show it between, even if one goes astray.
+	InterpolationDelay = 100 * time.Millisecond
+
+	// DialTimeout is how long Dial keeps asking before giving up on the server.
+	DialTimeout = 5 * time.Second
+
+	// helloEvery is how often Dial asks again.
+	helloEvery = 250 * time.Millisecond
+
+	// keptSnapshots is about a second of snapshots, far more than interpolating needs.
+	keptSnapshots = 64
+
+	// maxUnacked is the most input a client remembers replaying over the server's state, four seconds'
+	// worth. The server never holds more than a fraction of that.
+	maxUnacked = 4 * playfield.TickRate
+)
+
+// Client plays in a game on a server. Send its player's input every tick and draw its Sprites every
+// frame. It's safe to use from one goroutine while it receives snapshots on another.
+type Client struct {
+	conn    *net.UDPConn
+	welcome Welcome
+	sheets  []*gameobjects.SpriteSheet
+	delta   float32 // Seconds per tick
+
+	lock      sync.Mutex
+	snapshots []receivedSnapshot // The snapshots received, oldest first
+	heardAt   time.Time          // When the server was last heard from
+
+	// The client's own spaceship, predicted from the last snapshot and the input sent since
+	game       *core.Game // An empty game for the spaceship to fly in
+	ship       core.Spaceship
+	predicting bool   // The spaceship is flying, so its flight is predicted
+	reconciled uint32 // Tick of the snapshot the prediction starts from
+	seq        uint32 // Number of the newest input sent
+	unacked    []queuedInput
+}
+
+type receivedSnapshot struct {
+	*Snapshot
+	at time.Time
+}
+
+// Dial joins the game on the server at the address, such as "localhost:7777". It waits until the server
+// welcomes the client or turns it away, up to DialTimeout.
+func Dial(address string) (*Client, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	welcome, err := hello(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	c, err := newClient(conn, welcome)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	platform.Log(rl.LogInfo, "Joined the game on %s as player %d of %d", addr, welcome.Player+1, welcome.Players)
+	go c.read()
+	return c, nil
+}
+
+// hello asks the server for a place in the game until it answers.
+func hello(conn *net.UDPConn) (*Welcome, error) {
+	buf := make([]byte, maxDatagram)
+	deadline := time.Now().Add(DialTimeout)
+	for time.Now().Before(deadline) {
+		if _, err := conn.Write(Encode(&Hello{})); err != nil {
+			return nil, err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(helloEvery)); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				// Most likely nobody is listening yet; give them a moment
+				time.Sleep(helloEvery)
+			}
+			continue
+		}
+		msg, err := Decode(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("server can't be understood: %w", err)
+		}
+		switch m := msg.(type) {
+		case *Welcome:
+			return m, conn.SetReadDeadline(time.Time{})
+		case *Reject:
+			return nil, fmt.Errorf("server turned us away: %s", m.Reason)
+		}
+	}
+	return nil, errors.New("no answer from the server")
+}
+
+// newClient sets up a client for the game the server welcomed it to.
+func newClient(conn *net.UDPConn, welcome *Welcome) (*Client, error) {
+	if welcome.Player >= welcome.Players || welcome.TickRate == 0 {
+		return nil, errors.New("server sent a welcome that makes no sense")
+	}
+	tuning, err := core.ParseTuning(welcome.Tuning)
+	if err != nil {
+		return nil, fmt.Errorf("server's tuning can't be used: %w", err)
+	}
+	c := &Client{
+		conn:    conn,
+		welcome: *welcome,
+		delta:   1 / float32(welcome.TickRate),
+		game:    core.NewGame(welcome.Width, welcome.Height, 1),
+	}
+	c.game.Tuning = tuning
+	// The prediction is never drawn as a playfield, and never ages any effects it sends out
+	c.game.World.Particles.Off = true
+	c.ship = core.NewSpaceship(c.game, welcome.Player)
+	for _, s := range welcome.Sheets {
+		c.sheets = append(c.sheets, gameobjects.LoadSpriteSheet(s.File, s.Rows, s.Cols))
+	}
+	return c, nil
+}
+
+// Welcome returns what the server said about the game when the client joined.
+func (c *Client) Welcome() Welcome {
+	return c.welcome
+}
+
+// Close leaves the game.
+func (c *Client) Close() error {
+	_, _ = c.conn.Write(Encode(&Bye{}))
+	return c.conn.Close()
+}
+
+// read takes in every snapshot the server sends, until the connection is closed.
+func (c *Client) read() {
+	buf := make([]byte, maxDatagram)
+	for {
+		n, err := c.conn.Read(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			// Most likely the server has gone; Lost says so once it has been gone a while
+			time.Sleep(helloEvery)
+			continue
+		}
+		msg, err := Decode(buf[:n])
+		if err != nil {
+			platform.Log(rl.LogDebug, "Ignoring datagram from the server: %v", err)
+			continue
+		}
+		if snapshot, ok := msg.(*Snapshot); ok {
+			c.receive(snapshot, time.Now())
+		}
+	}
+}
+
+// receive keeps a snapshot that arrived at the given time, unless a newer one has already arrived.
+func (c *Client) receive(snapshot *Snapshot, at time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.heardAt = at
+	if n := len(c.snapshots); n > 0 && snapshot.Tick <= c.snapshots[n-1].Tick {
+		return
+	}
+	if len(snapshot.Ships) != c.welcome.Players {
+		platform.Log(rl.LogDebug, "Ignoring snapshot with %d spaceships", len(snapshot.Ships))
+		return
+	}
+	c.snapshots = append(c.snapshots, receivedSnapshot{Snapshot: snapshot, at: at})
+	if len(c.snapshots) > keptSnapshots {
+		c.snapshots = c.snapshots[len(c.snapshots)-keptSnapshots:]
+	}
+}
+
+// Latest returns the newest snapshot, or false if the game hasn't started yet.
+func (c *Client) Latest() (Snapshot, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.snapshots) == 0 {
+		return Snapshot{}, false
+	}
+	return *c.snapshots[len(c.snapshots)-1].Snapshot, true
+}
+
+// Lost returns true if the game started but the server hasn't been heard from in ClientTimeout.
+func (c *Client) Lost() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.snapshots) > 0 && time.Since(c.heardAt) > ClientTimeout
+}
+
+// Send sends the player's input for the next tick, along with the input the server hasn't
+// acknowledged yet, and flies the client's spaceship accordingly straight away.
+func (c *Client) Send(input replay.Input) error {
+	_, err := c.conn.Write(Encode(c.record(input)))
+	return err
+}
+
+// record remembers the input until the server acknowledges it, predicts its effect, and returns the
+// message to send.
+func (c *Client) record(input replay.Input) *Input {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reconcile()
+	c.seq++
+	c.unacked = append(c.unacked, queuedInput{seq: c.seq, input: input})
+	if len(c.unacked) > maxUnacked {
+		c.unacked = c.unacked[len(c.unacked)-maxUnacked:]
+	}
+	if c.predicting {
+		c.predict(input)
+	}
+	msg := &Input{Newest: c.seq}
+	for _, q := range c.unacked[max(0, len(c.unacked)-maxInputs):] {
+		msg.Inputs = append(msg.Inputs, q.input)
+	}
+	return msg
+}
+
+// reconcile starts the prediction over from the newest snapshot, if it hasn't already, by putting the
+// spaceship where the server says it is and replaying the input the server hadn't applied yet.
+func (c *Client) reconcile() {
+	if len(c.snapshots) == 0 {
+		return
+	}
+	latest := c.snapshots[len(c.snapshots)-1]
+	if latest.Tick == c.reconciled {
+		return
+	}
+	c.reconciled = latest.Tick
+	acked := 0
+	for acked < len(c.unacked) && c.unacked[acked].seq <= latest.Ack {
+		acked++
+	}
+	c.unacked = c.unacked[acked:]
+
+	state := latest.Ships[c.welcome.Player]
+	c.predicting = state.Alive && !state.InHyperspace
+	if !c.predicting {
+		return
+	}
+	c.ship.Position = state.Position
+	c.ship.Velocity = state.Velocity
+	c.ship.Rotation = state.Rotation
+	c.ship.FuelBurning = state.FuelBurning
+	for _, q := range c.unacked {
+		c.predict(q.input)
+	}
+}
+
+// predict flies the spaceship for a tick the way the server will. Only the flight is predicted; firing
+// and hyperspace are left to the server.
+func (c *Client) predict(input replay.Input) {
+	if input.Has(replay.RotateLeft) {
+		c.ship.RotateLeft(c.delta)
+	}
+	if input.Has(replay.RotateRight) {
+		c.ship.RotateRight(c.delta)
+	}
+	c.ship.FuelBurning = input.Has(replay.Thrust)
+	_ = c.ship.Update(c.delta)
+}
+
+// Sprites returns everything to draw at the given time. The client's own spaceship is where it's
+// predicted to be now; everything else is InterpolationDelay in the past, part way between the
+// snapshots either side.
+func (c *Client) Sprites(now time.Time) []core.Sprite {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.snapshots) == 0 {
+		return nil
+	}
+	c.reconcile()
+
+	// Work out the tick to show from the newest snapshot and how long ago it arrived
+	latest := c.snapshots[len(c.snapshots)-1]
+	ticksPerSecond := float64(c.welcome.TickRate)
+	at := float64(latest.Tick) + (now.Sub(latest.at).Seconds()-InterpolationDelay.Seconds())*ticksPerSecond
+	from, to := c.snapshots[0], c.snapshots[0]
+	for i, s := range c.snapshots {
+		if float64(s.Tick) > at {
+			break
+		}
+		from, to = s, s
+		if i+1 < len(c.snapshots) {
+			to = c.snapshots[i+1]
+		}
+	}
+	t := float32(0)
+	if to.Tick > from.Tick {
+		t = min(1, max(0, float32((at-float64(from.Tick))/float64(to.Tick-from.Tick))))
+	}
+
+	next := make(map[uint32]SpriteState, len(to.Sprites))
+	for _, s := range to.Sprites {
+		next[s.ID] = s
+	}
+	own := uint32(c.welcome.Player)
+	sprites := make([]core.Sprite, 0, len(from.Sprites)+1)
+	for _, s := range from.Sprites {
+		if s.Sheet >= len(c.sheets) || (s.ID == own && c.predicting) {
+			continue
+		}
+		sprite := core.Sprite{Sheet: c.sheets[s.Sheet], Row: s.Row, Col: s.Col, Position: s.Position, Rotation: s.Rotation}
+		if n, ok := next[s.ID]; ok {
+			sprite.Position = c.lerpPosition(s.Position, n.Position, t)
+			sprite.Rotation = lerpRotation(s.Rotation, n.Rotation, t)
+		}
+		sprites = append(sprites, sprite)
+	}
+	if c.predicting {
+		for _, s := range latest.Sprites {
+			if s.ID == own && s.Sheet < len(c.sheets) {
+				sprites = append(sprites, core.Sprite{
+					Sheet:    c.sheets[s.Sheet],
+					Row:      s.Row,
+					Col:      s.Col,
+					Position: c.ship.Position,
+					Rotation: c.ship.Rotation,
+				})
+			}
+		}
+	}
+	return sprites
+}
+
+// lerpPosition returns the point t of the way from a to b, going the short way round the edges of the
+// playfield for anything that just wrapped around.
+func (c *Client) lerpPosition(a, b rl.Vector2, t float32) rl.Vector2 {
+	return rl.Vector2{
+		X: lerpWrapped(a.X, b.X, c.welcome.Width, t),
+		Y: lerpWrapped(a.Y, b.Y, c.welcome.Height, t),
+	}
+}
+
+func lerpWrapped(a, b, size, t float32) float32 {
+	d := b - a
+	if d > size/2 {
+		d -= size
+	} else if d < -size/2 {
+		d += size
+	}
+	return a + d*t
+}
+
+// lerpRotation returns the direction t of the way from a to b.
+func lerpRotation(a, b rl.Vector2, t float32) rl.Vector2 {
+	r := rl.Vector2Lerp(a, b, t)
+	if rl.Vector2Length(r) == 0 {
+		return b
+	}
+	return rl.Vector2Normalize(r)
+}
@@ -0,0 +1,153 @@
+package netplay
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/replay"
+	"encoding/json"
+	"testing"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// offlineClient returns a client for the first of two players that isn't connected to anything, for
+// feeding snapshots by hand.
+func offlineClient(t *testing.T) *Client {
+	tuning, err := json.Marshal(core.DefaultTuning())
+	if err != nil {
+		t.Fatalf("Unexpected error encoding tuning: %v", err)
+	}
+	c, err := newClient(nil, &Welcome{
+		Players: 2, TickRate: 120, SnapshotEvery: 2, Width: 1000, Height: 800, Tuning: tuning,
+		Sheets: []Sheet{{File: "spaceship.png", Rows: 7, Cols: 1}, {File: "rock_big.png", Rows: 1, Cols: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error setting up client: %v", err)
+	}
+	return c
+}
+
+var up = rl.Vector2{X: 0, Y: -1}
+
+// snapshotAt returns a snapshot with both spaceships flying, the first as given, and a rock at the given
+// position.
+func snapshotAt(tick, ack uint32, ship ShipState, rock rl.Vector2) *Snapshot {
+	return &Snapshot{
+		Tick: tick,
+		Ack:  ack,
+		Ships: []ShipState{
+			ship,
+			{Alive: true, Lives: 3, Position: rl.Vector2{X: 600, Y: 400}, Rotation: up},
+		},
+		Sprites: []SpriteState{
+			{ID: 0, Sheet: 0, Position: ship.Position, Rotation: ship.Rotation},
+			{ID: 1, Sheet: 0, Position: rl.Vector2{X: 600, Y: 400}, Rotation: up},
+			{ID: 2, Sheet: 1, Position: rock, Rotation: rl.Vector2{X: 1}},
+		},
+	}
+}
+
+func TestClient_PredictsOwnSpaceship(t *testing.T) {
+	c := offlineClient(t)
+	start := ShipState{Alive: true, Lives: 3, Position: rl.Vector2{X: 333, Y: 400}, Rotation: up}
+	c.receive(snapshotAt(10, 0, start, rl.Vector2{}), time.Now())
+
+	// Thrust for ten ticks; the spaceship moves up straight away, without waiting for the server
+	for range 10 {
+		c.record(replay.Thrust)
+	}
+	if c.ship.Position.Y >= start.Position.Y || c.ship.Position.X != start.Position.X {
+		t.Fatalf("Expected the spaceship to head up from %v, got %v", start.Position, c.ship.Position)
+	}
+	predicted := c.ship.Position
+
+	// The server applied the first four; its state plus the six it hasn't gets the same answer
+	server := offlineClient(t)
+	server.receive(snapshotAt(10, 0, start, rl.Vector2{}), time.Now())
+	for range 4 {
+		server.record(replay.Thrust)
+	}
+	applied := start
+	applied.Position, applied.Velocity, applied.FuelBurning = server.ship.Position, server.ship.Velocity, true
+	c.receive(snapshotAt(14, 4, applied, rl.Vector2{}), time.Now())
+	c.reconcile()
+	if len(c.unacked) != 6 {
+		t.Errorf("Expected 6 inputs still unacknowledged, got %d", len(c.unacked))
+	}
+	if rl.Vector2Distance(c.ship.Position, predicted) > 0.01 {
+		t.Errorf("Expected replaying the unacknowledged input to land at %v, got %v", predicted, c.ship.Position)
+	}
+
+	// A correction from the server wins
+	corrected := start
+	corrected.Position = rl.Vector2{X: 100, Y: 100}
+	c.receive(snapshotAt(20, 10, corrected, rl.Vector2{}), time.Now())
+	c.reconcile()
+	if c.ship.Position != (rl.Vector2{X: 100, Y: 100}) {
+		t.Errorf("Expected the server's position once all input is acknowledged, got %v", c.ship.Position)
+	}
+}
+
+func TestClient_PredictionSendsOutNoEffects(t *testing.T) {
+	c := offlineClient(t)
+	start := ShipState{Alive: true, Lives: 3, Position: rl.Vector2{X: 333, Y: 400}, Rotation: up}
+	at := time.Now()
+	c.receive(snapshotAt(10, 0, start, rl.Vector2{}), at)
+
+	// A minute of thrust, replayed over every snapshot as the server falls behind
+	for tick := range uint32(7200) {
+		c.record(replay.Thrust)
+		if tick%2 == 0 {
+			c.receive(snapshotAt(12+tick, tick/2, start, rl.Vector2{}), at)
+			c.Sprites(at)
+		}
+	}
+	if n := c.game.World.Particles.Len(); n != 0 {
+		t.Errorf("Expected no exhaust left behind by the prediction, got %d particles", n)
+	}
+}
+
+func TestClient_InterpolatesEverythingElse(t *testing.T) {
+	c := offlineClient(t)
+	ship := ShipState{Alive: true, Lives: 3, Position: rl.Vector2{X: 300, Y: 400}, Rotation: up}
+	at := time.Now()
+	c.receive(snapshotAt(10, 0, ship, rl.Vector2{X: 100, Y: 100}), at)
+	c.receive(snapshotAt(12, 0, ship, rl.Vector2{X: 200, Y: 100}), at.Add(time.Second/60))
+
+	// Halfway between the two snapshots, InterpolationDelay ago
+	now := at.Add(time.Second/60 + InterpolationDelay - time.Second/120)
+	rock := findSprite(t, c.Sprites(now), 1)
+	if rl.Vector2Distance(rock.Position, rl.Vector2{X: 150, Y: 100}) > 0.01 {
+		t.Errorf("Expected the rock halfway along at (150, 100), got %v", rock.Position)
+	}
+
+	// Going the short way round when it wraps
+	c.receive(snapshotAt(14, 0, ship, rl.Vector2{X: 990, Y: 100}), at.Add(2*time.Second/60))
+	c.receive(snapshotAt(16, 0, ship, rl.Vector2{X: 10, Y: 100}), at.Add(3*time.Second/60))
+	now = at.Add(3*time.Second/60 + InterpolationDelay - time.Second/120)
+	rock = findSprite(t, c.Sprites(now), 1)
+	if rl.Vector2Distance(rock.Position, rl.Vector2{X: 1000, Y: 100}) > 0.01 {
+		t.Errorf("Expected the rock at the edge at (1000, 100), got %v", rock.Position)
+	}
+
+	// Both spaceships are there, the other player's from the snapshots and this one's predicted
+	sprites := c.Sprites(now)
+	if len(sprites) != 3 {
+		t.Errorf("Expected both spaceships and the rock, got %v", sprites)
+	}
+}
+
+// findSprite returns the only sprite drawn from the sheet, failing if there isn't exactly one.
+func findSprite(t *testing.T, sprites []core.Sprite, sheet int) core.Sprite {
+	t.Helper()
+	var found []core.Sprite
+	for _, s := range sprites {
+		if file, _, _ := s.Sheet.Layout(); file == []string{"spaceship.png", "rock_big.png"}[sheet] {
+			found = append(found, s)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected one sprite from sheet %d, got %v", sheet, found)
+	}
+	return found[0]
+}
@@ -0,0 +1,435 @@
+// Package netplay plays a game over UDP. A dedicated server runs the one true simulation with nothing to
+// show it on; clients send it their players' input and draw the state it sends back. Each client
+// predicts its own spaceship's flight from its input so the controls respond straight away, and shows
+// everything else a little in the past, smoothly, between the states the server sent. See
+// docs/protocol.md for the messages on the wire.
+package netplay
+
+import (
+	"avoid_the_space_rocks/internal/replay"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"math"
+)
+
+// ProtocolVersion is sent with every message. Bump it whenever a message changes, so clients and
+// servers from different versions turn each other away rather than misreading each other.
+const ProtocolVersion = 1
+
+// Every message starts with magic bytes, the protocol version, and the message type.
+var magic = []byte("ASRN")
+
+const headerSize = 6
+
+// maxDatagram is the most a UDP datagram can carry over IPv4.
+const maxDatagram = 65507
+
+type msgType byte
+
+const (
+	msgHello msgType = iota + 1
+	msgWelcome
+	msgReject
+	msgInput
+	msgSnapshot
+	msgBye
+)
+
+// Message is one of the messages clients and servers send each other, one per datagram.
+type Message interface {
+	msgType() msgType
+	encode(w *writer)
+	decode(r *reader)
+}
+
+// Hello asks the server for a place in the game. Clients send it until they're welcomed or rejected.
+type Hello struct{}
+
+// Welcome gives the client a place in the game, and everything it needs to show the game and predict
+// its own spaceship.
+type Welcome struct {
+	Player        int     // Index of the client's spaceship
+	Players       int     // How many spaceships fly in the game
+	TickRate      int     // Simulation ticks per second
+	SnapshotEvery int     // Ticks between snapshots
+	Width         float32 // Size of the playfield in worldspace
+	Height        float32
+	Mode          int    // The core.Mode the game is played under
+	Difficulty    int    // The core.Difficulty the game is played on
+	Tuning        []byte // The game's tuning as JSON
+	Sheets        []Sheet
+}
+
+// Sheet is a sprite sheet snapshots refer to by its index in Welcome.Sheets.
+type Sheet struct {
+	File       string
+	Rows, Cols int
+}
+
+// Reject turns the client away, saying why.
+type Reject struct {
+	Reason string
+}
+
+// Input is the client's input for the most recent ticks, oldest first, ending with the tick numbered
+// Newest. Each message repeats the input the server hasn't acknowledged yet, so a lost datagram loses
+// nothing.
+type Input struct {
+	Newest uint32
+	Inputs []replay.Input
+}
+
+// Snapshot is the state of the game after a tick.
+type Snapshot struct {
+	Tick    uint32 // Ticks since the game started
+	Ack     uint32 // The newest of the client's inputs the server has applied; 0 for none
+	Level   int
+	Score   uint
+	Over    bool
+	Ships   []ShipState   // Every spaceship, in player order
+	Sprites []SpriteState // Everything to draw, spaceships included
+}
+
+// ShipState is where a spaceship is and how it's doing. Clients start predicting their own spaceship
+// from it.
+type ShipState struct {
+	Alive        bool
+	Out          bool
+	InHyperspace bool
+	FuelBurning  bool
+	Lives        int
+	Score        uint
+	Position     rl.Vector2
+	Velocity     rl.Vector2
+	Rotation     rl.Vector2
+}
+
+// SpriteState is one object to draw. An object keeps its ID from one snapshot to the next, so clients
+// can follow it between them; a spaceship's ID is its player's index.
+type SpriteState struct {
+	ID       uint32
+	Sheet    int // Index into Welcome.Sheets
+	Row, Col int
+	Position rl.Vector2
+	Rotation rl.Vector2
+}
+
+// Bye tells the server the client is leaving, so it doesn't wait for it to time out.
+type Bye struct{}
+
+// Sizes of the variable parts of messages, to keep them within a datagram.
+const (
+	maxInputs  = 32
+	shipSize   = 1 + 1 + 4 + 6*4
+	spriteSize = 4 + 1 + 1 + 1 + 4*4
+	maxSprites = (maxDatagram - headerSize - 16 - 255*shipSize) / spriteSize
+)
+
+func (*Hello) msgType() msgType    { return msgHello }
+func (*Welcome) msgType() msgType  { return msgWelcome }
+func (*Reject) msgType() msgType   { return msgReject }
+func (*Input) msgType() msgType    { return msgInput }
+func (*Snapshot) msgType() msgType { return msgSnapshot }
+func (*Bye) msgType() msgType      { return msgBye }
+
+func (*Hello) encode(*writer) {}
+func (*Hello) decode(*reader) {}
+func (*Bye) encode(*writer)   {}
+func (*Bye) decode(*reader)   {}
+
+func (m *Welcome) encode(w *writer) {
+	w.u8(uint8(m.Player))
+	w.u8(uint8(m.Players))
+	w.u16(uint16(m.TickRate))
+	w.u8(uint8(m.SnapshotEvery))
+	w.f32(m.Width)
+	w.f32(m.Height)
+	w.u8(uint8(m.Mode))
+	w.u8(uint8(m.Difficulty))
+	w.u16(uint16(len(m.Tuning)))
+	w.buf = append(w.buf, m.Tuning...)
+	w.u8(uint8(len(m.Sheets)))
+	for _, s := range m.Sheets {
+		w.str(s.File)
+		w.u8(uint8(s.Rows))
+		w.u8(uint8(s.Cols))
+	}
+}
+
+func (m *Welcome) decode(r *reader) {
+	m.Player = int(r.u8())
+	m.Players = int(r.u8())
+	m.TickRate = int(r.u16())
+	m.SnapshotEvery = int(r.u8())
+	m.Width = r.f32()
+	m.Height = r.f32()
+	m.Mode = int(r.u8())
+	m.Difficulty = int(r.u8())
+	m.Tuning = r.bytes(int(r.u16()))
+	m.Sheets = make([]Sheet, r.u8())
+	for i := range m.Sheets {
+		m.Sheets[i] = Sheet{File: r.str(), Rows: int(r.u8()), Cols: int(r.u8())}
+	}
+}
+
+func (m *Reject) encode(w *writer) {
+	w.str(m.Reason)
+}
+
+func (m *Reject) decode(r *reader) {
+	m.Reason = r.str()
+}
+
+func (m *Input) encode(w *writer) {
+	inputs := m.Inputs[max(0, len(m.Inputs)-maxInputs):]
+	w.u32(m.Newest)
+	w.u8(uint8(len(inputs)))
+	for _, input := range inputs {
+		w.u8(uint8(input))
+	}
+}
+
+func (m *Input) decode(r *reader) {
+	m.Newest = r.u32()
+	m.Inputs = make([]replay.Input, r.u8())
+	for i := range m.Inputs {
+		m.Inputs[i] = replay.Input(r.u8())
+	}
+	if len(m.Inputs) > int(m.Newest) {
+		r.fail(errors.New("input from before the first tick"))
+	}
+}
+
+// Ship state flags
+const (
+	shipAlive = 1 << iota
+	shipOut
+	shipInHyperspace
+	shipFuelBurning
+)
+
+func (m *Snapshot) encode(w *writer) {
+	w.u32(m.Tick)
+	w.u32(m.Ack)
+	w.u16(uint16(m.Level))
+	w.u32(uint32(m.Score))
+	w.bool(m.Over)
+	w.u8(uint8(len(m.Ships)))
+	for _, s := range m.Ships {
+		w.u8(flag(s.Alive, shipAlive) | flag(s.Out, shipOut) | flag(s.InHyperspace, shipInHyperspace) |
+			flag(s.FuelBurning, shipFuelBurning))
+		w.u8(uint8(min(s.Lives, math.MaxUint8)))
+		w.u32(uint32(s.Score))
+		w.vec(s.Position)
+		w.vec(s.Velocity)
+		w.vec(s.Rotation)
+	}
+	sprites := m.Sprites[:min(len(m.Sprites), maxSprites)]
+	w.u16(uint16(len(sprites)))
+	for _, s := range sprites {
+		w.u32(s.ID)
+		w.u8(uint8(s.Sheet))
+		w.u8(uint8(s.Row))
+		w.u8(uint8(s.Col))
+		w.vec(s.Position)
+		w.vec(s.Rotation)
+	}
+}
+
+// flag returns the bit if it's set, or nothing.
+func flag(set bool, bit uint8) uint8 {
+	if set {
+		return bit
+	}
+	return 0
+}
+
+func (m *Snapshot) decode(r *reader) {
+	m.Tick = r.u32()
+	m.Ack = r.u32()
+	m.Level = int(r.u16())
+	m.Score = uint(r.u32())
+	m.Over = r.bool()
+	m.Ships = make([]ShipState, r.u8())
+	for i := range m.Ships {
+		flags := r.u8()
+		m.Ships[i] = ShipState{
+			Alive:        flags&shipAlive != 0,
+			Out:          flags&shipOut != 0,
+			InHyperspace: flags&shipInHyperspace != 0,
+			FuelBurning:  flags&shipFuelBurning != 0,
+			Lives:        int(r.u8()),
+			Score:        uint(r.u32()),
+			Position:     r.vec(),
+			Velocity:     r.vec(),
+			Rotation:     r.vec(),
+		}
+	}
+	count := int(r.u16())
+	if count*spriteSize > len(r.buf) {
+		r.fail(errors.New("short message"))
+		return
+	}
+	m.Sprites = make([]SpriteState, count)
+	for i := range m.Sprites {
+		m.Sprites[i] = SpriteState{
+			ID:       r.u32(),
+			Sheet:    int(r.u8()),
+			Row:      int(r.u8()),
+			Col:      int(r.u8()),
+			Position: r.vec(),
+			Rotation: r.vec(),
+		}
+	}
+}
+
+// Encode returns the datagram carrying the message.
+func Encode(m Message) []byte {
+	w := &writer{buf: make([]byte, 0, 64)}
+	w.buf = append(w.buf, magic...)
+	w.u8(ProtocolVersion)
+	w.u8(uint8(m.msgType()))
+	m.encode(w)
+	return w.buf
+}
+
+// Decode reads the message a datagram carries. It fails on anything that isn't a whole message of this
+// version of the protocol.
+func Decode(data []byte) (Message, error) {
+	if len(data) < headerSize || string(data[:len(magic)]) != string(magic) {
+		return nil, errors.New("not a netplay message")
+	}
+	if version := data[len(magic)]; version != ProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d", version)
+	}
+	var m Message
+	switch msgType(data[len(magic)+1]) {
+	case msgHello:
+		m = &Hello{}
+	case msgWelcome:
+		m = &Welcome{}
+	case msgReject:
+		m = &Reject{}
+	case msgInput:
+		m = &Input{}
+	case msgSnapshot:
+		m = &Snapshot{}
+	case msgBye:
+		m = &Bye{}
+	default:
+		return nil, fmt.Errorf("unknown message type %d", data[len(magic)+1])
+	}
+	r := &reader{buf: data[headerSize:]}
+	m.decode(r)
+	if r.err == nil && len(r.buf) > 0 {
+		r.fail(errors.New("trailing bytes"))
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("bad %T: %w", m, r.err)
+	}
+	return m, nil
+}
+
+// writer appends little-endian values to a message.
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) u8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *writer) u16(v uint16) {
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, v)
+}
+
+func (w *writer) u32(v uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+func (w *writer) f32(v float32) {
+	w.u32(math.Float32bits(v))
+}
+
+func (w *writer) vec(v rl.Vector2) {
+	w.f32(v.X)
+	w.f32(v.Y)
+}
+
+func (w *writer) bool(v bool) {
+	if v {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+// str writes a string of up to 255 bytes, cutting longer ones short.
+func (w *writer) str(s string) {
+	s = s[:min(len(s), math.MaxUint8)]
+	w.u8(uint8(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// reader reads little-endian values from a message. Once a read fails, every later read returns zero,
+// so a message can be read through and checked once at the end.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	r.buf = nil
+}
+
+func (r *reader) bytes(n int) []byte {
+	if n > len(r.buf) {
+		r.fail(errors.New("short message"))
+		return nil
+	}
+	b := r.buf[:n:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) u8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) f32() float32 {
+	return math.Float32frombits(r.u32())
+}
+
+func (r *reader) vec() rl.Vector2 {
+	return rl.Vector2{X: r.f32(), Y: r.f32()}
+}
+
+func (r *reader) bool() bool {
+	return r.u8() != 0
+}
+
+func (r *reader) str() string {
+	return string(r.bytes(int(r.u8())))
+}
@@ -0,0 +1,85 @@
+package netplay
+
+import (
+	"avoid_the_space_rocks/internal/replay"
+	"reflect"
+	"strings"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestProtocol_RoundTrip(t *testing.T) {
+	messages := []Message{
+		&Hello{},
+		&Welcome{
+			Player: 1, Players: 2, TickRate: 120, SnapshotEvery: 2, Width: 1024, Height: 768, Mode: 2, Difficulty: 3,
+			Tuning: []byte(`{"ship":{"max_lives":5}}`),
+			Sheets: []Sheet{{File: "spaceship.png", Rows: 7, Cols: 1}, {File: "alien_small.png", Rows: 3, Cols: 3}},
+		},
+		&Reject{Reason: "the game is full"},
+		&Input{Newest: 40, Inputs: []replay.Input{0, replay.Thrust, replay.Thrust | replay.Fire}},
+		&Snapshot{
+			Tick: 1234, Ack: 1200, Level: 3, Score: 45_000, Over: true,
+			Ships: []ShipState{
+				{Alive: true, FuelBurning: true, Lives: 2, Score: 40_000, Position: rl.Vector2{X: 1, Y: 2},
+					Velocity: rl.Vector2{X: -3, Y: 4}, Rotation: rl.Vector2{X: 0, Y: -1}},
+				{Out: true, InHyperspace: true, Score: 5_000},
+			},
+			Sprites: []SpriteState{
+				{ID: 0, Sheet: 0, Row: 1, Position: rl.Vector2{X: 1, Y: 2}, Rotation: rl.Vector2{X: 0, Y: -1}},
+				{ID: 77, Sheet: 6, Row: 2, Col: 1, Position: rl.Vector2{X: 500.5, Y: 20}, Rotation: rl.Vector2{X: 1}},
+			},
+		},
+		&Bye{},
+	}
+	for _, m := range messages {
+		decoded, err := Decode(Encode(m))
+		if err != nil {
+			t.Errorf("Unexpected error decoding %T: %v", m, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("Expected %+v, got %+v", m, decoded)
+		}
+	}
+}
+
+func TestInput_OnlyTheNewestGoOut(t *testing.T) {
+	inputs := make([]replay.Input, 50)
+	inputs[len(inputs)-1] = replay.Fire
+	decoded, err := Decode(Encode(&Input{Newest: 50, Inputs: inputs}))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding: %v", err)
+	}
+	input := decoded.(*Input)
+	if len(input.Inputs) != maxInputs || input.Newest != 50 || input.Inputs[maxInputs-1] != replay.Fire {
+		t.Errorf("Expected the newest %d inputs ending with 50, got %d ending with %d", maxInputs, len(input.Inputs), input.Newest)
+	}
+}
+
+func TestDecode_Rejects(t *testing.T) {
+	snapshot := Encode(&Snapshot{Ships: []ShipState{{}}, Sprites: []SpriteState{{ID: 1}}})
+	otherVersion := Encode(&Hello{})
+	otherVersion[len(magic)] = ProtocolVersion + 1
+	tests := map[string]struct {
+		data []byte
+		err  string
+	}{
+		"empty":         {nil, "not a netplay message"},
+		"other game":    {[]byte("ASRR\x01\x01"), "not a netplay message"},
+		"other version": {otherVersion, "unsupported protocol version"},
+		"unknown type":  {[]byte("ASRN\x01\x63"), "unknown message type"},
+		"truncated":     {snapshot[:len(snapshot)-3], "short message"},
+		"trailing":      {append(Encode(&Bye{}), 0), "trailing bytes"},
+		"early input":   {Encode(&Input{Newest: 1, Inputs: []replay.Input{0, 0}}), "before the first tick"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected an error about %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package netplay
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
+	"avoid_the_space_rocks/internal/scenes/playfield"
+	"encoding/json"
+	"errors"
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"net"
+	"sync"
+	"time"
+)
+
+// ServerConfig is the game a server runs.
+type ServerConfig struct {
+	Players      int // How many clients fly at once, a spaceship each; the game starts once they've all joined
+	Mode         core.Mode
+	Difficulty   core.Difficulty
+	FriendlyFire bool         // The players' bullets destroy each other's spaceships
+	Seed         uint64       // Seed for the game's random source; zero picks one
+	Tuning       *core.Tuning // How the game feels; nil for the defaults
+	Width        float32      // Size of the playfield in worldspace; zero for the size of the window
+	Height       float32
+}
+
+const (
+	// SnapshotEvery is how many ticks go by between snapshots, so clients see the game at 60 frames a
+	// second and show it smoothly in between.
+	SnapshotEvery = 2
+
+	// ClientTimeout is how long a client can go without a word before the server stops waiting for it.
+	// Its spaceship stays in the game with nobody at the controls.
+	ClientTimeout = 5 * time.Second
+
+	// maxPending is the most input the server holds for a client, a quarter of a second's worth. A client
+	// further ahead than that has its oldest input dropped, so a burst of late datagrams can't leave its
+	// spaceship lagging behind its controls for good.
+	maxPending = playfield.TickRate / 4
+)
+
+// Server runs the one true game for its clients, headless. Clients join until every spaceship has a
+// player, then the game runs in real time until it's over or every client has gone.
+type Server struct {
+	config  ServerConfig
+	conn    *net.UDPConn
+	packets chan packet
+	done    chan struct{}
+	close   sync.Once
+
+	welcome Welcome                          // What every client is told when it joins, bar its player
+	sheets  map[*gameobjects.SpriteSheet]int // Index of each sheet in welcome.Sheets
+	clients []*remoteClient                  // Everyone who has joined, in player order
+
+	game   *core.Game // Nil until everyone has joined
+	tick   uint32
+	ids    map[gameobjects.GameObject]uint32 // ID of every object in the last snapshot
+	nextID uint32
+}
+
+// remoteClient is one player, as far as the server knows.
+type remoteClient struct {
+	addr     *net.UDPAddr
+	player   int
+	heardAt  time.Time     // When the client was last heard from
+	gone     bool          // The client said goodbye or timed out
+	received uint32        // Newest input received
+	applied  uint32        // Newest input applied to the game
+	pending  []queuedInput // Input received but not yet applied, oldest first
+	held     replay.Input  // Controls held down in the input last applied, which stay held until the next
+}
+
+type queuedInput struct {
+	seq   uint32
+	input replay.Input
+}
+
+type packet struct {
+	addr *net.UDPAddr
+	data []byte
+}
+
+// Listen starts a server listening on the UDP address, such as ":7777" or "127.0.0.1:0". Call Run to
+// play the game.
+func Listen(address string, config ServerConfig) (*Server, error) {
+	if config.Players < 1 || config.Players > 255 {
+		return nil, fmt.Errorf("can't play with %d players", config.Players)
+	}
+	if config.Tuning == nil {
+		config.Tuning = core.DefaultTuning()
+	}
+	if config.Width == 0 || config.Height == 0 {
+		config.Width, config.Height = 1024, 768
+	}
+	tuning, err := json.Marshal(config.Tuning)
+	if err != nil {
+		return nil, fmt.Errorf("encoding tuning: %w", err)
+	}
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config:  config,
+		conn:    conn,
+		packets: make(chan packet, 64),
+		done:    make(chan struct{}),
+		welcome: Welcome{
+			Players:       config.Players,
+			TickRate:      playfield.TickRate,
+			SnapshotEvery: SnapshotEvery,
+			Width:         config.Width,
+			Height:        config.Height,
+			Mode:          int(config.Mode),
+			Difficulty:    int(config.Difficulty),
+			Tuning:        tuning,
+		},
+		sheets: make(map[*gameobjects.SpriteSheet]int),
+		ids:    make(map[gameobjects.GameObject]uint32),
+		nextID: uint32(config.Players),
+	}
+	for i, sheet := range core.SpriteSheets() {
+		file, rows, cols := sheet.Layout()
+		s.welcome.Sheets = append(s.welcome.Sheets, Sheet{File: file, Rows: rows, Cols: cols})
+		s.sheets[sheet] = i
+	}
+	platform.Log(rl.LogInfo, "Server listening on %s for %d players", conn.LocalAddr(), config.Players)
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Close stops the server. Run returns once it has.
+func (s *Server) Close() error {
+	var err error
+	s.close.Do(func() {
+		close(s.done)
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// Game returns the game being played, or nil if the players are still joining. It's only safe to look
+// at once Run has returned.
+func (s *Server) Game() *core.Game {
+	return s.game
+}
+
+// Run waits for the players to join, then plays the game in real time until it's over, every client
+// has gone, or the server is closed.
+func (s *Server) Run() error {
+	defer s.Close()
+	go s.read()
+
+	ticker := time.NewTicker(time.Second / playfield.TickRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case p, ok := <-s.packets:
+			if !ok {
+				return errors.New("server connection closed")
+			}
+			s.handle(p)
+		case now := <-ticker.C:
+			if s.game == nil {
+				continue
+			}
+			if !s.checkClients(now) {
+				platform.Log(rl.LogInfo, "Everyone has left, so the game is over")
+				return nil
+			}
+			s.step()
+			if s.game.Over {
+				platform.Log(rl.LogInfo, "Game over on level %d with a score of %d", s.game.Level, s.game.Score)
+				// Say so a few times, in case a datagram goes astray
+				for range 3 {
+					s.sendSnapshots()
+				}
+				return nil
+			}
+			if s.tick%SnapshotEvery == 0 {
+				s.sendSnapshots()
+			}
+		}
+	}
+}
+
+// read passes every datagram that arrives on to Run, until the connection is closed.
+func (s *Server) read() {
+	defer close(s.packets)
+	buf := make([]byte, maxDatagram)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				platform.Log(rl.LogError, "error reading from network: %v", err)
+			}
+			return
+		}
+		select {
+		case s.packets <- packet{addr: addr, data: append([]byte(nil), buf[:n]...)}:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// handle acts on a datagram from a client. Anything that isn't a message from this version of the
+// protocol is ignored.
+func (s *Server) handle(p packet) {
+	msg, err := Decode(p.data)
+	if err != nil {
+		platform.Log(rl.LogDebug, "Ignoring datagram from %s: %v", p.addr, err)
+		return
+	}
+	c := s.clientAt(p.addr)
+	if c != nil && !c.gone {
+		c.heardAt = time.Now()
+	}
+	switch m := msg.(type) {
+	case *Hello:
+		s.join(c, p.addr)
+	case *Input:
+		if c != nil && !c.gone {
+			s.receive(c, m)
+		}
+	case *Bye:
+		if c != nil && !c.gone {
+			platform.Log(rl.LogInfo, "Player %d left", c.player+1)
+			s.leave(c)
+		}
+	}
+}
+
+// clientAt returns the client at the address, or nil if nobody there has joined.
+func (s *Server) clientAt(addr *net.UDPAddr) *remoteClient {
+	for _, c := range s.clients {
+		if c.addr.String() == addr.String() {
+			return c
+		}
+	}
+	return nil
+}
+
+// join welcomes a new client if there's room, starting the game once there's no more. A client that
+// has already joined is welcomed again, since it wouldn't ask if the first welcome had arrived.
+func (s *Server) join(c *remoteClient, addr *net.UDPAddr) {
+	if c == nil {
+		if len(s.clients) == s.config.Players {
+			s.send(addr, &Reject{Reason: "the game is full"})
+			return
+		}
+		c = &remoteClient{addr: addr, player: len(s.clients), heardAt: time.Now()}
+		s.clients = append(s.clients, c)
+		platform.Log(rl.LogInfo, "Player %d joined from %s", c.player+1, addr)
+	}
+	welcome := s.welcome
+	welcome.Player = c.player
+	s.send(addr, &welcome)
+	if s.game == nil && len(s.clients) == s.config.Players {
+		s.start()
+	}
+}
+
+// receive queues up the input the client hasn't sent before. Until the game starts there's nothing to
+// apply it to, so it's counted as applied straight away.
+func (s *Server) receive(c *remoteClient, m *Input) {
+	first := m.Newest - uint32(len(m.Inputs)) + 1
+	for i, input := range m.Inputs {
+		seq := first + uint32(i)
+		if seq <= c.received {
+			continue
+		}
+		c.received = seq
+		if s.game == nil {
+			c.applied = seq
+			continue
+		}
+		c.pending = append(c.pending, queuedInput{seq: seq, input: input})
+	}
+	if excess := len(c.pending) - maxPending; excess > 0 {
+		c.applied = c.pending[excess-1].seq
+		c.pending = c.pending[excess:]
+	}
+}
+
+// leave stops waiting for the client. Its spaceship carries on with nobody at the controls.
+func (s *Server) leave(c *remoteClient) {
+	c.gone = true
+	c.pending = nil
+	c.held = 0
+}
+
+// checkClients times out any client that has gone quiet, and returns false if there's nobody left.
+func (s *Server) checkClients(now time.Time) bool {
+	anyone := false
+	for _, c := range s.clients {
+		if !c.gone && now.Sub(c.heardAt) > ClientTimeout {
+			platform.Log(rl.LogWarning, "Player %d timed out", c.player+1)
+			s.leave(c)
+		}
+		anyone = anyone || !c.gone
+	}
+	return anyone
+}
+
+// start sets up the game once everyone has joined, the same way the playfield does.
+func (s *Server) start() {
+	config := s.config
+	game := core.NewGame(config.Width, config.Height, config.Seed)
+	game.SetDifficulty(config.Difficulty)
+	game.Mode = config.Mode
+	game.Ships = config.Players
+	game.FriendlyFire = config.FriendlyFire
+	game.Tuning = config.Tuning
+	game.World.Initialize(game)
+
+	warden := playfield.NewGameWarden()
+	game.Observers = append(game.Observers, playfield.NewScoreKeeper(), warden)
+	for _, obs := range game.Observers {
+		if err := obs.Register(game); err != nil {
+			platform.Log(rl.LogError, "error registering observer: %v", err)
+		}
+	}
+	warden.Start()
+	s.game = game
+	platform.Log(rl.LogInfo, "Everyone has joined; starting a %s %s game", game.Mode, game.Difficulty)
+}
+
+// step applies each client's next input and advances the game by a tick. A client whose input hasn't
+// arrived yet keeps holding whatever it held, but presses nothing new.
+func (s *Server) step() {
+	inputs := make([]replay.Input, len(s.clients))
+	for i, c := range s.clients {
+		inputs[i] = c.held
+		if len(c.pending) > 0 {
+			next := c.pending[0]
+			c.pending = c.pending[1:]
+			c.applied = next.seq
+			inputs[i] = next.input
+			c.held = next.input &^ (replay.Fire | replay.Hyperspace)
+		}
+	}
+	playfield.ApplyInputs(s.game, inputs, playfield.TickDelta)
+	s.game.Update(playfield.TickDelta)
+	s.tick++
+}
+
+// sendSnapshots sends every client still playing the state of the game, with the newest of its input
+// that's been applied.
+func (s *Server) sendSnapshots() {
+	snapshot := s.snapshot()
+	for _, c := range s.clients {
+		if c.gone {
+			continue
+		}
+		snapshot.Ack = c.applied
+		s.send(c.addr, &snapshot)
+	}
+}
+
+// snapshot returns the state of the game, giving each object an ID it keeps for as long as it's around.
+func (s *Server) snapshot() Snapshot {
+	game := s.game
+	snapshot := Snapshot{Tick: s.tick, Level: game.Level, Score: game.Score, Over: game.Over}
+	for _, ship := range game.World.Spaceships {
+		snapshot.Ships = append(snapshot.Ships, ShipState{
+			Alive:        ship.IsAlive(),
+			Out:          ship.Out,
+			InHyperspace: ship.InHyperspace,
+			FuelBurning:  ship.FuelBurning,
+			Lives:        ship.Lives,
+			Score:        ship.Score,
+			Position:     ship.Position,
+			Velocity:     ship.Velocity,
+			Rotation:     ship.Rotation,
+		})
+	}
+	ids := make(map[gameobjects.GameObject]uint32, len(s.ids))
+	game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
+		sprite, ok := core.SpriteOf(obj)
+		sheet, known := s.sheets[sprite.Sheet]
+		if !ok || !known || !obj.IsAlive() {
+			return
+		}
+		id, seen := s.ids[obj]
+		if ship, isShip := obj.(*core.Spaceship); isShip {
+			id = uint32(ship.Player)
+		} else if !seen {
+			id = s.nextID
+			s.nextID++
+		}
+		ids[obj] = id
+		snapshot.Sprites = append(snapshot.Sprites, SpriteState{
+			ID:       id,
+			Sheet:    sheet,
+			Row:      sprite.Row,
+			Col:      sprite.Col,
+			Position: sprite.Position,
+			Rotation: sprite.Rotation,
+		})
+	})
+	s.ids = ids
+	return snapshot
+}
+
+// send sends a message to a client, logging rather than failing if it can't; the client will ask again.
+func (s *Server) send(addr *net.UDPAddr, m Message) {
+	if _, err := s.conn.WriteToUDP(Encode(m), addr); err != nil {
+		platform.Log(rl.LogWarning, "error sending to %s: %v", addr, err)
+	}
+}
@@ -0,0 +1,104 @@
+package netplay
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/replay"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestMain(m *testing.M) {
+	// Change to the project root directory, where the sprites the server measures hitboxes from are
+	if err := os.Chdir("../.."); err != nil {
+		panic("failed to change to project root directory: " + err.Error())
+	}
+	os.Exit(m.Run())
+}
+
+func TestServer_PlaysOverLoopback(t *testing.T) {
+	server, err := Listen("127.0.0.1:0", ServerConfig{Players: 2, Mode: core.ModeZen, Seed: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %v", err)
+	}
+	defer server.Close()
+	finished := make(chan error)
+	go func() { finished <- server.Run() }()
+
+	address := server.Addr().String()
+	first, err := Dial(address)
+	if err != nil {
+		t.Fatalf("Unexpected error joining: %v", err)
+	}
+	defer first.Close()
+	second, err := Dial(address)
+	if err != nil {
+		t.Fatalf("Unexpected error joining: %v", err)
+	}
+	defer second.Close()
+	if first.Welcome().Player != 0 || second.Welcome().Player != 1 || first.Welcome().Players != 2 {
+		t.Errorf("Expected to be players 1 and 2 of 2, got %+v and %+v", first.Welcome(), second.Welcome())
+	}
+	if _, err := Dial(address); err == nil || !strings.Contains(err.Error(), "full") {
+		t.Errorf("Expected a third player to be turned away, got %v", err)
+	}
+
+	// The first player turns and thrusts for a second while the second sits still
+	ticker := time.NewTicker(time.Second / 120)
+	defer ticker.Stop()
+	for range 120 {
+		<-ticker.C
+		if err := first.Send(replay.Thrust | replay.RotateLeft); err != nil {
+			t.Fatalf("Unexpected error sending: %v", err)
+		}
+		if err := second.Send(0); err != nil {
+			t.Fatalf("Unexpected error sending: %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The game ends once everyone has gone
+	_ = first.Close()
+	_ = second.Close()
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Errorf("Unexpected error running the server: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected the server to stop once everyone left")
+	}
+
+	snapshot, ok := first.Latest()
+	if !ok {
+		t.Fatalf("Expected snapshots from the server")
+	}
+	if snapshot.Ack < 60 || snapshot.Tick < 60 {
+		t.Errorf("Expected the server well into the game, at tick %d with input %d", snapshot.Tick, snapshot.Ack)
+	}
+	ship := snapshot.Ships[0]
+	spawn := rl.Vector2{X: 1024.0 / 3, Y: 768.0 / 2}
+	if rl.Vector2Distance(ship.Position, spawn) < 50 || ship.Rotation.Y == -1 {
+		t.Errorf("Expected the first spaceship to have flown off from %v, at %v facing %v", spawn, ship.Position, ship.Rotation)
+	}
+	still := snapshot.Ships[1]
+	if still.Position != (rl.Vector2{X: 1024.0 * 2 / 3, Y: 768.0 / 2}) {
+		t.Errorf("Expected the second spaceship where it spawned, at %v", still.Position)
+	}
+	// Everything sent has been acknowledged, so the prediction is where the server says
+	for _, sprite := range first.Sprites(time.Now()) {
+		if file, _, _ := sprite.Sheet.Layout(); file == "spaceship.png" && sprite.Position != still.Position &&
+			rl.Vector2Distance(sprite.Position, ship.Position) > 1 {
+			t.Errorf("Expected the prediction to agree with the server at %v, got %v", ship.Position, sprite.Position)
+		}
+	}
+	if seen, _ := second.Latest(); len(seen.Sprites) < 2 {
+		t.Errorf("Expected the second player to see both spaceships and the rocks, got %v", seen.Sprites)
+	}
+	if game := server.Game(); game == nil || rl.Vector2Distance(game.World.Spaceships[0].Position, spawn) < 50 {
+		t.Errorf("Expected the first spaceship to have flown off in the server's game")
+	}
+}
@@ -0,0 +1,203 @@
+package platform
+
+import (
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"image"
+	_ "image/png"
+	"log"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Headless runs the game with no window, GPU, or audio device. Every frame takes FrameDuration of
+// simulated time and runs as fast as the CPU allows. Drawing and sound do nothing, but textures and
+// sounds are still read from disk so sprite sizes and missing files behave the same as in a window.
+// Input comes from Press and Hold, usually called from Script, so a test or bot can play the game.
+type Headless struct {
+	FrameDuration float32          // Seconds per frame
+	MaxFrames     int              // Close after this many frames; zero runs until Close is called
+	LogLevel      rl.TraceLogLevel // Messages below this level are dropped
+	Script        func(frame int)  // Called at the end of every frame to set up the input for the next
+
+	lock    sync.Mutex
+	frames  int
+	closed  bool
+	held    map[int32]bool
+	pressed []int32
+}
+
+var _ Platform = (*Headless)(nil)
+
+var logPrefixes = map[rl.TraceLogLevel]string{
+	rl.LogTrace:   "TRACE",
+	rl.LogDebug:   "DEBUG",
+	rl.LogInfo:    "INFO",
+	rl.LogWarning: "WARNING",
+	rl.LogError:   "ERROR",
+	rl.LogFatal:   "FATAL",
+}
+
+// NewHeadless returns a headless platform running at 60 frames per second and logging warnings and errors.
+func NewHeadless() *Headless {
+	return &Headless{
+		FrameDuration: 1.0 / 60,
+		LogLevel:      rl.LogWarning,
+		held:          make(map[int32]bool),
+	}
+}
+
+// Press presses the key for the current frame.
+func (h *Headless) Press(key int32) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.pressed = append(h.pressed, key)
+}
+
+// Hold holds the key down, or lets it go, until told otherwise.
+func (h *Headless) Hold(key int32, down bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.held[key] = down
+}
+
+// Close makes ShouldClose return true, as if the player closed the window.
+func (h *Headless) Close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.closed = true
+}
+
+// Frames returns how many frames have finished.
+func (h *Headless) Frames() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.frames
+}
+
+func (h *Headless) Time() float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return float64(h.frames) * float64(h.FrameDuration)
+}
+
+func (h *Headless) FrameTime() float32 {
+	return h.FrameDuration
+}
+
+func (h *Headless) ShouldClose() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.closed || (h.MaxFrames > 0 && h.frames >= h.MaxFrames)
+}
+
+func (h *Headless) IsKeyDown(key int32) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.held[key] || slices.Contains(h.pressed, key)
+}
+
+func (h *Headless) IsKeyPressed(key int32) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return slices.Contains(h.pressed, key)
+}
+
+func (h *Headless) KeyPressed() int32 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.pressed) == 0 {
+		return rl.KeyNull
+	}
+	key := h.pressed[0]
+	h.pressed = h.pressed[1:]
+	return key
+}
+
+func (h *Headless) BeginFrame() {
+}
+
+// EndFrame finishes the frame; presses only last for the frame they happened in.
+func (h *Headless) EndFrame() {
+	h.lock.Lock()
+	h.frames++
+	h.pressed = h.pressed[:0]
+	frame := h.frames
+	h.lock.Unlock()
+	if h.Script != nil {
+		h.Script(frame)
+	}
+}
+
+// LoadTexture reads just the size of the image.
+func (h *Headless) LoadTexture(path string) (Texture, error) {
+	width, height, err := ImageSize(path)
+	if err != nil {
+		return Texture{}, err
+	}
+	return Texture{Width: width, Height: height}, nil
+}
+
+// ImageSize reads the size of the image at path without loading the rest of it. Every platform's
+// textures come out this size, so it can be known before there's a platform to load them on.
+func ImageSize(path string) (width, height int32, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not load texture %s: %w", path, err)
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not load texture %s: %w", path, err)
+	}
+	return int32(config.Width), int32(config.Height), nil
+}
+
+func (h *Headless) DrawTexture(_ Texture, _, _ rl.Rectangle, _ rl.Vector2, _ float32) {
+}
+
+func (h *Headless) DrawTextureTinted(_ Texture, _, _ rl.Rectangle, _ rl.Vector2, _ float32, _ rl.Color) {
+}
+
+// MeasureText guesses the size of the text, as if every character were as wide as it is tall.
+func (h *Headless) MeasureText(text string, fontSize float32) rl.Vector2 {
+	return rl.Vector2{X: float32(len(text)) * fontSize, Y: fontSize}
+}
+
+func (h *Headless) DrawText(_ string, _ rl.Vector2, _ float32) {
+}
+
+func (h *Headless) LoadSound(path string) (*Sound, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("could not load sound %s: %w", path, err)
+	}
+	return &Sound{}, nil
+}
+
+func (h *Headless) PlaySound(_ *Sound) {
+}
+
+func (h *Headless) LoadMusic(path string) (*Music, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("could not load music %s: %w", path, err)
+	}
+	return &Music{}, nil
+}
+
+func (h *Headless) PlayMusic(_ *Music) {
+}
+
+func (h *Headless) StopMusic(_ *Music) {
+}
+
+func (h *Headless) UpdateMusic(_ *Music) {
+}
+
+// Log writes the message to the standard logger, with the same level prefixes as raylib.
+func (h *Headless) Log(level rl.TraceLogLevel, format string, args ...any) {
+	if level < h.LogLevel {
+		return
+	}
+	log.Printf(logPrefixes[level]+": "+format, args...)
+}
@@ -0,0 +1,74 @@
+package platform
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"testing"
+)
+
+func TestHeadless_LoadTexture(t *testing.T) {
+	h := NewHeadless()
+
+	texture, err := h.LoadTexture("../../assets/sprites/alien_big.png")
+	if err != nil {
+		t.Fatalf("Unexpected error loading texture: %v", err)
+	}
+	if texture.Width != 108 || texture.Height != 64 {
+		t.Errorf("Expected texture to be 108x64, got %dx%d", texture.Width, texture.Height)
+	}
+
+	if _, err := h.LoadTexture("../../assets/sprites/non_existent_file.png"); err == nil {
+		t.Errorf("Expected an error loading a missing texture")
+	}
+}
+
+func TestHeadless_LoadSound(t *testing.T) {
+	h := NewHeadless()
+
+	if _, err := h.LoadSound("../../assets/audio/fire.wav"); err != nil {
+		t.Errorf("Unexpected error loading sound: %v", err)
+	}
+	if _, err := h.LoadMusic("../../assets/audio/non_existent_file.wav"); err == nil {
+		t.Errorf("Expected an error loading missing music")
+	}
+}
+
+func TestHeadless_Frames(t *testing.T) {
+	h := NewHeadless()
+	h.MaxFrames = 3
+	h.Script = func(frame int) {
+		if frame == 1 {
+			h.Press(rl.KeySpace)
+		}
+	}
+
+	pressed := make([]bool, 0, 3)
+	for !h.ShouldClose() {
+		h.BeginFrame()
+		pressed = append(pressed, h.IsKeyPressed(rl.KeySpace))
+		h.EndFrame()
+	}
+
+	if len(pressed) != 3 || pressed[0] || !pressed[1] || pressed[2] {
+		t.Errorf("Expected space pressed on the second of three frames only, got %v", pressed)
+	}
+	if h.Time() != 3*float64(h.FrameDuration) {
+		t.Errorf("Expected three frames of time to pass, got %f", h.Time())
+	}
+}
+
+func TestHeadless_Hold(t *testing.T) {
+	h := NewHeadless()
+	h.Hold(rl.KeyUp, true)
+	h.EndFrame()
+
+	if !h.IsKeyDown(rl.KeyUp) {
+		t.Errorf("Expected held key to stay down across frames")
+	}
+	if h.IsKeyPressed(rl.KeyUp) {
+		t.Errorf("Expected held key not to count as pressed")
+	}
+	h.Hold(rl.KeyUp, false)
+	if h.IsKeyDown(rl.KeyUp) {
+		t.Errorf("Expected released key to be up")
+	}
+}
@@ -0,0 +1,82 @@
+// Package platform is everything the game needs from the machine it runs on: time, input, textures,
+// drawing, audio, and logging. The game draws and plays through a Platform it's handed rather than calling
+// raylib directly, so the same simulation can run in a window or headless in CI, bots, and servers.
+package platform
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"sync/atomic"
+)
+
+// Platform is one way of running the game. Whatever runs the game loop owns the platform and passes it
+// to everything that reads input or draws, so games never reach for one of their own.
+type Platform interface {
+	// Time returns the wall clock seconds since the platform started.
+	Time() float64
+	// FrameTime returns how many seconds the last frame took.
+	FrameTime() float32
+	// ShouldClose returns true once the player has asked to quit, e.g. by closing the window.
+	ShouldClose() bool
+
+	// IsKeyDown returns true while the key is held.
+	IsKeyDown(key int32) bool
+	// IsKeyPressed returns true on the frame the key went down.
+	IsKeyPressed(key int32) bool
+	// KeyPressed returns a key pressed this frame, or rl.KeyNull if there are none.
+	KeyPressed() int32
+
+	// BeginFrame clears the screen for drawing; EndFrame shows it and collects the next frame's input.
+	BeginFrame()
+	EndFrame()
+	LoadTexture(path string) (Texture, error)
+	DrawTexture(texture Texture, source, destination rl.Rectangle, origin rl.Vector2, rotation float32)
+	// DrawTextureTinted draws like DrawTexture, multiplying the texture's colours by the tint.
+	DrawTextureTinted(texture Texture, source, destination rl.Rectangle, origin rl.Vector2, rotation float32, tint rl.Color)
+	MeasureText(text string, fontSize float32) rl.Vector2
+	DrawText(text string, position rl.Vector2, fontSize float32)
+
+	LoadSound(path string) (*Sound, error)
+	PlaySound(sound *Sound)
+	LoadMusic(path string) (*Music, error)
+	PlayMusic(music *Music)
+	StopMusic(music *Music)
+	UpdateMusic(music *Music)
+
+	Log(level rl.TraceLogLevel, format string, args ...any)
+}
+
+// Texture is an image loaded for drawing. Only the size is meaningful outside the platform.
+type Texture struct {
+	Width  int32
+	Height int32
+	native rl.Texture2D
+}
+
+// Sound is a short clip loaded for playing.
+type Sound struct {
+	native rl.Sound
+}
+
+// Music is a looping clip that streams while it plays.
+type Music struct {
+	native rl.Music
+}
+
+// logger is where Log writes. It's the only part of the platform that's shared by the whole process,
+// so anything can log without being handed a platform; nothing else may go through it.
+var logger atomic.Pointer[Platform]
+
+func init() {
+	LogTo(NewHeadless())
+}
+
+// LogTo makes Log write to p's log. Call it at startup, once the platform the game runs on is open.
+func LogTo(p Platform) {
+	logger.Store(&p)
+}
+
+// Log writes a message to the log of the platform last passed to LogTo, which until then is a
+// headless platform's.
+func Log(level rl.TraceLogLevel, format string, args ...any) {
+	(*logger.Load()).Log(level, format, args...)
+}
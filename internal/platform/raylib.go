@@ -0,0 +1,134 @@
+package platform
+
+import (
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"sync"
+)
+
+const fontSpacing = 2.0
+
+// Raylib runs the game in a window with sound, drawing with the GPU.
+type Raylib struct {
+	font     rl.Font
+	fontOnce sync.Once
+}
+
+var _ Platform = (*Raylib)(nil)
+
+// OpenRaylib opens the game window and the audio device. Close them with Close.
+func OpenRaylib(width, height int32, title string) *Raylib {
+	rl.InitWindow(width, height, title)
+	rl.InitAudioDevice()
+	rl.SetTargetFPS(60)
+	rl.SetExitKey(rl.KeyNull)
+	return &Raylib{}
+}
+
+// Close shuts the audio device and the window.
+func (r *Raylib) Close() {
+	rl.CloseAudioDevice()
+	rl.CloseWindow()
+}
+
+func (r *Raylib) Time() float64 {
+	return rl.GetTime()
+}
+
+func (r *Raylib) FrameTime() float32 {
+	return rl.GetFrameTime()
+}
+
+func (r *Raylib) ShouldClose() bool {
+	return rl.WindowShouldClose()
+}
+
+func (r *Raylib) IsKeyDown(key int32) bool {
+	return rl.IsKeyDown(key)
+}
+
+func (r *Raylib) IsKeyPressed(key int32) bool {
+	return rl.IsKeyPressed(key)
+}
+
+func (r *Raylib) KeyPressed() int32 {
+	return rl.GetKeyPressed()
+}
+
+func (r *Raylib) BeginFrame() {
+	rl.BeginDrawing()
+	rl.ClearBackground(rl.RayWhite)
+}
+
+func (r *Raylib) EndFrame() {
+	rl.EndDrawing()
+}
+
+func (r *Raylib) LoadTexture(path string) (Texture, error) {
+	texture := rl.LoadTexture(path)
+	if !rl.IsTextureValid(texture) {
+		return Texture{}, fmt.Errorf("could not load texture %s", path)
+	}
+	return Texture{Width: texture.Width, Height: texture.Height, native: texture}, nil
+}
+
+func (r *Raylib) DrawTexture(texture Texture, source, destination rl.Rectangle, origin rl.Vector2, rotation float32) {
+	rl.DrawTexturePro(texture.native, source, destination, origin, rotation, rl.Black)
+}
+
+func (r *Raylib) DrawTextureTinted(texture Texture, source, destination rl.Rectangle, origin rl.Vector2, rotation float32, tint rl.Color) {
+	rl.DrawTexturePro(texture.native, source, destination, origin, rotation, tint)
+}
+
+func (r *Raylib) MeasureText(text string, fontSize float32) rl.Vector2 {
+	return rl.MeasureTextEx(*r.getFont(), text, fontSize, fontSpacing)
+}
+
+func (r *Raylib) DrawText(text string, position rl.Vector2, fontSize float32) {
+	rl.DrawTextEx(*r.getFont(), text, position, fontSize, fontSpacing, rl.Black)
+}
+
+func (r *Raylib) LoadSound(path string) (*Sound, error) {
+	sound := rl.LoadSound(path)
+	if sound.Stream.Buffer == nil || !rl.IsSoundValid(sound) {
+		return nil, fmt.Errorf("could not load sound %s", path)
+	}
+	return &Sound{native: sound}, nil
+}
+
+func (r *Raylib) PlaySound(sound *Sound) {
+	rl.PlaySound(sound.native)
+}
+
+func (r *Raylib) LoadMusic(path string) (*Music, error) {
+	music := rl.LoadMusicStream(path)
+	if music.Stream.Buffer == nil || !rl.IsMusicValid(music) {
+		return nil, fmt.Errorf("could not load music %s", path)
+	}
+	music.Looping = true
+	return &Music{native: music}, nil
+}
+
+func (r *Raylib) PlayMusic(music *Music) {
+	rl.PlayMusicStream(music.native)
+}
+
+func (r *Raylib) StopMusic(music *Music) {
+	rl.StopMusicStream(music.native)
+}
+
+func (r *Raylib) UpdateMusic(music *Music) {
+	rl.UpdateMusicStream(music.native)
+}
+
+func (r *Raylib) Log(level rl.TraceLogLevel, format string, args ...any) {
+	rl.TraceLog(level, format, args...)
+}
+
+// getFont loads the game font the first time it's needed; it can't be loaded before the window opens.
+func (r *Raylib) getFont() *rl.Font {
+	r.fontOnce.Do(func() {
+		r.font = rl.LoadFontEx("assets/fonts/Orbitron-Regular.ttf", 32, nil, 250)
+	})
+	return &r.font
+}
@@ -0,0 +1,235 @@
+// Package replay records the players' input for a game so it can be played back exactly. A game is
+// deterministic given its rules, mode, tuning, difficulty, number of players and ships, friendly fire,
+// playfield size, seed, and the input on every simulation tick, so that's all a replay holds. Replays are
+// small enough to attach to bug reports.
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Input is the state of the player's controls for a single simulation tick, one bit per control.
+type Input uint8
+
+const (
+	RotateLeft Input = 1 << iota
+	RotateRight
+	Thrust
+	Fire
+	Hyperspace
+)
+
+// Has returns true if the control is active in this input.
+func (i Input) Has(control Input) bool {
+	return i&control != 0
+}
+
+// Replay is everything needed to play a game again exactly.
+type Replay struct {
+	RulesVersion int     // The core.RulesVersion the game was played under
+	Seed         uint64  // The game's random seed
+	Width        float32 // Size of the playfield in worldspace
+	Height       float32
+	Tuning       []byte  // The tuning the game was played with, as JSON; empty for the defaults
+	Difficulty   int     // The core.Difficulty the game was played on
+	Mode         int     // The core.Mode the game was played under
+	Players      int     // How many players took turns; zero is one
+	Ships        int     // How many spaceships flew at once; zero is one
+	FriendlyFire bool    // The spaceships' bullets hit each other
+	Inputs       []Input // The input on every tick, in order, with each spaceship's in turn within a tick
+}
+
+// The file starts with magic bytes and a format version, followed by the header fields, the tuning as a
+// uvarint length and its bytes, the difficulty, mode, players, ships, and friendly fire as uvarints, and
+// then the inputs run-length encoded as (uvarint count, input) pairs. Input rarely changes from one tick
+// to the next, so a game of several minutes is a few kilobytes.
+var magic = []byte("ASRR")
+
+const formatVersion = 6
+
+// maxTuning is far bigger than any real tuning file; longer tuning is corrupt.
+const maxTuning = 1 << 20
+
+// maxTicks is a day of play at 120 ticks a second, far longer than any real game; longer replays are corrupt.
+const maxTicks = 120 * 60 * 60 * 24
+
+// Load reads a replay from a file.
+func Load(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r, err := Read(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("reading replay %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Save writes the replay to a file, creating its directory if needed.
+func (r *Replay) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if err := r.Write(w); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write encodes the replay.
+func (r *Replay) Write(w io.Writer) error {
+	buf := make([]byte, 0, 32+len(r.Tuning)+len(r.Inputs)/8)
+	buf = append(buf, magic...)
+	buf = append(buf, formatVersion)
+	buf = binary.AppendUvarint(buf, uint64(r.RulesVersion))
+	buf = binary.LittleEndian.AppendUint64(buf, r.Seed)
+	buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(r.Width))
+	buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(r.Height))
+	buf = binary.AppendUvarint(buf, uint64(len(r.Tuning)))
+	buf = append(buf, r.Tuning...)
+	buf = binary.AppendUvarint(buf, uint64(r.Difficulty))
+	buf = binary.AppendUvarint(buf, uint64(r.Mode))
+	buf = binary.AppendUvarint(buf, uint64(r.Players))
+	buf = binary.AppendUvarint(buf, uint64(r.Ships))
+	friendlyFire := uint64(0)
+	if r.FriendlyFire {
+		friendlyFire = 1
+	}
+	buf = binary.AppendUvarint(buf, friendlyFire)
+	for i := 0; i < len(r.Inputs); {
+		run := 1
+		for i+run < len(r.Inputs) && r.Inputs[i+run] == r.Inputs[i] {
+			run++
+		}
+		buf = binary.AppendUvarint(buf, uint64(run))
+		buf = append(buf, byte(r.Inputs[i]))
+		i += run
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// Read decodes a replay written by Write.
+func Read(reader io.ByteReader) (*Replay, error) {
+	header := make([]byte, len(magic)+1)
+	for i := range header {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("short header: %w", err)
+		}
+		header[i] = b
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return nil, errors.New("not a replay file")
+	}
+	version := header[len(magic)]
+	if version < 1 || version > formatVersion {
+		return nil, fmt.Errorf("unsupported replay format %d", version)
+	}
+
+	r := &Replay{}
+	rules, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules version: %w", err)
+	}
+	r.RulesVersion = int(rules)
+	fixed := make([]byte, 16)
+	for i := range fixed {
+		if fixed[i], err = reader.ReadByte(); err != nil {
+			return nil, fmt.Errorf("short header: %w", err)
+		}
+	}
+	r.Seed = binary.LittleEndian.Uint64(fixed[0:8])
+	r.Width = math.Float32frombits(binary.LittleEndian.Uint32(fixed[8:12]))
+	r.Height = math.Float32frombits(binary.LittleEndian.Uint32(fixed[12:16]))
+	if version >= 2 {
+		// Replays from before tuning could be changed were all played with the defaults
+		size, err := binary.ReadUvarint(reader)
+		if err != nil || size > maxTuning {
+			return nil, fmt.Errorf("reading tuning: bad size %d (%v)", size, err)
+		}
+		if size > 0 {
+			r.Tuning = make([]byte, size)
+			for i := range r.Tuning {
+				if r.Tuning[i], err = reader.ReadByte(); err != nil {
+					return nil, fmt.Errorf("reading tuning: %w", err)
+				}
+			}
+		}
+	}
+	if version >= 3 {
+		// Older replays were all played on the zero difficulty, Normal
+		difficulty, err := binary.ReadUvarint(reader)
+		if err != nil || difficulty > math.MaxInt8 {
+			return nil, fmt.Errorf("reading difficulty: bad difficulty %d (%v)", difficulty, err)
+		}
+		r.Difficulty = int(difficulty)
+	}
+	if version >= 4 {
+		// Older replays were all played under the zero mode, Classic
+		mode, err := binary.ReadUvarint(reader)
+		if err != nil || mode > math.MaxInt8 {
+			return nil, fmt.Errorf("reading mode: bad mode %d (%v)", mode, err)
+		}
+		r.Mode = int(mode)
+	}
+	if version >= 5 {
+		// Older replays were all one player
+		players, err := binary.ReadUvarint(reader)
+		if err != nil || players > math.MaxInt8 {
+			return nil, fmt.Errorf("reading players: bad number of players %d (%v)", players, err)
+		}
+		r.Players = int(players)
+	}
+	if version >= 6 {
+		// Older replays all had the one spaceship
+		ships, err := binary.ReadUvarint(reader)
+		if err != nil || ships > math.MaxInt8 {
+			return nil, fmt.Errorf("reading ships: bad number of ships %d (%v)", ships, err)
+		}
+		r.Ships = int(ships)
+		friendlyFire, err := binary.ReadUvarint(reader)
+		if err != nil || friendlyFire > 1 {
+			return nil, fmt.Errorf("reading friendly fire: bad value %d (%v)", friendlyFire, err)
+		}
+		r.FriendlyFire = friendlyFire == 1
+	}
+
+	for {
+		run, err := binary.ReadUvarint(reader)
+		if errors.Is(err, io.EOF) {
+			return r, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading inputs: %w", err)
+		}
+		if run == 0 || uint64(len(r.Inputs))+run > maxTicks*uint64(max(1, r.Ships)) {
+			return nil, fmt.Errorf("bad run of %d inputs after tick %d", run, len(r.Inputs))
+		}
+		input, err := reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading inputs: %w", err)
+		}
+		for range run {
+			r.Inputs = append(r.Inputs, Input(input))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package replay
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReplay_RoundTrip(t *testing.T) {
+	original := &Replay{
+		RulesVersion: 3,
+		Seed:         1234567890123,
+		Width:        1024,
+		Height:       768,
+		Tuning:       []byte(`{"ship":{"max_lives":5}}`),
+		Difficulty:   2,
+		Mode:         3,
+		Players:      2,
+		Ships:        3,
+		FriendlyFire: true,
+		Inputs:       []Input{0, 0, 0, Thrust, Thrust | RotateLeft, Fire, 0, 0, Hyperspace},
+	}
+
+	var buf bytes.Buffer
+	if err := original.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error writing: %v", err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error reading: %v", err)
+	}
+
+	if read.RulesVersion != 3 || read.Seed != original.Seed || read.Width != 1024 || read.Height != 768 {
+		t.Errorf("Expected header %+v, got %+v", original, read)
+	}
+	if string(read.Tuning) != string(original.Tuning) || read.Difficulty != 2 || read.Mode != 3 || read.Players != 2 {
+		t.Errorf("Expected tuning %s on difficulty 2 in mode 3 for 2 players, got %s on %d in mode %d for %d",
+			original.Tuning, read.Tuning, read.Difficulty, read.Mode, read.Players)
+	}
+	if read.Ships != 3 || !read.FriendlyFire {
+		t.Errorf("Expected 3 ships with friendly fire, got %d ships with friendly fire %v", read.Ships, read.FriendlyFire)
+	}
+	if !slices.Equal(read.Inputs, original.Inputs) {
+		t.Errorf("Expected inputs %v, got %v", original.Inputs, read.Inputs)
+	}
+}
+
+func TestRead_FormatVersion1(t *testing.T) {
+	// Replays from before they held tuning: rules 1, seed 42, 800x600, then three ticks of thrust
+	data := []byte("ASRR\x01\x01\x2a\x00\x00\x00\x00\x00\x00\x00\x00\x00\x48\x44\x00\x00\x16\x44\x03\x04")
+	r, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error reading: %v", err)
+	}
+	if r.Seed != 42 || r.Width != 800 || r.Height != 600 || r.Tuning != nil || r.Difficulty != 0 || r.Mode != 0 {
+		t.Errorf("Expected seed 42 on 800x600 with default tuning, difficulty, and mode, got %+v", r)
+	}
+	if !slices.Equal(r.Inputs, []Input{Thrust, Thrust, Thrust}) {
+		t.Errorf("Expected three ticks of thrust, got %v", r.Inputs)
+	}
+}
+
+func TestReplay_Compact(t *testing.T) {
+	// Ten minutes of holding thrust then ten minutes of nothing
+	r := &Replay{Inputs: make([]Input, 120*60*20)}
+	for i := range len(r.Inputs) / 2 {
+		r.Inputs[i] = Thrust
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error writing: %v", err)
+	}
+	if buf.Len() > 64 {
+		t.Errorf("Expected a long unchanging input to encode in a few bytes, took %d", buf.Len())
+	}
+}
+
+func TestReplay_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replays", "test.replay")
+	original := &Replay{RulesVersion: 1, Seed: 42, Width: 800, Height: 600, Inputs: []Input{Fire, 0, Fire}}
+
+	if err := original.Save(path); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+	if loaded.Seed != 42 || !slices.Equal(loaded.Inputs, original.Inputs) {
+		t.Errorf("Expected %+v, got %+v", original, loaded)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":          {},
+		"wrong magic":    []byte("RIFF\x01\x01"),
+		"future version": []byte("ASRR\x09\x01"),
+		"short header":   []byte("ASRR\x01\x01\x2a"),
+	}
+	for name, data := range tests {
+		if _, err := Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -1,89 +1,196 @@
 package attractmode
 
 import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/platform"
 	"avoid_the_space_rocks/internal/scenes"
 	"avoid_the_space_rocks/internal/utils"
+	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"slices"
 	"time"
 )
 
 type AttractMode struct {
-	width  float32
-	height float32
+	Session *scenes.Session
+	width   float32
+	height  float32
+
+	hasSave  bool // Offer to continue the saved game
+	selected int  // The menu entry chosen when there's a saved game
 }
 
+// Menu entries shown when there's a saved game to continue
+const (
+	menuNewGame = iota
+	menuContinue
+)
+
 var _ scenes.Scene = (*AttractMode)(nil)
 
 func (am *AttractMode) Init(width, height float32) {
 	am.width = width
 	am.height = height
+	am.hasSave = am.Session.HasSave()
+	if am.hasSave {
+		am.selected = menuContinue
+	}
 }
 
 func (am *AttractMode) Close() {
 }
 
 func (am *AttractMode) Loop() scenes.SceneCode {
+	p := am.Session.Platform
 	screenDuration := time.Second * time.Duration(5)
-	lastSwitchTime := rl.GetTime()
+	lastSwitchTime := p.Time()
 	currentScreen := 0
 
-	for !rl.WindowShouldClose() {
+	for !p.ShouldClose() {
 
-		key := rl.GetKeyPressed()
+		key := p.KeyPressed()
 		if key == rl.KeyEscape {
 			return scenes.Quit
+		} else if key == rl.KeyLeft || key == rl.KeyRight {
+			am.changeDifficulty(key)
+		} else if key == rl.KeyTab {
+			am.changeMode()
+		} else if key == rl.KeyF {
+			am.Session.FriendlyFire = !am.Session.FriendlyFire
+		} else if key == rl.KeyOne || key == rl.KeyTwo || key == rl.KeyC {
+			am.startPlayers(key)
+			return scenes.GameplayScene
+		} else if am.hasSave {
+			if code, ok := am.handleMenuKey(key); ok {
+				return code
+			}
 		} else if key != rl.KeyNull {
 			return scenes.GameplayScene
 		}
 
-		rl.BeginDrawing()
-		rl.ClearBackground(rl.RayWhite)
+		p.BeginFrame()
 
-		if rl.GetTime()-lastSwitchTime >= screenDuration.Seconds() {
+		if p.Time()-lastSwitchTime >= screenDuration.Seconds() {
 			currentScreen = (currentScreen + 1) % 3
-			lastSwitchTime = rl.GetTime()
+			lastSwitchTime = p.Time()
 		}
 
 		switch currentScreen {
 		case 0:
-			am.titleScreen()
+			am.titleScreen(p)
 		case 1:
-			am.howToPlayScreen()
+			am.howToPlayScreen(p)
 		case 2:
-			am.keymappingsScreen()
+			am.keymappingsScreen(p)
 		}
 
-		utils.CenterText("Press any key to start", rl.Vector2{X: am.width / 2, Y: am.height - 100}, 20)
-		rl.EndDrawing()
+		if am.hasSave {
+			am.drawMenu(p)
+		} else {
+			utils.CenterText(p, "Press any key to start", rl.Vector2{X: am.width / 2, Y: am.height - 100}, 20)
+		}
+		am.drawSettings(p)
+		p.EndFrame()
 	}
 
 	return scenes.Quit
 }
 
-func (am *AttractMode) titleScreen() {
-	utils.CenterText("Avoid", rl.Vector2{X: am.width / 2, Y: am.height/3 - 55}, 80)
-	utils.CenterText("the", rl.Vector2{X: am.width / 2, Y: am.height / 3}, 40)
-	utils.CenterText("Space Rocks", rl.Vector2{X: am.width / 2, Y: am.height/3 + 50}, 80)
+// changeDifficulty steps the difficulty of the next new game down for left or up for right.
+func (am *AttractMode) changeDifficulty(key int32) {
+	i := slices.Index(core.Difficulties, am.Session.Difficulty)
+	if key == rl.KeyLeft {
+		i = max(0, i-1)
+	} else {
+		i = min(len(core.Difficulties)-1, i+1)
+	}
+	am.Session.Difficulty = core.Difficulties[i]
 }
 
-func (am *AttractMode) howToPlayScreen() {
-	utils.CenterText("How to Play", rl.Vector2{X: am.width / 2, Y: am.height/3 - 125}, 70)
-	utils.CenterText("1. Avoid rocks", rl.Vector2{X: am.width / 2, Y: am.height / 3}, 50)
-	utils.CenterText("2. Shoot aliens", rl.Vector2{X: am.width / 2, Y: am.height/3 + 50}, 50)
+// changeMode moves on to the next mode for the next new game, going round to the first after the last.
+func (am *AttractMode) changeMode() {
+	i := slices.Index(core.Modes, am.Session.Mode)
+	am.Session.Mode = core.Modes[(i+1)%len(core.Modes)]
+}
+
+// startPlayers sets up a new game for one player, for two taking turns as in the arcade, or for two
+// flying together in co-op. Players only take turns in Classic.
+func (am *AttractMode) startPlayers(key int32) {
+	am.Session.Continue = false
+	am.Session.Players = 1
+	am.Session.Ships = 1
+	switch key {
+	case rl.KeyTwo:
+		am.Session.Players = 2
+		am.Session.Mode = core.ModeClassic
+	case rl.KeyC:
+		am.Session.Ships = 2
+	}
+}
+
+// drawSettings shows the mode, difficulty, and friendly fire the next new game will be played with on p.
+func (am *AttractMode) drawSettings(p platform.Platform) {
+	friendlyFire := "off"
+	if am.Session.FriendlyFire {
+		friendlyFire = "on"
+	}
+	text := fmt.Sprintf("Mode  %s (tab)      Difficulty  < %s >      1 or 2 players, C for co-op      Friendly fire  %s (F)",
+		am.Session.Mode, am.Session.Difficulty, friendlyFire)
+	utils.CenterText(p, text, rl.Vector2{X: am.width / 2, Y: am.height - 25}, 16)
 }
 
-func (am *AttractMode) keymappingsScreen() {
-	utils.CenterText("Keys", rl.Vector2{X: am.width / 2, Y: am.height/3 - 125}, 70)
+// handleMenuKey moves through the New Game and Continue entries, and returns the scene to go to once one
+// is chosen.
+func (am *AttractMode) handleMenuKey(key int32) (scenes.SceneCode, bool) {
+	switch key {
+	case rl.KeyUp, rl.KeyDown:
+		am.selected = 1 - am.selected
+	case rl.KeyEnter, rl.KeySpace:
+		am.Session.Continue = am.selected == menuContinue
+		return scenes.GameplayScene, true
+	}
+	return 0, false
+}
+
+// drawMenu shows the New Game and Continue entries on p, marking the selected one.
+func (am *AttractMode) drawMenu(p platform.Platform) {
+	entries := []string{"New Game", "Continue"}
+	for i, entry := range entries {
+		if i == am.selected {
+			entry = "> " + entry + " <"
+		}
+		utils.CenterText(p, entry, rl.Vector2{X: am.width / 2, Y: am.height - 130 + float32(i)*30}, 24)
+	}
+	utils.CenterText(p, "Up and down to choose, enter to start", rl.Vector2{X: am.width / 2, Y: am.height - 60}, 16)
+}
+
+func (am *AttractMode) titleScreen(p platform.Platform) {
+	utils.CenterText(p, "Avoid", rl.Vector2{X: am.width / 2, Y: am.height/3 - 55}, 80)
+	utils.CenterText(p, "the", rl.Vector2{X: am.width / 2, Y: am.height / 3}, 40)
+	utils.CenterText(p, "Space Rocks", rl.Vector2{X: am.width / 2, Y: am.height/3 + 50}, 80)
+}
+
+func (am *AttractMode) howToPlayScreen(p platform.Platform) {
+	utils.CenterText(p, "How to Play", rl.Vector2{X: am.width / 2, Y: am.height/3 - 125}, 70)
+	utils.CenterText(p, "1. Avoid rocks", rl.Vector2{X: am.width / 2, Y: am.height / 3}, 50)
+	utils.CenterText(p, "2. Shoot aliens", rl.Vector2{X: am.width / 2, Y: am.height/3 + 50}, 50)
+}
+
+func (am *AttractMode) keymappingsScreen(p platform.Platform) {
+	utils.CenterText(p, "Keys", rl.Vector2{X: am.width / 2, Y: am.height/3 - 125}, 70)
+
+	utils.CenterText(p, "left / a", rl.Vector2{X: am.width/2 - 175, Y: am.height / 3}, 50)
+	utils.CenterText(p, "right / d", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 50}, 50)
+	utils.CenterText(p, "up / w", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 100}, 50)
+	utils.CenterText(p, "space / shift", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 150}, 50)
+	utils.CenterText(p, "enter / s", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 200}, 50)
 
-	utils.CenterText("left", rl.Vector2{X: am.width/2 - 175, Y: am.height / 3}, 50)
-	utils.CenterText("right", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 50}, 50)
-	utils.CenterText("up", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 100}, 50)
-	utils.CenterText("space", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 150}, 50)
-	utils.CenterText("enter", rl.Vector2{X: am.width/2 - 175, Y: am.height/3 + 200}, 50)
+	utils.CenterText(p, "Rotate left", rl.Vector2{X: am.width/2 + 175, Y: am.height / 3}, 50)
+	utils.CenterText(p, "Rotate right", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 50}, 50)
+	utils.CenterText(p, "Thrust", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 100}, 50)
+	utils.CenterText(p, "Fire", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 150}, 50)
+	utils.CenterText(p, "Hyperspace", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 200}, 50)
 
-	utils.CenterText("Rotate left", rl.Vector2{X: am.width/2 + 175, Y: am.height / 3}, 50)
-	utils.CenterText("Rotate right", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 50}, 50)
-	utils.CenterText("Thrust", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 100}, 50)
-	utils.CenterText("Fire", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 150}, 50)
-	utils.CenterText("Hyperspace", rl.Vector2{X: am.width/2 + 175, Y: am.height/3 + 200}, 50)
+	utils.CenterText(p, "In co-op the first player flies with the arrows and the second with WASD",
+		rl.Vector2{X: am.width / 2, Y: am.height/3 + 270}, 20)
 }
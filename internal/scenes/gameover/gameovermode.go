@@ -2,16 +2,27 @@ package gameover
 
 import (
 	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/highscores"
+	"avoid_the_space_rocks/internal/platform"
 	"avoid_the_space_rocks/internal/scenes"
 	"avoid_the_space_rocks/internal/utils"
+	"fmt"
 	"github.com/dustin/go-humanize"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"slices"
 	"time"
 )
 
+// Number of high scores shown under the player's score
+const shownHighScores = 5
+
 type GameOverMode struct {
-	width  float32
-	height float32
+	Session *scenes.Session
+	width   float32
+	height  float32
+
+	ranks []int              // Where each player's game ranks in its mode and difficulty, or 0 if it didn't make the table
+	top   []highscores.Entry // The best scores in the game's mode and difficulty
 }
 
 var _ scenes.Scene = (*GameOverMode)(nil)
@@ -19,33 +30,154 @@ var _ scenes.Scene = (*GameOverMode)(nil)
 func (am *GameOverMode) Init(width, height float32) {
 	am.width = width
 	am.height = height
+	am.recordHighScores()
+}
+
+// players returns every player's game from the game just played.
+func (am *GameOverMode) players() []*core.Game {
+	if len(am.Session.LastPlayers) > 0 {
+		return am.Session.LastPlayers
+	}
+	return []*core.Game{am.Session.LastGame}
+}
+
+// recordHighScores adds each player's game to the high scores. Replays don't count.
+func (am *GameOverMode) recordHighScores() {
+	players := am.players()
+	am.ranks = make([]int, len(players))
+	path := am.Session.HighScoresPath
+	game := am.Session.LastGame
+	if path == "" || game == nil {
+		return
+	}
+	table, err := highscores.Load(path)
+	if err != nil {
+		platform.Log(rl.LogError, "error loading high scores: %v", err)
+		return
+	}
+	if !am.Session.Replayed {
+		ranked := false
+		for i, g := range players {
+			rank := table.Add(highscores.Entry{
+				Mode:       g.Mode,
+				Difficulty: g.Difficulty,
+				Score:      g.Score,
+				Level:      g.Level,
+				When:       time.Now(),
+			})
+			if rank == 0 {
+				continue
+			}
+			// Anyone already added at or below this rank moves down one, maybe off the table
+			for j := range i {
+				if am.ranks[j] >= rank {
+					am.ranks[j] = (am.ranks[j] + 1) % (highscores.Kept + 1)
+				}
+			}
+			am.ranks[i] = rank
+			ranked = true
+		}
+		if ranked {
+			if err := table.Save(path); err != nil {
+				platform.Log(rl.LogError, "error saving high scores: %v", err)
+			}
+		}
+	}
+	am.top = table.Top(game.Mode, game.Difficulty)
 }
 
 func (am *GameOverMode) Close() {
 }
 
 func (am *GameOverMode) Loop() scenes.SceneCode {
-	next := scenes.GameOverScene
-
-	go func() {
-		time.Sleep(4 * time.Second)
-		next = scenes.AttractModeScene
-	}()
+	screenDuration := time.Second * time.Duration(4)
+	if len(am.top) > 0 {
+		screenDuration = time.Second * time.Duration(7)
+	}
+	p := am.Session.Platform
+	startTime := p.Time()
 
-	for !rl.WindowShouldClose() && next == scenes.GameOverScene {
-		rl.BeginDrawing()
-		rl.ClearBackground(rl.RayWhite)
-		utils.CenterText("Game Over", rl.Vector2{X: am.width / 2, Y: am.height / 3}, 80)
+	for !p.ShouldClose() && p.Time()-startTime < screenDuration.Seconds() {
+		p.BeginFrame()
+		game := am.Session.LastGame
+		title := "Game Over"
+		if game.Mode == core.ModeTimeAttack {
+			title = "Time's Up"
+		}
+		utils.CenterText(p, title, rl.Vector2{X: am.width / 2, Y: am.height / 5}, 80)
 
-		score := humanize.Comma(int64(core.GetGame().Score))
-		utils.CenterText("Your Score", rl.Vector2{X: am.width / 2, Y: am.height / 2}, 30)
-		utils.CenterText(score, rl.Vector2{X: am.width / 2, Y: am.height/2 + 75}, 60)
+		if len(am.players()) > 1 {
+			am.drawResult(p)
+		} else {
+			score := humanize.Comma(int64(game.Score))
+			utils.CenterText(p, fmt.Sprintf("Your %s Score on %s", game.Mode, game.Difficulty), rl.Vector2{X: am.width / 2, Y: am.height/5 + 90}, 30)
+			utils.CenterText(p, score, rl.Vector2{X: am.width / 2, Y: am.height/5 + 150}, 60)
+			if am.ranks[0] > 0 {
+				utils.CenterText(p, fmt.Sprintf("New high score! #%d", am.ranks[0]), rl.Vector2{X: am.width / 2, Y: am.height/5 + 205}, 24)
+			}
+			am.drawShipScores(p, game)
+		}
+		am.drawHighScores(p)
 
-		rl.EndDrawing()
+		p.EndFrame()
 	}
 
-	if rl.WindowShouldClose() {
+	if p.ShouldClose() {
 		return scenes.Quit
 	}
-	return next
+	return scenes.AttractModeScene
+}
+
+// drawResult announces the winner of a game where players took turns on p, with everyone's score.
+func (am *GameOverMode) drawResult(p platform.Platform) {
+	players := am.players()
+	winner, tied := 0, false
+	for i, g := range players[1:] {
+		if g.Score > players[winner].Score {
+			winner, tied = i+1, false
+		} else if g.Score == players[winner].Score {
+			tied = true
+		}
+	}
+	result := fmt.Sprintf("Player %d Wins!", winner+1)
+	if tied {
+		result = "It's a Tie!"
+	}
+	utils.CenterText(p, result, rl.Vector2{X: am.width / 2, Y: am.height/5 + 100}, 50)
+
+	for i, g := range players {
+		line := fmt.Sprintf("Player %d  %s", i+1, humanize.Comma(int64(g.Score)))
+		if am.ranks[i] > 0 {
+			line += fmt.Sprintf("  (new high score #%d)", am.ranks[i])
+		}
+		utils.CenterText(p, line, rl.Vector2{X: am.width / 2, Y: am.height/5 + 160 + float32(i)*30}, 24)
+	}
+}
+
+// drawShipScores shows on p what each player scored themselves when they flew together.
+func (am *GameOverMode) drawShipScores(p platform.Platform, game *core.Game) {
+	if len(game.World.Spaceships) < 2 {
+		return
+	}
+	for i, ship := range game.World.Spaceships {
+		line := fmt.Sprintf("Player %d  %s", ship.Player+1, humanize.Comma(int64(ship.Score)))
+		utils.CenterText(p, line, rl.Vector2{X: am.width / 2, Y: am.height/5 + 240 + float32(i)*28}, 20)
+	}
+}
+
+// drawHighScores lists the best scores in the game's mode and difficulty on p, marking the ones just played.
+func (am *GameOverMode) drawHighScores(p platform.Platform) {
+	if len(am.top) == 0 {
+		return
+	}
+	y := am.height/2 + 80
+	game := am.Session.LastGame
+	utils.CenterText(p, fmt.Sprintf("%s %s High Scores", game.Mode, game.Difficulty), rl.Vector2{X: am.width / 2, Y: y}, 24)
+	for i, e := range am.top[:min(len(am.top), shownHighScores)] {
+		line := fmt.Sprintf("%d.  %s  (level %d)", i+1, humanize.Comma(int64(e.Score)), e.Level)
+		if slices.Contains(am.ranks, i+1) {
+			line = "> " + line + " <"
+		}
+		utils.CenterText(p, line, rl.Vector2{X: am.width / 2, Y: y + 35 + float32(i)*28}, 20)
+	}
 }
@@ -0,0 +1,149 @@
+package netgame
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/netplay"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/scenes"
+	"avoid_the_space_rocks/internal/scenes/playfield"
+	"avoid_the_space_rocks/internal/utils"
+	"fmt"
+	"github.com/dustin/go-humanize"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"time"
+)
+
+const (
+	farewellDuration = 3 * time.Second // How long the game over or lost touch message stays up
+	maxFrameTime     = float32(0.25)   // Longest frame we try to catch up on after a hitch
+)
+
+// NetGame plays a game on a server. The server runs the game; this sends it the player's input every
+// tick and draws what it sends back.
+type NetGame struct {
+	Session  *scenes.Session
+	width    float32
+	height   float32
+	client   *netplay.Client
+	keyboard *playfield.Keyboard
+	failed   string // Why the game couldn't be joined, if it couldn't
+}
+
+var _ scenes.Scene = (*NetGame)(nil)
+
+func (ng *NetGame) Init(width, height float32) {
+	ng.width = width
+	ng.height = height
+	ng.keyboard = playfield.NewKeyboard(ng.Session.Platform)
+	// Only the first game is played on the server; after that it's back to playing here
+	address := ng.Session.Connect
+	ng.Session.Connect = ""
+	client, err := netplay.Dial(address)
+	if err != nil {
+		platform.Log(rl.LogError, "error joining the game on %s: %v", address, err)
+		ng.failed = fmt.Sprintf("Couldn't join the game on %s", address)
+		return
+	}
+	ng.client = client
+}
+
+func (ng *NetGame) Close() {
+	if ng.client != nil {
+		if err := ng.client.Close(); err != nil {
+			platform.Log(rl.LogWarning, "error leaving the game: %v", err)
+		}
+	}
+}
+
+func (ng *NetGame) Loop() scenes.SceneCode {
+	p := ng.Session.Platform
+	accumulator := float32(0)
+	endedAt := 0.0
+	for !p.ShouldClose() {
+		if p.IsKeyPressed(rl.KeyEscape) {
+			return scenes.AttractModeScene
+		}
+		if ng.client != nil {
+			ng.keyboard.Poll()
+			accumulator += min(p.FrameTime(), maxFrameTime)
+			for accumulator >= playfield.TickDelta {
+				if err := ng.client.Send(ng.keyboard.Next()); err != nil {
+					platform.Log(rl.LogDebug, "error sending input: %v", err)
+				}
+				accumulator -= playfield.TickDelta
+			}
+		}
+
+		message := ng.message()
+		if message != "" && endedAt == 0 {
+			endedAt = p.Time()
+		} else if message != "" && p.Time()-endedAt > farewellDuration.Seconds() {
+			return scenes.AttractModeScene
+		}
+
+		p.BeginFrame()
+		ng.render(p, message)
+		p.EndFrame()
+	}
+	return scenes.Quit
+}
+
+// message returns why the game has ended, or nothing if it's still going.
+func (ng *NetGame) message() string {
+	if ng.client == nil {
+		return ng.failed
+	}
+	if ng.client.Lost() {
+		return "Lost touch with the server"
+	}
+	if snapshot, ok := ng.client.Latest(); ok && snapshot.Over {
+		return "GAME OVER"
+	}
+	return ""
+}
+
+// render draws the game on p as the server last had it, with the player's own spaceship where it's
+// predicted to be, and the message over the top if there is one.
+func (ng *NetGame) render(p platform.Platform, message string) {
+	center := rl.Vector2{X: ng.width / 2, Y: ng.height / 3}
+	if ng.client == nil {
+		utils.CenterText(p, message, center, 30)
+		return
+	}
+	snapshot, ok := ng.client.Latest()
+	if !ok {
+		utils.CenterText(p, "Waiting for everyone to join", center, 30)
+		return
+	}
+	welcome := ng.client.Welcome()
+	for _, sprite := range ng.client.Sprites(time.Now()) {
+		if err := sprite.Draw(p, welcome.Width, welcome.Height); err != nil {
+			platform.Log(rl.LogError, "error drawing sprite: %v", err)
+		}
+	}
+	ng.drawHud(p, snapshot)
+	if message != "" {
+		utils.CenterText(p, message, center, 40)
+	}
+}
+
+// drawHud shows the score, the mode and difficulty, and each player's score and lives on p, with this
+// player's marked.
+func (ng *NetGame) drawHud(p platform.Platform, snapshot netplay.Snapshot) {
+	welcome := ng.client.Welcome()
+	utils.WriteText(p, humanize.Comma(int64(snapshot.Score)), rl.Vector2{X: 15, Y: 12}, 36)
+	label := fmt.Sprintf("%s  %s  Level %d", core.Mode(welcome.Mode), core.Difficulty(welcome.Difficulty), snapshot.Level)
+	utils.WriteText(p, label, rl.Vector2{X: 15, Y: 52}, 18)
+	y := float32(76)
+	for i, ship := range snapshot.Ships {
+		text := fmt.Sprintf("Player %d  %s  Lives %d", i+1, humanize.Comma(int64(ship.Score)), ship.Lives)
+		if ship.Out {
+			text = fmt.Sprintf("Player %d  %s  Out", i+1, humanize.Comma(int64(ship.Score)))
+		}
+		if i == welcome.Player {
+			text += "  (you)"
+		}
+		utils.WriteText(p, text, rl.Vector2{X: 15, Y: y}, 18)
+		y += 24
+	}
+}
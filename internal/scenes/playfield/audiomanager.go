@@ -2,6 +2,9 @@ package playfield
 
 import (
 	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
 	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"github.com/hashicorp/go-set"
@@ -9,60 +12,77 @@ import (
 )
 
 type AudioManager struct {
-	soundMap  map[string]*rl.Sound
+	platform platform.Platform // What the sounds are loaded on and played through
+
+	soundMap  map[string]*platform.Sound
 	soundLock sync.RWMutex
 
-	musicMap     map[string]*rl.Music
+	musicMap     map[string]*platform.Music
 	musicLock    sync.RWMutex
 	playingMusic set.Set[string]
+	burning      set.Set[int] // Players burning fuel; the engine sound plays while any are
+
+	subscriptions []*events.Subscription
 }
 
 var _ core.EventObserver = (*AudioManager)(nil)
 
-func NewAudioManager() *AudioManager {
+// NewAudioManager creates an audio manager that plays the game's sounds through p.
+func NewAudioManager(p platform.Platform) *AudioManager {
 	return &AudioManager{
-		soundMap:     make(map[string]*rl.Sound),
-		musicMap:     make(map[string]*rl.Music),
+		platform:     p,
+		soundMap:     make(map[string]*platform.Sound),
+		musicMap:     make(map[string]*platform.Music),
 		playingMusic: *set.New[string](10),
-	}
-}
-
-func (mgr *AudioManager) eventMappings() []eventMapping {
-	return []eventMapping{
-		{"alien:destroyed", mgr.alienDestroyedHandler},
-		{"alien:fire", mgr.alienFireHandler},
-		{"alien:left_playfield", mgr.alienLeftPlayfieldHandler},
-		{"alien:spawned", mgr.alienSpawnedHandler},
-		{"rock:destroyed", mgr.rockExplosionHandler},
-		{"spaceship:extra_life", mgr.spaceshipExtraLifeHandler},
-		{"spaceship:fire", mgr.spaceshipFireHandler},
-		{"spaceship:thrust", mgr.spaceshipThrustHandler},
-		{"spaceship:enter_hyperspace", mgr.spaceshipEnterHyperspaceHandler},
-		{"spaceship:destroyed", mgr.spaceshipExplosionHandler},
+		burning:      *set.New[int](2),
 	}
 }
 
 func (mgr *AudioManager) Register(game *core.Game) error {
-	for _, sub := range mgr.eventMappings() {
-		if err := game.EventBus.SubscribeAsync(sub.event, sub.handler, false); err != nil {
-			rl.TraceLog(rl.LogError, "error subscribing to %s event: %v", sub.event, err)
-			return err
+	bus := game.EventBus
+	mgr.subscriptions = []*events.Subscription{
+		events.Subscribe(bus, mgr.alienDestroyedHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.alienFireHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.alienLeftPlayfieldHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.alienSpawnedHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.rockExplosionHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.spaceshipExtraLifeHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.spaceshipFireHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.spaceshipThrustHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.spaceshipEnterHyperspaceHandler, events.PriorityNormal),
+		events.Subscribe(bus, mgr.spaceshipExplosionHandler, events.PriorityNormal),
+	}
+	// A continued game may already have an alien on the playfield or spaceships burning fuel
+	game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
+		if alien, ok := obj.(*core.Alien); ok && alien.IsAlive() {
+			mgr.alienSpawnedMusic(alien.Size())
+		}
+	})
+	for _, ship := range game.World.Spaceships {
+		if ship.FuelBurning {
+			mgr.burningChanged(ship.Player, true)
 		}
 	}
 	return nil
 }
 
 func (mgr *AudioManager) Deregister(game *core.Game) error {
-	for _, sub := range mgr.eventMappings() {
-		if err := game.EventBus.Unsubscribe(sub.event, sub.handler); err != nil {
-			rl.TraceLog(rl.LogError, "error unsubscribing from %s event: %v", sub.event, err)
+	for _, sub := range mgr.subscriptions {
+		if err := game.EventBus.Unsubscribe(sub); err != nil {
+			platform.Log(rl.LogError, "error unsubscribing: %v", err)
 			return err
 		}
 	}
+	mgr.subscriptions = nil
+	mgr.musicLock.Lock()
+	defer mgr.musicLock.Unlock()
 	mgr.playingMusic.ForEach(func(filename string) bool {
-		rl.StopMusicStream(*mgr.musicMap[filename])
+		mgr.platform.StopMusic(mgr.musicMap[filename])
 		return true
 	})
+	// Nothing's playing now, so registering with a game again starts its music afresh
+	mgr.playingMusic = *set.New[string](10)
+	mgr.burning = *set.New[int](2)
 	return nil
 }
 
@@ -73,15 +93,15 @@ func (mgr *AudioManager) Update(_ *core.Game) error {
 		mgr.musicLock.RLock()
 		defer mgr.musicLock.RUnlock()
 		mgr.playingMusic.ForEach(func(filename string) bool {
-			rl.UpdateMusicStream(*mgr.musicMap[filename])
+			mgr.platform.UpdateMusic(mgr.musicMap[filename])
 			return true
 		})
 	}
 	return nil
 }
 
-func (mgr *AudioManager) rockExplosionHandler(size core.RockSize) {
-	switch size {
+func (mgr *AudioManager) rockExplosionHandler(e events.RockDestroyed) {
+	switch e.Size {
 	case core.RockTiny:
 		_ = mgr.playSound("explosion_tiny.wav")
 	case core.RockSmall:
@@ -93,17 +113,22 @@ func (mgr *AudioManager) rockExplosionHandler(size core.RockSize) {
 	}
 }
 
-func (mgr *AudioManager) alienSpawnedHandler(size core.AlienSize) {
+func (mgr *AudioManager) alienSpawnedHandler(e events.AlienSpawned) {
+	mgr.alienSpawnedMusic(e.Size)
+	_ = mgr.playSound("explosion_alien.wav")
+}
+
+// alienSpawnedMusic starts the music that plays while an alien of the given size is on the playfield.
+func (mgr *AudioManager) alienSpawnedMusic(size core.AlienSize) {
 	if size == core.AlienBig {
 		_ = mgr.startMusic("move_alien_big.wav")
 	} else {
 		_ = mgr.startMusic("move_alien_small.wav")
 	}
-	_ = mgr.playSound("explosion_alien.wav")
 }
 
-func (mgr *AudioManager) alienDestroyedHandler(size core.AlienSize) {
-	if size == core.AlienBig {
+func (mgr *AudioManager) alienDestroyedHandler(e events.AlienDestroyed) {
+	if e.Size == core.AlienBig {
 		_ = mgr.stopMusic("move_alien_big.wav")
 	} else {
 		_ = mgr.stopMusic("move_alien_small.wav")
@@ -111,53 +136,61 @@ func (mgr *AudioManager) alienDestroyedHandler(size core.AlienSize) {
 	_ = mgr.playSound("explosion_alien.wav")
 }
 
-func (mgr *AudioManager) alienLeftPlayfieldHandler(size core.AlienSize) {
-	if size == core.AlienBig {
+func (mgr *AudioManager) alienLeftPlayfieldHandler(e events.AlienLeftPlayfield) {
+	if e.Size == core.AlienBig {
 		_ = mgr.stopMusic("move_alien_big.wav")
 	} else {
 		_ = mgr.stopMusic("move_alien_small.wav")
 	}
 }
 
-func (mgr *AudioManager) alienFireHandler() {
+func (mgr *AudioManager) alienFireHandler(_ events.AlienFired) {
 	_ = mgr.playSound("fire_alien.wav")
 }
 
-func (mgr *AudioManager) spaceshipExtraLifeHandler() {
+func (mgr *AudioManager) spaceshipExtraLifeHandler(_ events.ExtraLife) {
 	_ = mgr.playSound("extra_life.wav")
 }
 
-func (mgr *AudioManager) spaceshipFireHandler() {
+func (mgr *AudioManager) spaceshipFireHandler(_ events.SpaceshipFired) {
 	_ = mgr.playSound("fire.wav")
 }
 
-func (mgr *AudioManager) spaceshipThrustHandler(start bool) {
-	if start {
-		_ = mgr.startMusic("fuel_burn.wav")
-	} else {
-		_ = mgr.stopMusic("fuel_burn.wav")
-	}
+func (mgr *AudioManager) spaceshipThrustHandler(e events.SpaceshipThrust) {
+	mgr.burningChanged(e.Player, e.Burning)
 }
 
-func (mgr *AudioManager) spaceshipExplosionHandler() {
-	if mgr.playingMusic.Contains("fuel_burn.wav") {
-		_ = mgr.stopMusic("fuel_burn.wav")
-	}
+func (mgr *AudioManager) spaceshipExplosionHandler(e events.SpaceshipDestroyed) {
+	mgr.burningChanged(e.Player, false)
 	_ = mgr.playSound("explosion_ship.wav")
 }
 
-func (mgr *AudioManager) spaceshipEnterHyperspaceHandler() {
-	if mgr.playingMusic.Contains("fuel_burn.wav") {
-		_ = mgr.stopMusic("fuel_burn.wav")
-	}
+func (mgr *AudioManager) spaceshipEnterHyperspaceHandler(e events.SpaceshipEnteredHyperspace) {
+	mgr.burningChanged(e.Player, false)
 	_ = mgr.playSound("hyperspace.wav")
 }
 
+// burningChanged notes whether a player is burning fuel, playing the engine sound while anyone is.
+func (mgr *AudioManager) burningChanged(player int, burning bool) {
+	if burning {
+		mgr.burning.Insert(player)
+	} else {
+		mgr.burning.Remove(player)
+	}
+	if mgr.burning.Empty() {
+		if mgr.playingMusic.Contains("fuel_burn.wav") {
+			_ = mgr.stopMusic("fuel_burn.wav")
+		}
+	} else {
+		_ = mgr.startMusic("fuel_burn.wav")
+	}
+}
+
 // playSound plays a sound from a filename, or returns an error if it can't.
 func (mgr *AudioManager) playSound(filename string) error {
 	sound, err := mgr.soundFromFile(filename)
 	if err == nil {
-		rl.PlaySound(*sound)
+		mgr.platform.PlaySound(sound)
 	}
 	return err
 }
@@ -165,12 +198,12 @@ func (mgr *AudioManager) playSound(filename string) error {
 // startMusic starts playing a music file from a filename, or returns an error if it can't.
 func (mgr *AudioManager) startMusic(filename string) error {
 	if !mgr.playingMusic.Contains(filename) {
-		rl.TraceLog(rl.LogDebug, "Starting music for %s", filename)
-		return mgr.withMusic(filename, func(music *rl.Music) error {
+		platform.Log(rl.LogDebug, "Starting music for %s", filename)
+		return mgr.withMusic(filename, func(music *platform.Music) error {
 			mgr.musicLock.Lock()
 			mgr.playingMusic.Insert(filename)
 			mgr.musicLock.Unlock()
-			rl.PlayMusicStream(*music)
+			mgr.platform.PlayMusic(music)
 			return nil
 		})
 	}
@@ -180,19 +213,19 @@ func (mgr *AudioManager) startMusic(filename string) error {
 // stopMusic stops playing a music file from a filename, or returns an error if it can't.
 func (mgr *AudioManager) stopMusic(filename string) error {
 	if mgr.playingMusic.Contains(filename) {
-		rl.TraceLog(rl.LogDebug, "Stopping music for %s", filename)
-		return mgr.withMusic(filename, func(music *rl.Music) error {
+		platform.Log(rl.LogDebug, "Stopping music for %s", filename)
+		return mgr.withMusic(filename, func(music *platform.Music) error {
 			mgr.musicLock.Lock()
 			mgr.playingMusic.Remove(filename)
 			mgr.musicLock.Unlock()
-			rl.StopMusicStream(*music)
+			mgr.platform.StopMusic(music)
 			return nil
 		})
 	}
 	return nil
 }
 
-type musicHandler func(*rl.Music) error
+type musicHandler func(*platform.Music) error
 
 // withMusic loads a music file from a filename and calls the callback with it.
 func (mgr *AudioManager) withMusic(filename string, callback musicHandler) error {
@@ -205,7 +238,7 @@ func (mgr *AudioManager) withMusic(filename string, callback musicHandler) error
 
 // soundFromFile loads a sound from a file, or returns an error if it can't.
 // The sound files are cached forever, but they take up very little memory.
-func (mgr *AudioManager) soundFromFile(filename string) (*rl.Sound, error) {
+func (mgr *AudioManager) soundFromFile(filename string) (*platform.Sound, error) {
 	// Almost all the time we have the sound already
 	mgr.soundLock.RLock()
 	if sound, ok := mgr.soundMap[filename]; ok {
@@ -216,17 +249,17 @@ func (mgr *AudioManager) soundFromFile(filename string) (*rl.Sound, error) {
 	// Load and save the sound file so we need a writers lock
 	mgr.soundLock.Lock()
 	defer mgr.soundLock.Unlock()
-	sound := rl.LoadSound(fmt.Sprintf("assets/audio/%s", filename))
-	if sound.Stream.Buffer == nil || !rl.IsSoundValid(sound) {
-		return &sound, fmt.Errorf("could not load sound file %s", filename)
+	sound, err := mgr.platform.LoadSound(fmt.Sprintf("assets/audio/%s", filename))
+	if err != nil {
+		return nil, fmt.Errorf("could not load sound file %s: %w", filename, err)
 	}
-	mgr.soundMap[filename] = &sound
-	return &sound, nil
+	mgr.soundMap[filename] = sound
+	return sound, nil
 }
 
 // musicFromFile loads music from a file, or returns an error if it can't.
 // The music files are cached forever, but they take up very little memory.
-func (mgr *AudioManager) musicFromFile(filename string) (*rl.Music, error) {
+func (mgr *AudioManager) musicFromFile(filename string) (*platform.Music, error) {
 	// Almost all the time we have the sound already
 	mgr.musicLock.RLock()
 	if music, ok := mgr.musicMap[filename]; ok {
@@ -237,11 +270,10 @@ func (mgr *AudioManager) musicFromFile(filename string) (*rl.Music, error) {
 	// Load and save the music file so we need a writers lock
 	mgr.musicLock.Lock()
 	defer mgr.musicLock.Unlock()
-	music := rl.LoadMusicStream(fmt.Sprintf("assets/audio/%s", filename))
-	if music.Stream.Buffer == nil || !rl.IsMusicValid(music) {
-		return &music, fmt.Errorf("could not load music file %s", filename)
+	music, err := mgr.platform.LoadMusic(fmt.Sprintf("assets/audio/%s", filename))
+	if err != nil {
+		return nil, fmt.Errorf("could not load music file %s: %w", filename, err)
 	}
-	music.Looping = true
-	mgr.musicMap[filename] = &music
-	return &music, nil
+	mgr.musicMap[filename] = music
+	return music, nil
 }
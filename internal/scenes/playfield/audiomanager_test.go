@@ -1,10 +1,9 @@
 package playfield
 
 import (
+	"avoid_the_space_rocks/internal/platform"
 	"os"
 	"testing"
-
-	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 func TestMain(m *testing.M) {
@@ -12,14 +11,11 @@ func TestMain(m *testing.M) {
 	if err := os.Chdir("../../.."); err != nil {
 		panic("failed to change to project root directory: " + err.Error())
 	}
-
-	rl.InitAudioDevice()
-	defer rl.CloseAudioDevice()
 	os.Exit(m.Run())
 }
 
 func TestSoundFromFile(t *testing.T) {
-	audioManager := NewAudioManager()
+	audioManager := NewAudioManager(platform.NewHeadless())
 
 	// Test loading a sound file
 	filename := "fire.wav"
@@ -27,8 +23,8 @@ func TestSoundFromFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if sound.Stream.Buffer == nil {
-		t.Fatalf("expected valid sound, got nil buffer")
+	if sound == nil {
+		t.Fatalf("expected valid sound, got nil")
 	}
 
 	// Test loading the same sound file from cache
@@ -36,8 +32,8 @@ func TestSoundFromFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error loading cache, got %v", err)
 	}
-	if cachedSound.Stream.Buffer == nil {
-		t.Fatalf("expected valid sound loading cache, got nil buffer")
+	if cachedSound == nil {
+		t.Fatalf("expected valid sound loading cache, got nil")
 	}
 	if sound != cachedSound {
 		t.Fatalf("expected cached sound, got different instance")
@@ -45,7 +41,7 @@ func TestSoundFromFile(t *testing.T) {
 }
 
 func TestSoundFromFile_NotExist(t *testing.T) {
-	audioManager := NewAudioManager()
+	audioManager := NewAudioManager(platform.NewHeadless())
 
 	// Test loading a non-existent sound file
 	filename := "non_existent_file.wav"
@@ -56,7 +52,7 @@ func TestSoundFromFile_NotExist(t *testing.T) {
 }
 
 func TestMusicFromFile(t *testing.T) {
-	audioManager := NewAudioManager()
+	audioManager := NewAudioManager(platform.NewHeadless())
 
 	// Test loading a music file
 	filename := "fuel_burn.wav"
@@ -64,8 +60,8 @@ func TestMusicFromFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if music.Stream.Buffer == nil {
-		t.Fatalf("expected valid music, got nil buffer")
+	if music == nil {
+		t.Fatalf("expected valid music, got nil")
 	}
 
 	// Test loading the same music file from cache
@@ -73,8 +69,8 @@ func TestMusicFromFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error loading cache, got %v", err)
 	}
-	if cachedMusic.Stream.Buffer == nil {
-		t.Fatalf("expected valid music loading cache, got nil buffer")
+	if cachedMusic == nil {
+		t.Fatalf("expected valid music loading cache, got nil")
 	}
 	if music != cachedMusic {
 		t.Fatalf("expected cached music, got different instance")
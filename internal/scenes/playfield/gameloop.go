@@ -2,155 +2,519 @@ package playfield
 
 import (
 	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/core/events"
 	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
 	"avoid_the_space_rocks/internal/scenes"
 	"avoid_the_space_rocks/internal/utils"
+	"encoding/json"
+	"fmt"
 	"github.com/dustin/go-humanize"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
 )
 
 type Gameloop struct {
+	Session   *scenes.Session
+	game      *core.Game    // The game being played: the current player's
+	warden    *GameWarden   // The current player's game warden
+	audio     *AudioManager // Plays the sound of whichever game is being played
+	input     inputSource
+	recording *replay.Replay // The game so far, when the player is at the keyboard
+	saved     bool           // The player saved the game to carry on later
+
+	players  []player // Everyone taking turns, in order; just the one player otherwise
+	turn     int      // Index of the current player
+	shipLost bool     // The current player's spaceship has been lost, so their turn ends once it's back
+
+	tuningModTime   time.Time // When the tuning file last changed, to spot edits in debug mode
+	tuningCheckedAt float64   // Platform time the tuning file was last checked
 }
 
-type eventMapping struct {
-	event   string
-	handler any
+// player is one player's game and the warden running it. Players who take turns each have a game of
+// their own, with their own level, score, lives, and rocks, and only the current player's runs.
+type player struct {
+	game   *core.Game
+	warden *GameWarden
 }
 
 var _ scenes.Scene = (*Gameloop)(nil)
 
 func (gl *Gameloop) Init(width, height float32) {
-	core.InitGame(width, height)
-	game := core.GetGame()
-	game.World.Initialize()
-	game.Observers = append(game.Observers, NewAudioManager(), NewScoreKeeper(), NewGameWarden())
-	for _, obs := range game.Observers {
-		if err := obs.Register(game); err != nil {
-			rl.TraceLog(rl.LogError, "error registering observer: %v", err)
+	seed := gl.Session.Seed
+	tuning := gl.Session.Tuning
+	difficulty := gl.Session.Difficulty
+	mode := gl.Session.Mode
+	players := max(1, gl.Session.Players)
+	ships := max(1, gl.Session.Ships)
+	friendlyFire := gl.Session.FriendlyFire
+	var recorded []replay.Input
+	gl.Session.Replayed = gl.Session.Replay != nil
+	if r := gl.Session.Replay; r != nil {
+		// Play the replay back exactly as it was recorded, once
+		gl.Session.Replay = nil
+		if r.RulesVersion != core.RulesVersion {
+			platform.Log(rl.LogWarning, "Replay was recorded under rules version %d, not %d; it may play out differently",
+				r.RulesVersion, core.RulesVersion)
+		}
+		width, height, seed = r.Width, r.Height, r.Seed
+		tuning = replayTuning(r)
+		difficulty = core.Difficulty(r.Difficulty)
+		mode = core.Mode(r.Mode)
+		players = max(1, r.Players)
+		ships = max(1, r.Ships)
+		friendlyFire = r.FriendlyFire
+		recorded = r.Inputs
+	}
+
+	if players > 1 && mode != core.ModeClassic {
+		platform.Log(rl.LogWarning, "Players only take turns in Classic, so this %s game is for one player", mode)
+		players = 1
+	}
+	if players > 1 && ships > 1 {
+		platform.Log(rl.LogWarning, "Players either take turns or fly together, so this game has one spaceship")
+		ships = 1
+	}
+	if gl.Session.Replayed {
+		gl.input = &replayInput{inputs: recorded, ships: ships}
+	} else {
+		gl.input = newKeyboardInput(gl.Session.Platform, ships)
+	}
+
+	game := gl.continueGame()
+	continued := game != nil
+	games := []*core.Game{game}
+	if !continued {
+		// Each player gets a rock field of their own, so the later players' seeds follow on from the first's
+		games = make([]*core.Game, players)
+		for i := range games {
+			if i > 0 {
+				seed = games[0].Random.Seed() + uint64(i)
+			}
+			games[i] = core.NewGame(width, height, seed)
+			games[i].SetDifficulty(difficulty)
+			games[i].Mode = mode
+			games[i].Ships = ships
+			games[i].FriendlyFire = friendlyFire
+		}
+	}
+	for _, g := range games {
+		if tuning != nil {
+			g.Tuning = tuning
+		}
+		if !continued {
+			g.World.Initialize(g)
 		}
 	}
-	go game.StartLevel()
+	game = games[0]
+	if _, ok := gl.input.(*keyboardInput); ok && continued {
+		// The saved game has as many spaceships as it was saved with
+		gl.input = newKeyboardInput(gl.Session.Platform, game.Ships)
+	}
+	if _, ok := gl.input.(*keyboardInput); ok && !continued {
+		gl.recording = &replay.Replay{
+			RulesVersion: core.RulesVersion,
+			Seed:         game.Random.Seed(),
+			Width:        width,
+			Height:       height,
+			Difficulty:   int(game.Difficulty),
+			Mode:         int(game.Mode),
+			Players:      len(games),
+			Ships:        game.Ships,
+			FriendlyFire: game.FriendlyFire,
+			Inputs:       make([]replay.Input, 0, 120*TickRate*game.Ships),
+		}
+		if data, err := json.Marshal(game.Tuning); err == nil {
+			gl.recording.Tuning = data
+		} else {
+			platform.Log(rl.LogError, "error recording tuning, so the game won't be recorded: %v", err)
+			gl.recording = nil
+		}
+	}
+	if info, err := os.Stat(gl.Session.TuningPath); err == nil {
+		gl.tuningModTime = info.ModTime()
+		gl.tuningCheckedAt = gl.Session.Platform.Time()
+	}
+	gl.Session.LastGame = game
+	gl.Session.LastPlayers = games
+	gl.audio = NewAudioManager(gl.Session.Platform)
+	for i, g := range games {
+		p := player{game: g, warden: NewGameWarden()}
+		if i == 0 {
+			g.Observers = append(g.Observers, gl.audio)
+		}
+		g.Observers = append(g.Observers, NewScoreKeeper(), p.warden)
+		for _, obs := range g.Observers {
+			if err := obs.Register(g); err != nil {
+				platform.Log(rl.LogError, "error registering observer: %v", err)
+			}
+		}
+		if !continued {
+			p.warden.Start()
+		}
+		gl.players = append(gl.players, p)
+	}
+	gl.game, gl.warden = game, gl.players[0].warden
+}
+
+// replayTuning returns the tuning the replay was recorded with, falling back on the defaults if it
+// doesn't have any or it can't be read.
+func replayTuning(r *replay.Replay) *core.Tuning {
+	if len(r.Tuning) == 0 {
+		return core.DefaultTuning()
+	}
+	tuning, err := core.ParseTuning(r.Tuning)
+	if err != nil {
+		platform.Log(rl.LogWarning, "Replay's tuning can't be used, so it may play out differently: %v", err)
+		return core.DefaultTuning()
+	}
+	return tuning
+}
+
+// continueGame loads the saved game if the player chose to continue it, or returns nil to start a new
+// one. The save is removed once loaded so each saved game can only be carried on once. A continued game
+// isn't recorded, since a replay has to start from the beginning.
+func (gl *Gameloop) continueGame() *core.Game {
+	if !gl.Session.Continue {
+		return nil
+	}
+	gl.Session.Continue = false
+	if _, ok := gl.input.(*keyboardInput); !ok {
+		return nil
+	}
+	game, err := core.LoadGameFile(gl.Session.SavePath)
+	if err != nil {
+		platform.Log(rl.LogError, "error continuing saved game, starting a new one: %v", err)
+		return nil
+	}
+	if err := os.Remove(gl.Session.SavePath); err != nil {
+		platform.Log(rl.LogWarning, "error removing saved game: %v", err)
+	}
+	platform.Log(rl.LogInfo, "Continuing saved game on level %d; it won't be recorded", game.Level)
+	return game
 }
 
 func (gl *Gameloop) Close() {
-	game := core.GetGame()
-	for _, obs := range game.Observers {
-		if err := obs.Deregister(game); err != nil {
-			rl.TraceLog(rl.LogError, "error deregistering observer: %v", err)
+	for _, p := range gl.players {
+		for _, obs := range p.game.Observers {
+			if err := obs.Deregister(p.game); err != nil {
+				platform.Log(rl.LogError, "error deregistering observer: %v", err)
+			}
 		}
 	}
+	gl.saveReplay()
+}
+
+// saveReplay writes the recording of the game to the session's replay directory, if there is one.
+func (gl *Gameloop) saveReplay() {
+	if gl.recording == nil || gl.Session.ReplayDir == "" {
+		return
+	}
+	name := fmt.Sprintf("%s-%d.replay", time.Now().Format("20060102-150405"), gl.recording.Seed)
+	path := filepath.Join(gl.Session.ReplayDir, name)
+	if err := gl.recording.Save(path); err != nil {
+		platform.Log(rl.LogError, "error saving replay: %v", err)
+		return
+	}
+	platform.Log(rl.LogInfo, "Saved replay of %d ticks to %s", len(gl.recording.Inputs)/max(1, gl.recording.Ships), path)
 }
 
+// The simulation runs in fixed ticks regardless of the display rate, so physics play out
+// the same on every machine.
+const (
+	TickRate     = 120
+	TickDelta    = float32(1.0 / TickRate)
+	maxFrameTime = float32(0.25) // Longest frame we try to catch up on after a hitch
+)
+
 func (gl *Gameloop) Loop() scenes.SceneCode {
-	game := core.GetGame()
-	for !rl.WindowShouldClose() && !game.Over {
-		handleInput()
-		update()
-		render()
+	p := gl.Session.Platform
+	accumulator := float32(0)
+	outOfInput := false
+	// The game being played changes when players take turns, and is only over once everyone's is
+	for !p.ShouldClose() && !gl.game.Over && !outOfInput && !gl.saved {
+		gl.handleGameStateInput()
+		if gl.game.Paused {
+			accumulator = 0
+		} else {
+			gl.input.poll()
+			accumulator += min(p.FrameTime(), maxFrameTime)
+		}
+		for accumulator >= TickDelta {
+			inputs, ok := gl.input.next()
+			if !ok {
+				// The replay is over
+				outOfInput = true
+				break
+			}
+			if gl.recording != nil {
+				gl.recording.Inputs = append(gl.recording.Inputs, inputs...)
+			}
+			gl.handleInput(inputs, TickDelta)
+			gl.update(TickDelta)
+			accumulator -= TickDelta
+		}
+		gl.render()
 	}
-	if rl.WindowShouldClose() {
+	if p.ShouldClose() {
 		return scenes.Quit
 	}
+	if gl.saved {
+		return scenes.AttractModeScene
+	}
 	return scenes.GameOverScene
 }
 
-// Handle player input
-func handleInput() {
-	game := core.GetGame()
-	if game.DebugMode {
-		handleDebugInput()
+// handleInput applies each player's input to their spaceship for one simulation tick
+func (gl *Gameloop) handleInput(inputs []replay.Input, delta float32) {
+	ApplyInputs(gl.game, inputs, delta)
+}
+
+// ApplyInputs applies each player's input to their spaceship for one simulation tick, in the order of the
+// game's spaceships. Call it just before updating the game for the tick.
+func ApplyInputs(game *core.Game, inputs []replay.Input, delta float32) {
+	for i, input := range inputs[:min(len(inputs), len(game.World.Spaceships))] {
+		applyShipInput(game, game.World.Spaceships[i], input, delta)
 	}
-	// Player input
-	spaceship := &game.World.Spaceship
+}
+
+// applyShipInput applies one player's input to their spaceship for one simulation tick
+func applyShipInput(game *core.Game, spaceship *core.Spaceship, input replay.Input, delta float32) {
 	if spaceship.IsAlive() && !spaceship.InHyperspace {
-		if rl.IsKeyDown(rl.KeyLeft) {
-			spaceship.RotateLeft()
+		if input.Has(replay.RotateLeft) {
+			spaceship.RotateLeft(delta)
 		}
-		if rl.IsKeyDown(rl.KeyRight) {
-			spaceship.RotateRight()
+		if input.Has(replay.RotateRight) {
+			spaceship.RotateRight(delta)
 		}
-		if rl.IsKeyDown(rl.KeyUp) {
+		if input.Has(replay.Thrust) {
 			if !spaceship.FuelBurning {
 				spaceship.FuelBurning = true
-				game.EventBus.Publish("spaceship:thrust", true)
+				events.Publish(game.EventBus, events.SpaceshipThrust{Player: spaceship.Player, Burning: true})
 			}
 		} else {
 			if spaceship.FuelBurning {
 				spaceship.FuelBurning = false
-				game.EventBus.Publish("spaceship:thrust", false)
+				events.Publish(game.EventBus, events.SpaceshipThrust{Player: spaceship.Player, Burning: false})
 			}
 		}
-		pressedKey := rl.GetKeyPressed()
-		if pressedKey == rl.KeySpace {
+		if input.Has(replay.Fire) {
 			spaceship.Fire()
 		}
-		if pressedKey == rl.KeyEnter {
+		if input.Has(replay.Hyperspace) {
 			spaceship.EnterHyperspace()
 		}
 	}
-	// Game state input
-	if rl.IsKeyPressed(rl.KeyEscape) {
-		core.GetGame().Paused = !core.GetGame().Paused
+}
+
+// handleGameStateInput handles the keys that aren't part of the simulation, once per frame
+func (gl *Gameloop) handleGameStateInput() {
+	game := gl.game
+	if game.DebugMode {
+		gl.handleDebugInput()
+		gl.reloadTuning()
+	}
+	p := gl.Session.Platform
+	if p.IsKeyPressed(rl.KeyEscape) {
+		game.Paused = !game.Paused
+	}
+	if game.Paused && p.IsKeyPressed(rl.KeyS) && gl.canSave() {
+		gl.saveGame()
+	}
+	if game.Paused && p.IsKeyPressed(rl.KeyQ) {
+		// Zen never ends otherwise, and any game can be given up on
+		platform.Log(rl.LogInfo, "Game ended from the pause screen")
+		for _, pl := range gl.players {
+			pl.game.Over = true
+		}
+	}
+}
+
+// canSave returns true if the player can save the game right now to carry on later.
+func (gl *Gameloop) canSave() bool {
+	_, replaying := gl.input.(*replayInput)
+	return gl.Session.SavePath != "" && !replaying && len(gl.players) == 1 && gl.game.CanSave()
+}
+
+// saveGame saves the game to the session's save file and ends it, so the player can continue it later.
+func (gl *Gameloop) saveGame() {
+	if err := gl.game.SaveFile(gl.Session.SavePath); err != nil {
+		platform.Log(rl.LogError, "error saving game: %v", err)
+		return
 	}
+	platform.Log(rl.LogInfo, "Saved game to %s", gl.Session.SavePath)
+	gl.saved = true
 }
 
-func handleDebugInput() {
-	game := core.GetGame()
-	if rl.IsKeyPressed(rl.KeyF1) {
-		game.Lives += 1
+func (gl *Gameloop) handleDebugInput() {
+	game := gl.game
+	p := gl.Session.Platform
+	if p.IsKeyPressed(rl.KeyF1) {
+		for _, ship := range game.World.Spaceships {
+			if !ship.Out {
+				ship.Lives += 1
+			}
+		}
 	}
-	if rl.IsKeyPressed(rl.KeyF2) {
+	if p.IsKeyPressed(rl.KeyF2) {
+		// Destroy the rocks once ForEach is done, since their blasts take the collection's lock
+		var rocks []*core.Rock
 		game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
 			if rock, ok := obj.(*core.Rock); ok {
-				_ = rock.OnDestruction(rl.Vector2{})
+				rocks = append(rocks, rock)
 			}
 		})
+		for _, rock := range rocks {
+			_ = rock.OnDestruction(nil, rl.Vector2{})
+		}
 	}
 }
 
-// Update all game state since last time through game loop
-func update() {
-	game := core.GetGame()
-	if game.Paused {
+// reloadTuning checks the tuning file every second and, if it has changed, switches the game over to the
+// new tuning straight away. A file that doesn't load is logged and the game carries on as it was, so a
+// half-saved edit doesn't stop play. Changing tuning mid-game means the recording can't be replayed, so
+// it stops.
+func (gl *Gameloop) reloadTuning() {
+	path := gl.Session.TuningPath
+	p := gl.Session.Platform
+	if _, replaying := gl.input.(*replayInput); replaying || path == "" || p.Time()-gl.tuningCheckedAt < 1 {
 		return
 	}
-	delta := rl.GetFrameTime()
-	game.World.Objects.Update(delta)
-	for _, obs := range game.Observers {
-		_ = obs.Update(game)
+	gl.tuningCheckedAt = p.Time()
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Equal(gl.tuningModTime) {
+		return
+	}
+	gl.tuningModTime = info.ModTime()
+
+	tuning, err := core.LoadTuning(path)
+	if err != nil {
+		platform.Log(rl.LogError, "Tuning not reloaded: %v", err)
+		return
+	}
+	platform.Log(rl.LogInfo, "Reloaded tuning from %s", path)
+	for _, pl := range gl.players {
+		pl.game.Tuning = tuning
+	}
+	gl.Session.Tuning = tuning
+	if gl.recording != nil {
+		platform.Log(rl.LogInfo, "Tuning changed mid-game, so this game won't be saved as a replay")
+		gl.recording = nil
 	}
 }
 
-// Draw all game state
-func render() {
-	game := core.GetGame()
-	rl.BeginDrawing()
-	rl.ClearBackground(rl.RayWhite)
-	drawHud()
+// Update all game state by one simulation tick
+func (gl *Gameloop) update(delta float32) {
+	gl.game.Update(delta)
+	gl.takeTurns()
+}
 
-	game.World.Objects.Draw()
+// takeTurns hands over to the next player still in the game once the current player's spaceship has
+// been lost and the explosion has played out: when the spaceship comes back, or when that was their
+// last life. If nobody else is still in the game, the current player carries on.
+func (gl *Gameloop) takeTurns() {
+	if len(gl.players) < 2 {
+		return
+	}
+	if !gl.game.World.Spaceships[0].IsAlive() && !gl.game.Over {
+		gl.shipLost = true
+		return
+	}
+	if !gl.shipLost {
+		return
+	}
+	gl.shipLost = false
+	for i := 1; i < len(gl.players); i++ {
+		next := (gl.turn + i) % len(gl.players)
+		if !gl.players[next].game.Over {
+			gl.switchTo(next)
+			return
+		}
+	}
+}
+
+// switchTo swaps the whole world out for the given player's, with the sound following it, and
+// announces whose turn it is.
+func (gl *Gameloop) switchTo(turn int) {
+	previous, next := gl.game, gl.players[turn].game
+	if err := gl.audio.Deregister(previous); err != nil {
+		platform.Log(rl.LogError, "error deregistering observer: %v", err)
+	}
+	previous.Observers = slices.DeleteFunc(previous.Observers, func(obs core.EventObserver) bool {
+		return obs == core.EventObserver(gl.audio)
+	})
+	next.Observers = slices.Insert(next.Observers, 0, core.EventObserver(gl.audio))
+	if err := gl.audio.Register(next); err != nil {
+		platform.Log(rl.LogError, "error registering observer: %v", err)
+	}
+	gl.turn, gl.game, gl.warden = turn, next, gl.players[turn].warden
 
-	rl.EndDrawing()
+	platform.Log(rl.LogInfo, "Player %d's turn", turn+1)
+	next.Overlay = func(p platform.Platform) {
+		utils.CenterText(p, fmt.Sprintf("Player %d", turn+1), rl.Vector2{X: next.World.Width / 2, Y: next.World.Height / 3}, 60)
+	}
+	next.Scheduler.After(2*time.Second, func() {
+		next.Overlay = nil
+	})
+}
+
+// Draw all game state
+func (gl *Gameloop) render() {
+	game := gl.game
+	p := gl.Session.Platform
+	p.BeginFrame()
+	// Effects go underneath everything else
+	game.World.Particles.Draw(p)
+	gl.drawHud(p)
+	game.World.Objects.Draw(p)
+
+	p.EndFrame()
 }
 
-// drawHud displays the score and the number of lives remaining
-func drawHud() {
-	game := core.GetGame()
+// drawHud displays the score, the mode and difficulty, and whatever else the mode shows, such as the
+// number of lives remaining, on p
+func (gl *Gameloop) drawHud(p platform.Platform) {
+	game := gl.game
 
 	score := humanize.Comma(int64(game.Score))
-	utils.WriteText(score, rl.Vector2{X: 15, Y: 12}, 36)
+	utils.WriteText(p, score, rl.Vector2{X: 15, Y: 12}, 36)
+	label := fmt.Sprintf("%s  %s", game.Mode, game.Difficulty)
+	if len(gl.players) > 1 {
+		label = fmt.Sprintf("Player %d  %s", gl.turn+1, label)
+	}
+	utils.WriteText(p, label, rl.Vector2{X: 15, Y: 52}, 18)
+	gl.warden.mode.drawHud(p, game)
 
-	size := game.World.Spaceship.Spritesheet.GetSize()
-	for i := range game.Lives {
-		pos := rl.Vector2{X: game.World.Width - 20 - (float32(i) * size.X * 0.6), Y: 20 + (size.Y / 2)}
-		if err := game.World.Spaceship.Spritesheet.Draw(0, 0, pos, rl.Vector2{X: 0, Y: -1}); err != nil {
-			rl.TraceLog(rl.LogError, "error drawing spaceship for lives: %v", err)
+	// Everyone else's score goes underneath, or when flying together, what each player scored themselves
+	y := float32(76)
+	for i, player := range gl.players {
+		if i != gl.turn {
+			utils.WriteText(p, fmt.Sprintf("Player %d  %s", i+1, humanize.Comma(int64(player.game.Score))), rl.Vector2{X: 15, Y: y}, 18)
+			y += 24
 		}
 	}
+	if ships := game.World.Spaceships; len(ships) > 1 {
+		for _, ship := range ships {
+			utils.WriteText(p, fmt.Sprintf("Player %d  %s", ship.Player+1, humanize.Comma(int64(ship.Score))), rl.Vector2{X: 15, Y: y}, 18)
+			y += 24
+		}
+	}
+
+	if game.DebugMode {
+		utils.WriteText(p, fmt.Sprintf("seed %d", game.Random.Seed()), rl.Vector2{X: 15, Y: game.World.Height - 30}, 18)
+	}
 
 	if game.Paused {
-		utils.CenterText("PAUSED", rl.Vector2{X: game.World.Width / 2, Y: game.World.Height / 3}, 40)
+		utils.CenterText(p, "PAUSED", rl.Vector2{X: game.World.Width / 2, Y: game.World.Height / 3}, 40)
+		if gl.canSave() {
+			utils.CenterText(p, "Press S to save and quit", rl.Vector2{X: game.World.Width / 2, Y: game.World.Height/3 + 50}, 20)
+		}
+		utils.CenterText(p, "Press Q to end the game", rl.Vector2{X: game.World.Width / 2, Y: game.World.Height/3 + 80}, 20)
 	} else if game.Overlay != nil {
-		game.Overlay()
+		game.Overlay(p)
 	}
 }
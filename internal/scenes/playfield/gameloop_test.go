@@ -0,0 +1,360 @@
+package playfield
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/gameobjects"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
+	"avoid_the_space_rocks/internal/scenes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// TestScriptedLevel plays a minute of the game with scripted input, the same way the game loop does,
+// and clears the rocks every couple of seconds to push it through the level changes. Run it with
+// -race to prove the game state is only ever touched from the simulation thread.
+func TestScriptedLevel(t *testing.T) {
+	game := core.NewGame(800, 600, 7)
+	gl := &Gameloop{Session: &scenes.Session{Platform: platform.NewHeadless()}, game: game}
+	game.World.Initialize(game)
+	game.Observers = append(game.Observers, NewScoreKeeper(), NewGameWarden())
+	for _, obs := range game.Observers {
+		if err := obs.Register(game); err != nil {
+			t.Fatalf("error registering observer: %v", err)
+		}
+	}
+	defer func() {
+		for _, obs := range game.Observers {
+			_ = obs.Deregister(game)
+		}
+	}()
+	game.StartLevel()
+
+	for tick := range 60 * TickRate {
+		input := replay.Input(0)
+		if tick%240 < 120 {
+			input |= replay.RotateRight
+		}
+		if tick%400 < 20 {
+			input |= replay.Thrust
+		}
+		if tick%30 == 0 {
+			input |= replay.Fire
+		}
+		gl.handleInput([]replay.Input{input}, TickDelta)
+		if tick%(2*TickRate) == 0 {
+			var rocks []*core.Rock
+			game.World.Objects.ForEach(func(obj gameobjects.GameObject) {
+				if rock, ok := obj.(*core.Rock); ok && rock.IsAlive() {
+					rocks = append(rocks, rock)
+				}
+			})
+			for _, rock := range rocks {
+				_ = rock.OnDestruction(nil, rl.Vector2{})
+			}
+		}
+		gl.update(TickDelta)
+		if game.Rocks < 0 {
+			t.Fatalf("Rock count went negative at tick %d", tick)
+		}
+	}
+
+	if game.Level < 2 {
+		t.Errorf("Expected to reach at least level 2, still on level %d", game.Level)
+	}
+	if game.Score == 0 {
+		t.Errorf("Expected to score some points")
+	}
+}
+
+// TestGameloop_Headless runs the real game loop, drawing and sound included, on the headless platform.
+func TestGameloop_Headless(t *testing.T) {
+	h := platform.NewHeadless()
+	h.MaxFrames = 10 * 60
+	h.Script = func(frame int) {
+		h.Hold(rl.KeyRight, frame%120 < 60)
+		if frame%20 == 0 {
+			h.Press(rl.KeySpace)
+		}
+	}
+	gl := &Gameloop{Session: &scenes.Session{Platform: h, Seed: 11}}
+	gl.Init(800, 600)
+	code := gl.Loop()
+	gl.Close()
+
+	if code != scenes.Quit {
+		t.Errorf("Expected the loop to quit when the platform closed, got scene %v", code)
+	}
+	if gl.Session.LastGame != gl.game {
+		t.Errorf("Expected the session to remember the game")
+	}
+	if now := gl.game.Scheduler.Now().Seconds(); now < 9.9 || now > 10.1 {
+		t.Errorf("Expected ten seconds of game time, got %f", now)
+	}
+	if gl.game.Level != 1 {
+		t.Errorf("Expected to still be on level 1, got %d", gl.game.Level)
+	}
+}
+
+// TestGameloop_Replay records a game played on the headless platform, then plays the replay back and
+// checks it ends up in exactly the same state.
+func TestGameloop_Replay(t *testing.T) {
+	h := platform.NewHeadless()
+	h.MaxFrames = 30 * 60
+	h.Script = func(frame int) {
+		h.Hold(rl.KeyLeft, frame%200 < 50)
+		h.Hold(rl.KeyUp, frame%300 < 30)
+		if frame%15 == 0 {
+			h.Press(rl.KeySpace)
+		}
+		if frame%700 == 0 {
+			h.Press(rl.KeyEnter)
+		}
+	}
+	session := &scenes.Session{Platform: h, ReplayDir: t.TempDir()}
+	recorded := &Gameloop{Session: session}
+	recorded.Init(800, 600)
+	recorded.Loop()
+	recorded.Close()
+
+	files, err := filepath.Glob(filepath.Join(session.ReplayDir, "*.replay"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one replay file to be saved, got %v (%v)", files, err)
+	}
+	r, err := replay.Load(files[0])
+	if err != nil {
+		t.Fatalf("Unexpected error loading replay: %v", err)
+	}
+	if r.Seed != recorded.game.Random.Seed() || len(r.Inputs) == 0 {
+		t.Fatalf("Expected the replay to hold the seed and inputs, got seed %d and %d inputs", r.Seed, len(r.Inputs))
+	}
+
+	// Play it back with nobody at the keyboard
+	session.Platform = platform.NewHeadless()
+	session.Replay = r
+	session.ReplayDir = ""
+	replayed := &Gameloop{Session: session}
+	replayed.Init(1024, 768)
+	replayed.Loop()
+	replayed.Close()
+
+	want, got := recorded.game, replayed.game
+	if got.Scheduler.Now() != want.Scheduler.Now() {
+		t.Errorf("Expected the replay to run until %v, ran until %v", want.Scheduler.Now(), got.Scheduler.Now())
+	}
+	if got.Score != want.Score || got.Lives() != want.Lives() || got.Level != want.Level {
+		t.Errorf("Expected score %d, lives %d, level %d; got %d, %d, %d",
+			want.Score, want.Lives(), want.Level, got.Score, got.Lives(), got.Level)
+	}
+	if got.World.Spaceships[0].Position != want.World.Spaceships[0].Position {
+		t.Errorf("Expected the spaceship at %v, got %v", want.World.Spaceships[0].Position, got.World.Spaceships[0].Position)
+	}
+}
+
+// TestGameloop_SaveAndContinue pauses a game to save it, then continues it in a new game loop and checks
+// both play on the same.
+func TestGameloop_SaveAndContinue(t *testing.T) {
+	h := platform.NewHeadless()
+	h.Script = func(frame int) {
+		switch frame {
+		case 5 * 60:
+			h.Press(rl.KeyEscape)
+		case 5*60 + 1:
+			h.Press(rl.KeyS)
+		}
+	}
+	session := &scenes.Session{Platform: h, Seed: 5, SavePath: filepath.Join(t.TempDir(), "save.json")}
+	first := &Gameloop{Session: session}
+	first.Init(800, 600)
+	defer first.Close()
+	if code := first.Loop(); code != scenes.AttractModeScene {
+		t.Fatalf("Expected saving to return to the attract scene, got scene %v", code)
+	}
+	if !session.HasSave() {
+		t.Fatalf("Expected the game to be saved")
+	}
+
+	session.Continue = true
+	second := &Gameloop{Session: session}
+	second.Init(800, 600)
+	defer second.Close()
+	if session.Continue || session.HasSave() {
+		t.Errorf("Expected the save to be used up")
+	}
+	if second.recording != nil {
+		t.Errorf("Expected a continued game not to be recorded")
+	}
+
+	want, got := first.game, second.game
+	want.Paused = false
+	for tick := range 20 * TickRate {
+		input := replay.Input(0)
+		if tick%300 < 100 {
+			input |= replay.RotateLeft
+		}
+		if tick%60 == 0 {
+			input |= replay.Fire
+		}
+		for _, gl := range []*Gameloop{first, second} {
+			gl.handleInput([]replay.Input{input}, TickDelta)
+			gl.update(TickDelta)
+		}
+	}
+	if got.Scheduler.Now() != want.Scheduler.Now() || got.Score != want.Score || got.Lives() != want.Lives() || got.Level != want.Level {
+		t.Errorf("Expected to carry on to %v with score %d, lives %d, level %d; got %v, %d, %d, %d",
+			want.Scheduler.Now(), want.Score, want.Lives(), want.Level, got.Scheduler.Now(), got.Score, got.Lives(), got.Level)
+	}
+	if got.World.Spaceships[0].Position != want.World.Spaceships[0].Position {
+		t.Errorf("Expected the spaceship at %v, got %v", want.World.Spaceships[0].Position, got.World.Spaceships[0].Position)
+	}
+}
+
+// TestGameloop_ReloadTuning changes the tuning file under a game in debug mode and checks the game picks
+// up good changes and ignores bad ones.
+func TestGameloop_ReloadTuning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	session := &scenes.Session{Platform: platform.NewHeadless(), Seed: 3, TuningPath: path}
+	gl := &Gameloop{Session: session}
+	gl.Init(800, 600)
+	defer gl.Close()
+	gl.game.DebugMode = true
+
+	edit := func(contents string, age time.Duration) {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		gl.tuningCheckedAt = -1
+		gl.handleGameStateInput()
+	}
+
+	edit(`{"bullet": {"speed": 900}}`, time.Minute)
+	if gl.game.Tuning.Bullet.Speed != 900 || session.Tuning != gl.game.Tuning {
+		t.Errorf("Expected the new bullet speed to be picked up, got %v", gl.game.Tuning.Bullet.Speed)
+	}
+	if gl.recording != nil {
+		t.Errorf("Expected recording to stop once the tuning changed")
+	}
+
+	edit(`{"bullet": {"speed": -1}}`, 2*time.Minute)
+	if gl.game.Tuning.Bullet.Speed != 900 {
+		t.Errorf("Expected bad tuning to be ignored, got bullet speed %v", gl.game.Tuning.Bullet.Speed)
+	}
+}
+
+// TestGameloop_TwoPlayers plays a two-player game with nobody at the controls until both players are out
+// of lives, then checks the players took turns on their own rock fields and the replay plays out the same.
+func TestGameloop_TwoPlayers(t *testing.T) {
+	session := &scenes.Session{Platform: platform.NewHeadless(), Seed: 3, Players: 2, ReplayDir: t.TempDir()}
+	recorded := &Gameloop{Session: session}
+	recorded.Init(800, 600)
+	if code := recorded.Loop(); code != scenes.GameOverScene {
+		t.Fatalf("Expected the game to end, got scene %v", code)
+	}
+	recorded.Close()
+
+	players := session.LastPlayers
+	if len(players) != 2 || session.LastGame != players[0] {
+		t.Fatalf("Expected the session to remember both players' games, got %d", len(players))
+	}
+	for i, game := range players {
+		if !game.Over || game.Lives() != 0 {
+			t.Errorf("Expected player %d to be out of lives, has %d", i+1, game.Lives())
+		}
+	}
+	if players[0].Random.Seed() == players[1].Random.Seed() {
+		t.Errorf("Expected each player to have a rock field of their own")
+	}
+	if players[1].Scheduler.Now() == 0 {
+		t.Errorf("Expected player 2 to get a turn")
+	}
+
+	files, err := filepath.Glob(filepath.Join(session.ReplayDir, "*.replay"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one replay file to be saved, got %v (%v)", files, err)
+	}
+	r, err := replay.Load(files[0])
+	if err != nil || r.Players != 2 {
+		t.Fatalf("Expected a replay of two players, got %+v (%v)", r, err)
+	}
+	session.Replay = r
+	session.ReplayDir = ""
+	replayed := &Gameloop{Session: session}
+	replayed.Init(800, 600)
+	replayed.Loop()
+	replayed.Close()
+	for i, game := range session.LastPlayers {
+		want := players[i]
+		if game.Score != want.Score || game.Level != want.Level || game.Scheduler.Now() != want.Scheduler.Now() {
+			t.Errorf("Expected player %d's replay to score %d on level %d at %v, got %d on %d at %v",
+				i+1, want.Score, want.Level, want.Scheduler.Now(), game.Score, game.Level, game.Scheduler.Now())
+		}
+	}
+}
+
+// TestGameloop_CoOp plays a game with two spaceships flying at once, each player on their own keys, until
+// both are out of lives, then checks each player scored for themselves and the replay plays out the same.
+func TestGameloop_CoOp(t *testing.T) {
+	h := platform.NewHeadless()
+	h.Script = func(frame int) {
+		h.Hold(rl.KeyLeft, frame%200 < 50)
+		h.Hold(rl.KeyD, frame%160 < 40)
+		if frame%15 == 0 {
+			h.Press(rl.KeySpace)
+		}
+		if frame%20 == 0 {
+			h.Press(rl.KeyLeftShift)
+		}
+	}
+	session := &scenes.Session{Platform: h, Seed: 5, Ships: 2, ReplayDir: t.TempDir()}
+	recorded := &Gameloop{Session: session}
+	recorded.Init(800, 600)
+	if code := recorded.Loop(); code != scenes.GameOverScene {
+		t.Fatalf("Expected the game to end, got scene %v", code)
+	}
+	recorded.Close()
+
+	want := session.LastGame
+	ships := want.World.Spaceships
+	if len(ships) != 2 || want.Lives() != 0 {
+		t.Fatalf("Expected two spaceships out of lives, got %d with %d lives", len(ships), want.Lives())
+	}
+	if ships[0].Score == 0 || ships[1].Score == 0 || ships[0].Score+ships[1].Score > want.Score {
+		t.Errorf("Expected each player to score their share of %d, got %d and %d", want.Score, ships[0].Score, ships[1].Score)
+	}
+
+	files, err := filepath.Glob(filepath.Join(session.ReplayDir, "*.replay"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one replay file to be saved, got %v (%v)", files, err)
+	}
+	r, err := replay.Load(files[0])
+	if err != nil || r.Ships != 2 {
+		t.Fatalf("Expected a replay of two spaceships, got %+v (%v)", r, err)
+	}
+	session.Replay = r
+	session.ReplayDir = ""
+	replayed := &Gameloop{Session: session}
+	replayed.Init(800, 600)
+	replayed.Loop()
+	replayed.Close()
+	got := session.LastGame
+	if got.Score != want.Score || got.Level != want.Level || got.Scheduler.Now() != want.Scheduler.Now() {
+		t.Errorf("Expected the replay to score %d on level %d at %v, got %d on %d at %v",
+			want.Score, want.Level, want.Scheduler.Now(), got.Score, got.Level, got.Scheduler.Now())
+	}
+	for i, ship := range got.World.Spaceships {
+		if ship.Score != ships[i].Score {
+			t.Errorf("Expected player %d's replay to score %d, got %d", i+1, ships[i].Score, ship.Score)
+		}
+	}
+}
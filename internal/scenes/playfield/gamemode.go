@@ -0,0 +1,211 @@
+package playfield
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/utils"
+	"fmt"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"time"
+)
+
+// gameMode runs the flow of a game in one of core's modes: how it starts, what happens when the
+// playfield is cleared or a spaceship is lost, when the game is over, and what it adds to the HUD.
+// Anything it decides has to come from the game's state, since a continued game gets a fresh gameMode.
+type gameMode interface {
+	start(game *core.Game)                                    // Kicks off a new game
+	update(game *core.Game)                                   // Called at the end of every tick
+	levelCleared(game *core.Game)                             // Called when no enemies remain while a level is under way
+	spaceshipDestroyed(game *core.Game, ship *core.Spaceship) // Called when one of the spaceships has been destroyed
+	drawHud(p platform.Platform, game *core.Game)             // Draws whatever the mode shows besides the score on p
+}
+
+// newGameMode returns the flow for a mode, treating unknown modes as Classic.
+func newGameMode(mode core.Mode) gameMode {
+	switch mode {
+	case core.ModeTimeAttack:
+		return &timeAttackMode{}
+	case core.ModeSurvival:
+		return &survivalMode{}
+	case core.ModeZen:
+		return &zenMode{}
+	case core.ModeBilliards:
+		// Only the rocks play differently, which is up to the simulation
+		return &classicMode{}
+	default:
+		return &classicMode{}
+	}
+}
+
+// classicMode plays level after level until the player runs out of lives.
+type classicMode struct{}
+
+func (m *classicMode) start(game *core.Game) {
+	game.StartLevel()
+}
+
+func (m *classicMode) update(_ *core.Game) {
+}
+
+func (m *classicMode) levelCleared(game *core.Game) {
+	nextLevel(game)
+}
+
+func (m *classicMode) spaceshipDestroyed(game *core.Game, ship *core.Spaceship) {
+	loseLife(game, ship)
+}
+
+func (m *classicMode) drawHud(p platform.Platform, game *core.Game) {
+	drawLives(p, game)
+}
+
+// timeAttackLimit is how long a time attack game lasts.
+const timeAttackLimit = 3 * time.Minute
+
+// timeAttackMode plays levels as in Classic, but the game ends when the clock runs out rather than the
+// lives. Losing the spaceship only costs the time it takes to respawn.
+type timeAttackMode struct{}
+
+func (m *timeAttackMode) start(game *core.Game) {
+	game.StartLevel()
+}
+
+func (m *timeAttackMode) update(game *core.Game) {
+	if !game.Over && game.Scheduler.Now() >= timeAttackLimit {
+		platform.Log(rl.LogInfo, "Time's up")
+		game.Over = true
+	}
+}
+
+func (m *timeAttackMode) levelCleared(game *core.Game) {
+	nextLevel(game)
+}
+
+func (m *timeAttackMode) spaceshipDestroyed(game *core.Game, ship *core.Spaceship) {
+	game.Scheduler.After(4*time.Second, ship.Spawn)
+}
+
+func (m *timeAttackMode) drawHud(p platform.Platform, game *core.Game) {
+	remaining := max(0, timeAttackLimit-game.Scheduler.Now())
+	utils.CenterText(p, formatClock(remaining), rl.Vector2{X: game.World.Width / 2, Y: 30}, 36)
+}
+
+// survivalLevelEvery is how long each level of a survival game lasts before the next one takes over.
+const survivalLevelEvery = 30 * time.Second
+
+// survivalMode has no breaks between levels. Rocks drift in one at a time for as long as the player
+// survives, and the level goes up on the clock, so the rocks come faster and the aliens get meaner.
+type survivalMode struct {
+	lastTick time.Duration // Game time of the previous tick
+	ticked   bool          // lastTick is set; it isn't until the first tick after starting or continuing
+}
+
+func (m *survivalMode) start(game *core.Game) {
+	game.Overlay = func(p platform.Platform) {
+		utils.CenterText(p, "Survive", rl.Vector2{X: game.World.Width / 2, Y: game.World.Height / 3}, 60)
+	}
+	game.Scheduler.After(2*time.Second, func() {
+		game.Overlay = nil
+	})
+	game.RaiseLevel()
+	game.SpawnRock()
+}
+
+func (m *survivalMode) update(game *core.Game) {
+	now := game.Scheduler.Now()
+	last := m.lastTick
+	m.lastTick = now
+	if !m.ticked {
+		m.ticked = true
+		return
+	}
+	for game.Level < 1+int(now/survivalLevelEvery) {
+		game.RaiseLevel()
+	}
+	// A rock drifts in each time the clock passes a multiple of the delay
+	every := game.TrickleDelay()
+	if now/every != last/every && game.Rocks < game.Tuning.Rock.MaxCount {
+		game.SpawnRock()
+	}
+}
+
+// levelCleared brings the next rock in straight away, so the playfield is never empty for long.
+func (m *survivalMode) levelCleared(game *core.Game) {
+	game.SpawnRock()
+}
+
+func (m *survivalMode) spaceshipDestroyed(game *core.Game, ship *core.Spaceship) {
+	loseLife(game, ship)
+}
+
+func (m *survivalMode) drawHud(p platform.Platform, game *core.Game) {
+	drawLives(p, game)
+	utils.CenterText(p, formatClock(game.Scheduler.Now()), rl.Vector2{X: game.World.Width / 2, Y: 30}, 36)
+}
+
+// zenMode plays levels as in Classic with no aliens and a spaceship that can't be destroyed, for
+// practice. It goes on until the player ends it from the pause screen.
+type zenMode struct{}
+
+func (m *zenMode) start(game *core.Game) {
+	game.StartLevel()
+}
+
+func (m *zenMode) update(_ *core.Game) {
+}
+
+func (m *zenMode) levelCleared(game *core.Game) {
+	nextLevel(game)
+}
+
+// spaceshipDestroyed can't happen in Zen, but respawns the spaceship for free just in case.
+func (m *zenMode) spaceshipDestroyed(game *core.Game, ship *core.Spaceship) {
+	game.Scheduler.After(4*time.Second, ship.Spawn)
+}
+
+func (m *zenMode) drawHud(_ platform.Platform, _ *core.Game) {
+}
+
+// nextLevel ends the level and starts the next one.
+func nextLevel(game *core.Game) {
+	game.StopLevel()
+	game.StartLevel()
+}
+
+// loseLife takes a life for the lost spaceship, waits a moment, and then respawns it. A player out of
+// lives sits out the rest of the game, which is over once every player is.
+func loseLife(game *core.Game, ship *core.Spaceship) {
+	ship.Lives--
+	game.Scheduler.After(4*time.Second, func() {
+		if ship.Lives > 0 {
+			ship.Spawn()
+		} else if game.Lives() == 0 {
+			platform.Log(rl.LogInfo, "Game over")
+			game.Over = true
+		} else {
+			platform.Log(rl.LogInfo, "Player %d is out of lives", ship.Player+1)
+			ship.Out = true
+		}
+	})
+}
+
+// drawLives shows a spaceship on p for each life remaining across the top right of the screen, a row
+// for each player.
+func drawLives(p platform.Platform, game *core.Game) {
+	for _, ship := range game.World.Spaceships {
+		size := ship.Spritesheet.GetSize()
+		y := 20 + (size.Y / 2) + float32(ship.Player)*size.Y
+		for i := range ship.Lives {
+			pos := rl.Vector2{X: game.World.Width - 20 - (float32(i) * size.X * 0.6), Y: y}
+			if err := ship.Spritesheet.Draw(p, 0, 0, pos, rl.Vector2{X: 0, Y: -1}); err != nil {
+				platform.Log(rl.LogError, "error drawing spaceship for lives: %v", err)
+			}
+		}
+	}
+}
+
+// formatClock formats game time as minutes and seconds, rounding down.
+func formatClock(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,136 @@
+package playfield
+
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/scenes"
+	"testing"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// startMode starts a game in the given mode on the headless platform, with nobody at the controls.
+func startMode(t *testing.T, mode core.Mode) *Gameloop {
+	gl := &Gameloop{Session: &scenes.Session{Platform: platform.NewHeadless(), Seed: 3, Mode: mode}}
+	gl.Init(800, 600)
+	t.Cleanup(gl.Close)
+	return gl
+}
+
+// playFor runs the game for a stretch of game time, or until it's over.
+func playFor(gl *Gameloop, d time.Duration) {
+	for range int(d.Seconds() * TickRate) {
+		if gl.game.Over {
+			return
+		}
+		gl.update(TickDelta)
+	}
+}
+
+func TestTimeAttack_EndsOnTheClock(t *testing.T) {
+	gl := startMode(t, core.ModeTimeAttack)
+	destroyed := 0
+	events.Subscribe(gl.game.EventBus, func(events.SpaceshipDestroyed) { destroyed++ }, events.PriorityNormal)
+
+	playFor(gl, timeAttackLimit-time.Second)
+	if gl.game.Over {
+		t.Fatalf("Expected the game to last until the clock runs out, over at %v", gl.game.Scheduler.Now())
+	}
+	playFor(gl, 2*time.Second)
+	if !gl.game.Over {
+		t.Errorf("Expected the game to be over once the clock runs out, still going at %v", gl.game.Scheduler.Now())
+	}
+	if destroyed == 0 || gl.game.Lives() != 3 {
+		t.Errorf("Expected losing the spaceship not to cost lives, lost it %d times and have %d lives", destroyed, gl.game.Lives())
+	}
+}
+
+func TestSurvival_RocksKeepComing(t *testing.T) {
+	gl := startMode(t, core.ModeSurvival)
+	if gl.game.Level != 1 || !gl.game.LevelUnderway() {
+		t.Fatalf("Expected the first level to be under way straight away, on level %d", gl.game.Level)
+	}
+	spawned := 0
+	events.Subscribe(gl.game.EventBus, func(e events.RockSpawned) {
+		if e.Size == core.RockBig {
+			spawned++
+		}
+	}, events.PriorityNormal)
+
+	playFor(gl, survivalLevelEvery+time.Second)
+	if gl.game.Over {
+		t.Fatalf("Expected to survive %v with nobody at the controls", survivalLevelEvery)
+	}
+	if gl.game.Level != 2 {
+		t.Errorf("Expected level 2 after %v, got %d", survivalLevelEvery, gl.game.Level)
+	}
+	if spawned < 4 {
+		t.Errorf("Expected rocks to keep drifting in, only %d did", spawned)
+	}
+}
+
+func TestZen_NoDeathNoEnd(t *testing.T) {
+	gl := startMode(t, core.ModeZen)
+	aliens := 0
+	events.Subscribe(gl.game.EventBus, func(events.AlienSpawned) { aliens++ }, events.PriorityNormal)
+
+	playFor(gl, 2*time.Minute)
+	if gl.game.Over || !gl.game.World.Spaceships[0].IsAlive() || gl.game.Lives() != 3 {
+		t.Errorf("Expected the spaceship to come through untouched, alive %v with %d lives",
+			gl.game.World.Spaceships[0].IsAlive(), gl.game.Lives())
+	}
+	if aliens != 0 {
+		t.Errorf("Expected no aliens, got %d", aliens)
+	}
+}
+
+func TestGameloop_EndFromPause(t *testing.T) {
+	h := platform.NewHeadless()
+	h.Script = func(frame int) {
+		switch frame {
+		case 60:
+			h.Press(rl.KeyEscape)
+		case 61:
+			h.Press(rl.KeyQ)
+		}
+	}
+	gl := &Gameloop{Session: &scenes.Session{Platform: h, Seed: 3, Mode: core.ModeZen}}
+	gl.Init(800, 600)
+	defer gl.Close()
+	if code := gl.Loop(); code != scenes.GameOverScene || !gl.game.Over {
+		t.Errorf("Expected Q on the pause screen to end the game, got scene %v", code)
+	}
+}
+
+func TestCoOp_SpaceshipsRespawnOnTheirOwn(t *testing.T) {
+	gl := &Gameloop{Session: &scenes.Session{Platform: platform.NewHeadless(), Seed: 3, Ships: 2}}
+	gl.Init(800, 600)
+	defer gl.Close()
+	playFor(gl, 3*time.Second)
+
+	first, second := gl.game.World.Spaceships[0], gl.game.World.Spaceships[1]
+	if err := second.OnDestruction(nil, rl.Vector2{}); err != nil {
+		t.Fatalf("Unexpected error destroying the spaceship: %v", err)
+	}
+	gl.update(TickDelta)
+	if second.Lives != 2 || first.Lives != 3 || !first.IsAlive() {
+		t.Errorf("Expected only the second player to lose a life, have %d and %d", first.Lives, second.Lives)
+	}
+	playFor(gl, 4100*time.Millisecond)
+	if !second.IsAlive() {
+		t.Errorf("Expected the second player's spaceship to respawn")
+	}
+
+	// Out of lives, the second player sits out while the first plays on
+	second.Lives = 1
+	if err := second.OnDestruction(nil, rl.Vector2{}); err != nil {
+		t.Fatalf("Unexpected error destroying the spaceship: %v", err)
+	}
+	playFor(gl, 5*time.Second)
+	if second.IsAlive() || !second.Out || gl.game.Over {
+		t.Errorf("Expected the second player to be out with the game going on, alive %v out %v over %v",
+			second.IsAlive(), second.Out, gl.game.Over)
+	}
+}
@@ -2,123 +2,115 @@ package playfield
 
 import (
 	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"time"
 )
 
+// GameWarden keeps track of the rocks, the end of each level, and the fate of each spaceship, leaving the
+// game mode to decide what each of those means.
 type GameWarden struct {
-	game *core.Game
+	game          *core.Game
+	mode          gameMode
+	subscriptions []*events.Subscription
 }
 
 var _ core.EventObserver = (*GameWarden)(nil)
 
-func (gw *GameWarden) eventMappings() []eventMapping {
-	return []eventMapping{
-		{"rock:spawned", gw.rockSpawnedWatcher},
-		{"rock:destroyed", gw.rockDestroyedWatcher},
-		{"alien:destroyed", gw.alienRemovedWatcher},
-		{"alien:left_playfield", gw.alienRemovedWatcher},
-		{"spaceship:destroyed", gw.spaceshipDestroyedWatcher},
-		{"spaceship:enter_hyperspace", gw.spaceshipHyperspaceWatcher},
-	}
-}
-
 func NewGameWarden() *GameWarden {
 	return &GameWarden{}
 }
 
 func (gw *GameWarden) Register(game *core.Game) error {
 	gw.game = game
-	for _, sub := range gw.eventMappings() {
-		if err := game.EventBus.SubscribeAsync(sub.event, sub.handler, false); err != nil {
-			rl.TraceLog(rl.LogError, "error subscribing to %s event: %v", sub.event, err)
-			return err
-		}
+	gw.mode = newGameMode(game.Mode)
+	bus := game.EventBus
+	gw.subscriptions = []*events.Subscription{
+		// Count rocks before anyone else looks, and check for the end of level after everyone else
+		events.Subscribe(bus, gw.rockSpawnedWatcher, events.PriorityHigh),
+		events.Subscribe(bus, gw.rockDestroyedWatcher, events.PriorityLow),
+		events.Subscribe(bus, gw.alienDestroyedWatcher, events.PriorityLow),
+		events.Subscribe(bus, gw.alienLeftWatcher, events.PriorityLow),
+		events.Subscribe(bus, gw.spaceshipDestroyedWatcher, events.PriorityNormal),
+		events.Subscribe(bus, gw.spaceshipHyperspaceWatcher, events.PriorityNormal),
 	}
 	return nil
 }
 
 func (gw *GameWarden) Deregister(game *core.Game) error {
-	for _, sub := range gw.eventMappings() {
-		if err := game.EventBus.Unsubscribe(sub.event, sub.handler); err != nil {
-			rl.TraceLog(rl.LogError, "error unsubscribing from %s event: %v", sub.event, err)
+	for _, sub := range gw.subscriptions {
+		if err := game.EventBus.Unsubscribe(sub); err != nil {
+			platform.Log(rl.LogError, "error unsubscribing: %v", err)
 			return err
 		}
 	}
+	gw.subscriptions = nil
 	return nil
 }
 
-func (gw *GameWarden) Update(_ *core.Game) error {
+func (gw *GameWarden) Update(game *core.Game) error {
+	gw.mode.update(game)
 	return nil
 }
 
+// Start kicks off a new game the way its mode begins. A continued game is already under way, so it isn't
+// started again.
+func (gw *GameWarden) Start() {
+	gw.mode.start(gw.game)
+}
+
 // rockSpawnedWatcher is called when a new rock is added to the level.
-func (gw *GameWarden) rockSpawnedWatcher(_ core.RockSize) {
+func (gw *GameWarden) rockSpawnedWatcher(_ events.RockSpawned) {
 	gw.game.Rocks += 1
 }
 
 // rockDestroyedWatcher is called when a rock is destroyed. Calls the end-of-level check.
-func (gw *GameWarden) rockDestroyedWatcher(_ core.RockSize) {
+func (gw *GameWarden) rockDestroyedWatcher(_ events.RockDestroyed) {
 	gw.game.Rocks -= 1
 	gw.checkEndOfLevel()
 }
 
-// alienDestroyedWatcher is called when an alien is destroyed or leaves the playfield. Calls the end-of-level check.
-func (gw *GameWarden) alienRemovedWatcher(_ core.AlienSize) {
+// alienDestroyedWatcher is called when an alien is destroyed. Calls the end-of-level check.
+func (gw *GameWarden) alienDestroyedWatcher(_ events.AlienDestroyed) {
 	gw.checkEndOfLevel()
 }
 
-// checkEndOfLevel sees if there are remaining enemies; if not, it starts the next level.
+// alienLeftWatcher is called when an alien leaves the playfield. Calls the end-of-level check.
+func (gw *GameWarden) alienLeftWatcher(_ events.AlienLeftPlayfield) {
+	gw.checkEndOfLevel()
+}
+
+// checkEndOfLevel sees if there are remaining enemies; if not, the mode decides what comes next, which is
+// usually the next level. Several enemies can go in the same tick, so the level only ends once.
 func (gw *GameWarden) checkEndOfLevel() {
-	if !gw.game.World.Objects.HasRemainingEnemies() {
-		gw.game.StopLevel()
-		go gw.game.StartLevel()
+	if gw.game.LevelUnderway() && !gw.game.World.Objects.HasRemainingEnemies() {
+		gw.mode.levelCleared(gw.game)
 	}
 }
 
-// SpaceshipDestroyedWatcher is called when the spaceship is destroyed. It decrements the lives
-// remaining, waits a moment, and then respawns the spaceship. If the player is out of lives it goes to
-// game over state.
-func (gw *GameWarden) spaceshipDestroyedWatcher() {
-	gw.game.Lives--
-	time.Sleep(4 * time.Second)
-	if gw.game.Lives > 0 {
-		gw.game.World.Spaceship.Spawn()
-	} else {
-		rl.TraceLog(rl.LogInfo, "Game over")
-		gw.game.Over = true
-	}
+// SpaceshipDestroyedWatcher is called when a spaceship is destroyed. The mode decides whether it costs
+// its player a life and when it comes back; the other spaceships fly on regardless.
+func (gw *GameWarden) spaceshipDestroyedWatcher(e events.SpaceshipDestroyed) {
+	gw.mode.spaceshipDestroyed(gw.game, gw.game.World.Spaceships[e.Player])
 }
 
 // SpaceshipHyperspaceWatcher moves the spaceship to a random location with some graphic flair.
 // The audio clip is two seconds but the re-entry clack is at 1.9 seconds, so adjust accordingly.
-func (gw *GameWarden) spaceshipHyperspaceWatcher() {
-	s := &gw.game.World.Spaceship
+func (gw *GameWarden) spaceshipHyperspaceWatcher(e events.SpaceshipEnteredHyperspace) {
+	s := gw.game.World.Spaceships[e.Player]
 	// Stop the spaceship and put it in hyperspace
 	s.InHyperspace = true
 	s.Velocity = rl.Vector2{}
 	s.Acceleration = rl.Vector2{}
-	// Send four pieces of the spaceship to random locations
-	pieces := make([]*core.Shrapnel, 4)
-	for i := 0; i < 4; i++ {
-		piece := core.NewShrapnel(s.Position, s.Spritesheet, 1800, i+3)
-		pos := gw.game.World.RandomPosition()
-		piece.Velocity = rl.Vector2Normalize(rl.Vector2Subtract(pos, piece.Position))
-		piece.Velocity = rl.Vector2Scale(piece.Velocity, 300)
-		pieces[i] = &piece
-		gw.game.World.Objects.Add(&piece)
-	}
-	time.Sleep(900 * time.Millisecond)
-	// Place the spaceship at a random location, and send the four pieces to its new location
-	s.Position = gw.game.World.RandomPosition()
-	for i := 0; i < 4; i++ {
-		// Create a vector that points from piece.Position to s.Position
-		piece := &pieces[i]
-		(*piece).Velocity = rl.Vector2Normalize(rl.Vector2Subtract(s.Position, (*piece).Position))
-		// Scale the velocity so it arrives at the new space position
-		distance := rl.Vector2Distance(s.Position, (*piece).Position)
-		(*piece).Velocity = rl.Vector2Scale((*piece).Velocity, distance)
-	}
-	time.Sleep(time.Second)
-	s.InHyperspace = false
+	// Break the spaceship up into a cloud
+	gw.game.World.Particles.Burst(core.HyperspaceDeparture, 40, s.Position, s.Rotation, rl.Vector2{})
+	gw.game.Scheduler.After(900*time.Millisecond, func() {
+		// Place the spaceship at a random location, and gather a cloud there that comes together as it reappears
+		s.Position = gw.game.World.RandomPosition()
+		gw.game.World.Particles.Burst(core.HyperspaceArrival, 40, s.Position, s.Rotation, rl.Vector2{})
+	})
+	gw.game.Scheduler.After(1900*time.Millisecond, func() {
+		s.InHyperspace = false
+	})
 }
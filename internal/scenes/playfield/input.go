@@ -0,0 +1,116 @@
+package playfield
+
+import (
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"slices"
+)
+
+// inputSource supplies the players' input for each simulation tick.
+type inputSource interface {
+	// poll is called once per frame while the game is running, before that frame's ticks.
+	poll()
+	// next returns the input for each spaceship for the next tick, or false if there is no more input.
+	next() ([]replay.Input, bool)
+}
+
+// keyBindings are the keys that fly one spaceship.
+type keyBindings struct {
+	rotateLeft, rotateRight, thrust, fire, hyperspace int32
+}
+
+// shipKeys are the key bindings for each spaceship in turn: the arrow keys for the first, and WASD for
+// the second.
+var shipKeys = []keyBindings{
+	{rotateLeft: rl.KeyLeft, rotateRight: rl.KeyRight, thrust: rl.KeyUp, fire: rl.KeySpace, hyperspace: rl.KeyEnter},
+	{rotateLeft: rl.KeyA, rotateRight: rl.KeyD, thrust: rl.KeyW, fire: rl.KeyLeftShift, hyperspace: rl.KeyS},
+}
+
+// keyboardInput reads the players' input from the keyboard. Held controls are sampled every frame;
+// presses are latched until a tick consumes them, so none are lost when a frame runs no ticks.
+type keyboardInput struct {
+	platform platform.Platform // Where the keys are read from
+	inputs   []replay.Input    // One for each spaceship
+}
+
+var _ inputSource = (*keyboardInput)(nil)
+
+// newKeyboardInput reads input from p's keyboard for the given number of spaceships, each with its own keys.
+func newKeyboardInput(p platform.Platform, ships int) *keyboardInput {
+	return &keyboardInput{platform: p, inputs: make([]replay.Input, min(max(1, ships), len(shipKeys)))}
+}
+
+func (k *keyboardInput) poll() {
+	p := k.platform
+	for i, keys := range shipKeys[:len(k.inputs)] {
+		input := k.inputs[i] & (replay.Fire | replay.Hyperspace)
+		if p.IsKeyDown(keys.rotateLeft) {
+			input |= replay.RotateLeft
+		}
+		if p.IsKeyDown(keys.rotateRight) {
+			input |= replay.RotateRight
+		}
+		if p.IsKeyDown(keys.thrust) {
+			input |= replay.Thrust
+		}
+		if p.IsKeyPressed(keys.fire) {
+			input |= replay.Fire
+		}
+		if p.IsKeyPressed(keys.hyperspace) {
+			input |= replay.Hyperspace
+		}
+		k.inputs[i] = input
+	}
+}
+
+func (k *keyboardInput) next() ([]replay.Input, bool) {
+	inputs := slices.Clone(k.inputs)
+	for i := range k.inputs {
+		k.inputs[i] &^= replay.Fire | replay.Hyperspace
+	}
+	return inputs, true
+}
+
+// Keyboard reads the first player's input from the keyboard, for games played somewhere other than
+// the playfield, such as on a server.
+type Keyboard struct {
+	input *keyboardInput
+}
+
+// NewKeyboard reads input from the first player's keys on p.
+func NewKeyboard(p platform.Platform) *Keyboard {
+	return &Keyboard{input: newKeyboardInput(p, 1)}
+}
+
+// Poll reads the keys; call it once per frame, before that frame's ticks.
+func (k *Keyboard) Poll() {
+	k.input.poll()
+}
+
+// Next returns the input for the next tick.
+func (k *Keyboard) Next() replay.Input {
+	inputs, _ := k.input.next()
+	return inputs[0]
+}
+
+// replayInput plays back the input recorded in a replay, one tick at a time, ignoring the keyboard.
+type replayInput struct {
+	inputs []replay.Input // Each tick's input, one for each spaceship
+	ships  int
+	tick   int
+}
+
+var _ inputSource = (*replayInput)(nil)
+
+func (r *replayInput) poll() {
+}
+
+func (r *replayInput) next() ([]replay.Input, bool) {
+	start := r.tick * r.ships
+	if start+r.ships > len(r.inputs) {
+		return nil, false
+	}
+	r.tick++
+	return r.inputs[start : start+r.ships], true
+}
@@ -2,48 +2,39 @@ package playfield
 
 import (
 	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/core/events"
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-// Constants for score stuff
-const (
-	shipExtraLife = 10_000
-)
-
 type ScoreKeeper struct {
-	game *core.Game
+	game          *core.Game
+	subscriptions []*events.Subscription
 }
 
 var _ core.EventObserver = (*ScoreKeeper)(nil)
 
-func (sk *ScoreKeeper) eventMappings() []eventMapping {
-	return []eventMapping{
-		{"rock:destroyed", sk.rockScoreHandler},
-		{"alien:destroyed", sk.alienScoreHandler},
-	}
-}
 func NewScoreKeeper() *ScoreKeeper {
 	return &ScoreKeeper{}
 }
 
 func (sk *ScoreKeeper) Register(game *core.Game) error {
 	sk.game = game
-	for _, sub := range sk.eventMappings() {
-		if err := game.EventBus.SubscribeAsync(sub.event, sub.handler, false); err != nil {
-			rl.TraceLog(rl.LogError, "error subscribing to %s event: %v", sub.event, err)
-			return err
-		}
+	sk.subscriptions = []*events.Subscription{
+		events.Subscribe(game.EventBus, sk.rockScoreHandler, events.PriorityNormal),
+		events.Subscribe(game.EventBus, sk.alienScoreHandler, events.PriorityNormal),
 	}
 	return nil
 }
 
 func (sk *ScoreKeeper) Deregister(game *core.Game) error {
-	for _, sub := range sk.eventMappings() {
-		if err := game.EventBus.Unsubscribe(sub.event, sub.handler); err != nil {
-			rl.TraceLog(rl.LogError, "error unsubscribing from %s event: %v", sub.event, err)
+	for _, sub := range sk.subscriptions {
+		if err := game.EventBus.Unsubscribe(sub); err != nil {
+			platform.Log(rl.LogError, "error unsubscribing: %v", err)
 			return err
 		}
 	}
+	sk.subscriptions = nil
 	return nil
 }
 
@@ -51,34 +42,59 @@ func (sk *ScoreKeeper) Update(_ *core.Game) error {
 	return nil
 }
 
-func (sk *ScoreKeeper) rockScoreHandler(size core.RockSize) {
-	switch size {
+func (sk *ScoreKeeper) rockScoreHandler(e events.RockDestroyed) {
+	switch e.Size {
 	case core.RockTiny:
-		sk.addPoints(100)
+		sk.addPoints(100, e.Player)
 	case core.RockSmall:
-		sk.addPoints(75)
+		sk.addPoints(75, e.Player)
 	case core.RockMedium:
-		sk.addPoints(50)
+		sk.addPoints(50, e.Player)
 	case core.RockBig:
-		sk.addPoints(25)
+		sk.addPoints(25, e.Player)
 	}
 }
 
-func (sk *ScoreKeeper) alienScoreHandler(size core.AlienSize) {
-	switch size {
+func (sk *ScoreKeeper) alienScoreHandler(e events.AlienDestroyed) {
+	switch e.Size {
 	case core.AlienSmall:
-		sk.addPoints(250)
+		sk.addPoints(250, e.Player)
 	case core.AlienBig:
-		sk.addPoints(500)
+		sk.addPoints(500, e.Player)
 	}
 }
 
-func (sk *ScoreKeeper) addPoints(points int) {
-	rewardLevel := uint(float64(uint(core.GetGame().Score/shipExtraLife))) + 1
-	pointsForNewLife := rewardLevel * shipExtraLife
-	core.GetGame().Score += uint(points)
-	if core.GetGame().Score >= pointsForNewLife && sk.game.Lives < 20 {
-		sk.game.Lives += 1
-		sk.game.EventBus.Publish("spaceship:extra_life")
+// addPoints adds to the game's score, and to the score of the player who earned them, if one did. Every
+// so many points the game wins an extra life, which goes to whoever needs it most.
+func (sk *ScoreKeeper) addPoints(points int, player int) {
+	game := sk.game
+	if player >= 0 && player < len(game.World.Spaceships) {
+		game.World.Spaceships[player].Score += uint(points)
+	}
+	extraLifeEvery := game.ExtraLifeEvery()
+	rewardLevel := uint(float64(uint(game.Score/extraLifeEvery))) + 1
+	pointsForNewLife := rewardLevel * extraLifeEvery
+	game.Score += uint(points)
+	if !game.Mode.HasLives() || game.Score < pointsForNewLife {
+		return
+	}
+	if ship := neediestSpaceship(game); ship != nil && ship.Lives < game.Tuning.Ship.MaxLives {
+		ship.Lives += 1
+		events.Publish(game.EventBus, events.ExtraLife{Player: ship.Player, Lives: ship.Lives})
+	}
+}
+
+// neediestSpaceship returns the spaceship with the fewest lives, the first player's on a tie, passing over
+// players who are out of the game. It returns nil if they all are.
+func neediestSpaceship(game *core.Game) *core.Spaceship {
+	var neediest *core.Spaceship
+	for _, ship := range game.World.Spaceships {
+		if ship.Out {
+			continue
+		}
+		if neediest == nil || ship.Lives < neediest.Lives {
+			neediest = ship
+		}
 	}
+	return neediest
 }
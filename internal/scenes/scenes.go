@@ -1,5 +1,49 @@
 package scenes
 
+import (
+	"avoid_the_space_rocks/internal/core"
+	"avoid_the_space_rocks/internal/platform"
+	"avoid_the_space_rocks/internal/replay"
+	"os"
+)
+
+// Session is what carries over from one scene to the next while the program runs.
+type Session struct {
+	Platform platform.Platform // What the scenes read input from and draw and play sound on
+
+	Seed        uint64          // Seed for each new game's random source; zero picks one
+	Difficulty  core.Difficulty // The difficulty each new game is played on
+	Mode        core.Mode       // The mode each new game is played in
+	Players     int             // How many players take turns in each new Classic game; zero is one
+	LastGame    *core.Game      // The game most recently played, if any; the first player's if they took turns
+	LastPlayers []*core.Game    // Every player's game in the game most recently played, in turn order
+	Replayed    bool            // LastGame was a replay rather than played for real
+
+	Ships        int  // How many players fly at once in each new game, a spaceship each; zero is one
+	FriendlyFire bool // Players flying at once can shoot each other down
+
+	HighScoresPath string // Where the high scores are kept; empty keeps none
+
+	ReplayDir string         // Where to save a replay of each game; empty saves none
+	Replay    *replay.Replay // A replay to play back in the next game instead of the keyboard
+	SavePath  string         // Where to save a game in progress; empty disables saving
+	Continue  bool           // Carry on the saved game in the next game instead of starting a new one
+
+	Connect string // Address of a server to play the next game on instead of playing here; empty plays here
+
+	Tuning     *core.Tuning // How each new game feels; nil for the defaults
+	TuningPath string       // The file Tuning came from, watched for changes in debug mode; empty if none
+}
+
+// HasSave returns true if there's a saved game to continue.
+func (s *Session) HasSave() bool {
+	if s.SavePath == "" {
+		return false
+	}
+	_, err := os.Stat(s.SavePath)
+	return err == nil
+}
+
 type Scene interface {
 	Init(width, height float32)
 	Loop() SceneCode
@@ -12,5 +56,6 @@ const (
 	AttractModeScene SceneCode = iota
 	GameplayScene
 	GameOverScene
+	NetGameScene
 	Quit
 )
@@ -1,41 +1,97 @@
 package utils
 
-import "math/rand"
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Random is a seeded source of random numbers. Every game owns one so that the same seed
+// and the same inputs always play out the same way.
+type Random struct {
+	seed uint64
+	pcg  *rand.PCG
+	rnd  *rand.Rand
+}
+
+// NewRandom returns a random source for the given seed. A seed of zero picks one from the clock.
+func NewRandom(seed uint64) *Random {
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	pcg := rand.NewPCG(seed, seed)
+	return &Random{
+		seed: seed,
+		pcg:  pcg,
+		rnd:  rand.New(pcg),
+	}
+}
+
+// MarshalBinary returns the seed and the current state of the source, so a saved game carries on
+// with the same numbers it would have had.
+func (r *Random) MarshalBinary() ([]byte, error) {
+	state, err := r.pcg.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(binary.LittleEndian.AppendUint64(nil, r.seed), state...), nil
+}
+
+// UnmarshalBinary restores a source saved with MarshalBinary.
+func (r *Random) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("random state too short")
+	}
+	pcg := &rand.PCG{}
+	if err := pcg.UnmarshalBinary(data[8:]); err != nil {
+		return err
+	}
+	r.seed = binary.LittleEndian.Uint64(data[:8])
+	r.pcg = pcg
+	r.rnd = rand.New(pcg)
+	return nil
+}
+
+// Seed returns the seed this source was created with.
+func (r *Random) Seed() uint64 {
+	return r.seed
+}
 
 // RndFloat32 returns a random float32 between 0 and max. Panics if <= 0.
-func RndFloat32(max float32) float32 {
+func (r *Random) RndFloat32(max float32) float32 {
 	if max <= 0 {
 		panic("max must be greater than 0")
 	}
-	return rand.Float32() * max
+	return r.rnd.Float32() * max
 }
 
 // RndFloat32InRange returns a random float32 between min and max. Panics if min >= max.
-func RndFloat32InRange(min, max float32) float32 {
+func (r *Random) RndFloat32InRange(min, max float32) float32 {
 	if min >= max {
 		panic("min must be less than max")
 	}
-	return min + rand.Float32()*(max-min)
+	return min + r.rnd.Float32()*(max-min)
 }
 
 // RndIntInRange returns a random int between min and max. Panics if min >= max.
-func RndIntInRange(min, max int) int {
+func (r *Random) RndIntInRange(min, max int) int {
 	if min >= max {
 		panic("min must be less than max")
 	}
-	return min + rand.Intn(max-min)
+	return min + r.rnd.IntN(max-min)
 }
 
 // Chance returns true if a random number between 0 and 1 is less than chance.
 // Panics if chance is outside the range 0-1.
-func Chance(chance float32) bool {
+func (r *Random) Chance(chance float32) bool {
 	if chance < 0 || chance > 1 {
 		panic("chance must be between 0 and 1")
 	}
-	return rand.Float32() < chance
+	return r.rnd.Float32() < chance
 }
 
 // Choice returns a random element from the given slice.
-func Choice(float32s []float32) float32 {
-	return float32s[rand.Intn(len(float32s))]
+func (r *Random) Choice(float32s []float32) float32 {
+	return float32s[r.rnd.IntN(len(float32s))]
 }
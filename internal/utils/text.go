@@ -1,31 +1,17 @@
 package utils
 
 import (
+	"avoid_the_space_rocks/internal/platform"
 	rl "github.com/gen2brain/raylib-go/raylib"
-	"sync"
 )
 
-const spacing = 2.0
-
-var font rl.Font
-var once sync.Once
-
-func getFont() *rl.Font {
-	once.Do(func() {
-		font = rl.LoadFontEx("assets/fonts/Orbitron-Regular.ttf", 32, nil, 250)
-	})
-	return &font
-}
-
-// CenterText draws the given text centered around the passed-in position
-func CenterText(text string, position rl.Vector2, fontSize int) {
-	font := getFont()
-	textSize := rl.MeasureTextEx(*font, text, float32(fontSize), spacing)
+// CenterText draws the given text on p centered around the passed-in position
+func CenterText(p platform.Platform, text string, position rl.Vector2, fontSize int) {
+	textSize := p.MeasureText(text, float32(fontSize))
 	pos := rl.Vector2{X: position.X - textSize.X/2, Y: position.Y - textSize.Y/2}
-	WriteText(text, pos, fontSize)
+	WriteText(p, text, pos, fontSize)
 }
 
-func WriteText(text string, position rl.Vector2, fontSize int) {
-	font := getFont()
-	rl.DrawTextEx(*font, text, position, float32(fontSize), spacing, rl.Black)
+func WriteText(p platform.Platform, text string, position rl.Vector2, fontSize int) {
+	p.DrawText(text, position, float32(fontSize))
 }